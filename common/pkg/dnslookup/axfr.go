@@ -0,0 +1,150 @@
+package dnslookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	"github.com/miekg/dns"
+)
+
+const defaultTransferPort = "53"
+
+// WithTransferPort sets the port name servers are asked for zone transfers
+// on. Defaults to 53.
+func WithTransferPort(port string) Option {
+	return func(r *Resolver) {
+		r.transferPort = port
+	}
+}
+
+// dialTransfer dials a name server for a zone transfer.
+func (r *Resolver) dialTransfer(ctx context.Context, addr string) (net.Conn, error) {
+	if r.transferDialer != nil {
+		return r.transferDialer(ctx, addr)
+	}
+	d := &net.Dialer{Timeout: r.timeout}
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+// CheckZoneTransfer attempts a zone transfer (AXFR) of the looked up domain
+// against each of its name servers, whose addresses are resolved with the
+// configured resolvers. Failed attempts are reported in the result.
+func (r *Resolver) CheckZoneTransfer(ctx context.Context, lookup *tools.DNSLookupResult) *tools.ZoneTransferResult {
+	domain := trimDot(lookup.Domain)
+
+	var attempts []tools.ZoneTransferAttempt
+	for _, ns := range lookup.NameServers() {
+		if ctx.Err() != nil {
+			attempts = append(attempts, tools.ZoneTransferAttempt{NameServer: ns, Error: ctx.Err().Error()})
+			continue
+		}
+		attempts = append(attempts, r.transferZone(ctx, domain, ns))
+	}
+	return tools.NewZoneTransferResult(domain, attempts)
+}
+
+// transferZone attempts the transfer against each address of the name server
+// until one allows it.
+func (r *Resolver) transferZone(ctx context.Context, domain, ns string) tools.ZoneTransferAttempt {
+	attempt := tools.ZoneTransferAttempt{NameServer: ns}
+
+	addrs, err := r.resolveHost(ctx, ns)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	var errs []error
+	for _, addr := range addrs {
+		attempt.Address = addr
+		records, err := r.transfer(ctx, domain, addr)
+		if err == nil {
+			attempt.Allowed, attempt.Records = true, records
+			return attempt
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+	}
+	attempt.Error = errors.Join(errs...).Error()
+	return attempt
+}
+
+func (r *Resolver) transfer(ctx context.Context, domain, addr string) ([]tools.DNSRecord, error) {
+	msg := new(dns.Msg)
+	msg.SetAxfr(dns.Fqdn(domain))
+
+	conn, err := r.dialTransfer(ctx, net.JoinHostPort(addr, r.transferPort))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// Closing the connection on cancellation ends the transfer, and the
+	// envelopes channel, so the loop below never blocks past ctx
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	t := &dns.Transfer{Conn: &dns.Conn{Conn: conn}, ReadTimeout: r.timeout, WriteTimeout: r.timeout}
+	envelopes, err := t.In(msg, conn.RemoteAddr().String())
+	if err != nil {
+		return nil, err
+	}
+
+	records := []tools.DNSRecord{}
+	for env := range envelopes {
+		if ctx.Err() != nil {
+			// Drained until the closed connection ends the transfer
+			continue
+		}
+		if env.Error != nil {
+			err = env.Error
+			continue
+		}
+		for _, rr := range env.RR {
+			// The zone starts and ends with its SOA record
+			if record, ok := toDNSRecord(rr); ok && !containsRecord(records, record) {
+				records = append(records, record)
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty zone transfer")
+	}
+	return records, nil
+}
+
+// resolveHost returns the IPv4 and IPv6 addresses of the host.
+func (r *Resolver) resolveHost(ctx context.Context, host string) ([]string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{host}, nil
+	}
+
+	var addrs []string
+	var errs []error
+	for _, t := range []tools.DNSRecordType{tools.ARecord, tools.AAAARecord} {
+		records, err := r.Query(ctx, host, t)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, record := range records {
+			if addr, ok := record.AsString(); ok && record.Type == t {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	if len(addrs) == 0 {
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+		return nil, &net.DNSError{Err: "no address found", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
@@ -0,0 +1,162 @@
+package dnslookup
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// answerAXFR answers zone transfers with the whole zone, when allowed.
+func answerAXFR(f *fakeDNS, allowed bool) func(w dns.ResponseWriter, req *dns.Msg) bool {
+	return func(w dns.ResponseWriter, req *dns.Msg) bool {
+		if req.Question[0].Qtype != dns.TypeAXFR {
+			return false
+		}
+		resp := new(dns.Msg)
+		if !allowed {
+			resp.SetRcode(req, dns.RcodeRefused)
+			_ = w.WriteMsg(resp)
+			return true
+		}
+
+		resp.SetReply(req)
+		var soa dns.RR
+		for _, rr := range f.records {
+			if rr.Header().Rrtype == dns.TypeSOA {
+				soa = rr
+			}
+		}
+		resp.Answer = append(resp.Answer, soa)
+		for _, rr := range f.records {
+			if rr != soa && strings.HasSuffix(rr.Header().Name, "example.com.") {
+				resp.Answer = append(resp.Answer, rr)
+			}
+		}
+		resp.Answer = append(resp.Answer, soa)
+		_ = w.WriteMsg(resp)
+		return true
+	}
+}
+
+// withTransferAddrs makes the resolver dial the zone transfers of the name
+// server addresses to the given local servers, on the configured port.
+func withTransferAddrs(t *testing.T, port string, servers map[string]string) Option {
+	return func(r *Resolver) {
+		r.transferDialer = func(ctx context.Context, addr string) (net.Conn, error) {
+			host, p, err := net.SplitHostPort(addr)
+			require.NoError(t, err)
+			assert.Equal(t, port, p)
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", servers[host])
+		}
+	}
+}
+
+func Test_CheckZoneTransfer(t *testing.T) {
+	resolver := newFakeDNS(t)
+	for _, rr := range []string{
+		"ns1.example.com. 300 IN A 127.0.0.1",
+		"ns2.example.com. 300 IN A 192.0.2.53",
+		"ns3.example.com. 300 IN TXT \"no address\"",
+	} {
+		record, err := dns.NewRR(rr)
+		require.NoError(t, err)
+		resolver.records = append(resolver.records, record)
+	}
+	resolverAddr := resolver.start()
+
+	open := newFakeDNS(t)
+	open.handle = answerAXFR(open, true)
+	closed := newFakeDNS(t)
+	closed.handle = answerAXFR(closed, false)
+	servers := map[string]string{"127.0.0.1": open.startTCP(), "192.0.2.53": closed.startTCP()}
+
+	lookup := &tools.DNSLookupResult{
+		Domain: "example.com",
+		DNSRecords: []tools.DNSRecord{
+			{Type: tools.NSRecord, Name: "example.com", TTL: 86400, Value: "ns1.example.com"},
+			{Type: tools.NSRecord, Name: "example.com", TTL: 86400, Value: "ns2.example.com"},
+			{Type: tools.NSRecord, Name: "example.com", TTL: 86400, Value: "ns3.example.com"},
+			{Type: tools.NSRecord, Name: "sub.example.com", TTL: 86400, Value: "ns.sub.example.com"},
+		},
+	}
+	assert.Equal(t, []string{"ns1.example.com", "ns2.example.com", "ns3.example.com"}, lookup.NameServers())
+
+	r := NewResolver(WithServers(resolverAddr), WithTimeout(time.Second), WithTransferPort("5353"),
+		withTransferAddrs(t, "5353", servers))
+	res := r.CheckZoneTransfer(context.Background(), lookup)
+
+	assert.Equal(t, "example.com", res.Domain)
+	require.Len(t, res.Attempts, 3)
+	assert.Equal(t, []string{"ns1.example.com"}, res.AllowedNameServers())
+
+	leaked := res.Attempts[0]
+	assert.True(t, leaked.Allowed)
+	assert.Equal(t, "127.0.0.1", leaked.Address)
+	assert.Empty(t, leaked.Error)
+	// The SOA record closing the transfer is not repeated
+	assert.Equal(t, tools.SOARecord, leaked.Records[0].Type)
+	assert.Len(t, leaked.Records, len(open.records))
+	assert.Contains(t, leaked.Records, tools.DNSRecord{Type: tools.CNAMERecord, Name: "www.example.com", TTL: 300, Value: "example.com"})
+
+	refused := res.Attempts[1]
+	assert.False(t, refused.Allowed)
+	assert.Equal(t, "192.0.2.53", refused.Address)
+	assert.Empty(t, refused.Records)
+	assert.NotEmpty(t, refused.Error)
+
+	assert.False(t, res.Attempts[2].Allowed)
+	assert.Contains(t, res.Attempts[2].Error, "lookup ns3.example.com: no address found")
+
+	require.Len(t, res.Findings, 1)
+	finding := res.Findings[0]
+	assert.Equal(t, tools.FindingZoneTransfer, finding.ID)
+	assert.Equal(t, enums.OwaspCategorySecurityMisconfiguration, finding.Category)
+	assert.Equal(t, enums.SeverityTypeMedium, finding.Severity)
+	assert.Contains(t, finding.Description, "ns1.example.com")
+	assert.Equal(t, "AXFR example.com @127.0.0.1", finding.Evidence)
+
+	// Results are carried by the lookup result
+	lookup.ZoneTransfer = res
+	assert.Equal(t, 1, tools.GetFindingSeverityCounts(lookup.ZoneTransfer.Findings).Medium)
+}
+
+func Test_CheckZoneTransferCancel(t *testing.T) {
+	resolver := newFakeDNS(t)
+	record, err := dns.NewRR("ns1.example.com. 300 IN A 127.0.0.1")
+	require.NoError(t, err)
+	resolver.records = append(resolver.records, record)
+	resolverAddr := resolver.start()
+
+	// The name server never answers the transfer
+	stalled := newFakeDNS(t)
+	release := make(chan struct{})
+	stalled.handle = func(w dns.ResponseWriter, req *dns.Msg) bool {
+		<-release
+		return true
+	}
+	servers := map[string]string{"127.0.0.1": stalled.startTCP()}
+	t.Cleanup(func() { close(release) })
+
+	r := NewResolver(WithServers(resolverAddr), WithTimeout(time.Minute), withTransferAddrs(t, defaultTransferPort, servers))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	res := r.CheckZoneTransfer(ctx, &tools.DNSLookupResult{
+		Domain:     "example.com",
+		DNSRecords: []tools.DNSRecord{{Type: tools.NSRecord, Name: "example.com", TTL: 86400, Value: "ns1.example.com"}},
+	})
+	assert.Less(t, time.Since(start), 5*time.Second)
+	require.Len(t, res.Attempts, 1)
+	assert.False(t, res.Attempts[0].Allowed)
+	assert.Contains(t, res.Attempts[0].Error, context.Canceled.Error())
+}
@@ -0,0 +1,168 @@
+package dnslookup
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	"github.com/miekg/dns"
+)
+
+// recordTypes maps the supported record types to their wire types.
+var recordTypes = map[tools.DNSRecordType]uint16{
+	tools.ARecord:      dns.TypeA,
+	tools.AAAARecord:   dns.TypeAAAA,
+	tools.CNAMERecord:  dns.TypeCNAME,
+	tools.TXTRecord:    dns.TypeTXT,
+	tools.NSRecord:     dns.TypeNS,
+	tools.MXRecord:     dns.TypeMX,
+	tools.SOARecord:    dns.TypeSOA,
+	tools.DNSKeyRecord: dns.TypeDNSKEY,
+	tools.CAARecord:    dns.TypeCAA,
+	tools.SRVRecord:    dns.TypeSRV,
+	tools.PTRRecord:    dns.TypePTR,
+	tools.DSRecord:     dns.TypeDS,
+	tools.RRSIGRecord:  dns.TypeRRSIG,
+	tools.NSECRecord:   dns.TypeNSEC,
+	tools.NSEC3Record:  dns.TypeNSEC3,
+	tools.SVCBRecord:   dns.TypeSVCB,
+	tools.HTTPSRecord:  dns.TypeHTTPS,
+}
+
+// recordType returns the record type of a wire type. Unsupported types are
+// named after their mnemonic, e.g., "TLSA".
+func recordType(t uint16) tools.DNSRecordType {
+	for recordType, wireType := range recordTypes {
+		if wireType == t {
+			return recordType
+		}
+	}
+	return tools.DNSRecordType(dns.Type(t).String())
+}
+
+func recordTypes16(types []uint16) []tools.DNSRecordType {
+	converted := make([]tools.DNSRecordType, 0, len(types))
+	for _, t := range types {
+		converted = append(converted, recordType(t))
+	}
+	return converted
+}
+
+// toDNSRecord converts a resource record of a supported type.
+func toDNSRecord(rr dns.RR) (tools.DNSRecord, bool) {
+	hdr := rr.Header()
+	record := tools.DNSRecord{
+		Name: trimDot(hdr.Name),
+		TTL:  int(hdr.Ttl),
+	}
+
+	switch v := rr.(type) {
+	case *dns.A:
+		record.Type, record.Value = tools.ARecord, v.A.String()
+	case *dns.AAAA:
+		record.Type, record.Value = tools.AAAARecord, v.AAAA.String()
+	case *dns.CNAME:
+		record.Type, record.Value = tools.CNAMERecord, trimDot(v.Target)
+	case *dns.TXT:
+		// Long TXT records are split into several strings
+		record.Type, record.Value = tools.TXTRecord, strings.Join(v.Txt, "")
+	case *dns.NS:
+		record.Type, record.Value = tools.NSRecord, trimDot(v.Ns)
+	case *dns.PTR:
+		record.Type, record.Value = tools.PTRRecord, trimDot(v.Ptr)
+	case *dns.MX:
+		priority := int(v.Preference)
+		record.Type, record.Priority = tools.MXRecord, &priority
+		record.Value = tools.MailExchange{Host: trimDot(v.Mx), Priority: priority}
+	case *dns.SOA:
+		record.Type = tools.SOARecord
+		record.Value = tools.StartOfAuthority{
+			PrimaryNS:  trimDot(v.Ns),
+			AdminEmail: trimDot(v.Mbox),
+			Serial:     int(v.Serial),
+			Refresh:    int(v.Refresh),
+			Retry:      int(v.Retry),
+			Expire:     int(v.Expire),
+			MinimumTTL: int(v.Minttl),
+		}
+	case *dns.DNSKEY:
+		record.Type = tools.DNSKeyRecord
+		record.Value = tools.DNSKey{
+			Flags:     int(v.Flags),
+			Protocol:  int(v.Protocol),
+			Algorithm: int(v.Algorithm),
+			KeyTag:    int(v.KeyTag()),
+			PublicKey: v.PublicKey,
+		}
+	case *dns.CAA:
+		record.Type = tools.CAARecord
+		record.Value = tools.CertificationAuthorityAuthorization{Flag: int(v.Flag), Tag: v.Tag, Value: v.Value}
+	case *dns.SRV:
+		record.Type = tools.SRVRecord
+		record.Value = tools.ServiceLocation{
+			Priority: int(v.Priority),
+			Weight:   int(v.Weight),
+			Port:     int(v.Port),
+			Target:   trimDot(v.Target),
+		}
+	case *dns.DS:
+		record.Type = tools.DSRecord
+		record.Value = tools.DelegationSigner{
+			KeyTag:     int(v.KeyTag),
+			Algorithm:  int(v.Algorithm),
+			DigestType: int(v.DigestType),
+			Digest:     strings.ToLower(v.Digest),
+		}
+	case *dns.RRSIG:
+		record.Type = tools.RRSIGRecord
+		record.Value = tools.RecordSignature{
+			TypeCovered: recordType(v.TypeCovered),
+			Algorithm:   int(v.Algorithm),
+			Labels:      int(v.Labels),
+			OriginalTTL: int(v.OrigTtl),
+			Expiration:  time.Unix(int64(v.Expiration), 0).UTC(),
+			Inception:   time.Unix(int64(v.Inception), 0).UTC(),
+			KeyTag:      int(v.KeyTag),
+			SignerName:  trimDot(v.SignerName),
+			Signature:   v.Signature,
+		}
+	case *dns.NSEC:
+		record.Type = tools.NSECRecord
+		record.Value = tools.NextSecure{NextDomain: trimDot(v.NextDomain), Types: recordTypes16(v.TypeBitMap)}
+	case *dns.NSEC3:
+		record.Type = tools.NSEC3Record
+		record.Value = tools.NextSecure3{
+			HashAlgorithm: int(v.Hash),
+			Flags:         int(v.Flags),
+			Iterations:    int(v.Iterations),
+			Salt:          strings.TrimPrefix(v.Salt, "-"),
+			NextHashed:    v.NextDomain,
+			Types:         recordTypes16(v.TypeBitMap),
+		}
+	case *dns.SVCB:
+		record.Type, record.Value = tools.SVCBRecord, toServiceBinding(v)
+	case *dns.HTTPS:
+		record.Type, record.Value = tools.HTTPSRecord, toServiceBinding(&v.SVCB)
+	default:
+		return tools.DNSRecord{}, false
+	}
+	return record, true
+}
+
+func toServiceBinding(v *dns.SVCB) tools.ServiceBinding {
+	binding := tools.ServiceBinding{Priority: int(v.Priority), Target: v.Target}
+	if binding.Target != "." {
+		binding.Target = trimDot(binding.Target)
+	}
+	if len(v.Value) > 0 {
+		binding.Params = make(map[string]string, len(v.Value))
+		for _, kv := range v.Value {
+			binding.Params[kv.Key().String()] = kv.String()
+		}
+	}
+	return binding
+}
+
+func trimDot(name string) string {
+	return strings.TrimSuffix(name, ".")
+}
@@ -0,0 +1,342 @@
+// Package dnslookup queries the DNS records of a domain against configurable
+// resolvers and reports them as a tools.DNSLookupResult.
+package dnslookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	"github.com/miekg/dns"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	defaultRetries = 2
+
+	// ednsBufferSize is the UDP payload size advertised to resolvers, as
+	// recommended by DNS Flag Day 2020.
+	ednsBufferSize = 1232
+)
+
+// Networks supported by resolvers.
+const (
+	NetworkUDP = "udp"
+	NetworkTCP = "tcp"
+)
+
+// DefaultRecordTypes are the record types queried by Lookup unless
+// WithRecordTypes is given. RRSIG, NSEC and NSEC3 records are collected from
+// the answers to the other queries.
+var DefaultRecordTypes = []tools.DNSRecordType{
+	tools.ARecord,
+	tools.AAAARecord,
+	tools.CNAMERecord,
+	tools.TXTRecord,
+	tools.NSRecord,
+	tools.MXRecord,
+	tools.SOARecord,
+	tools.CAARecord,
+	tools.DNSKeyRecord,
+	tools.DSRecord,
+	tools.HTTPSRecord,
+}
+
+// ErrUnsupportedRecordType is returned when querying a record type without a
+// typed model in tools.
+var ErrUnsupportedRecordType = errors.New("unsupported record type")
+
+// ResolverError is returned when no resolver answered a query.
+type ResolverError struct {
+	Name string
+	Type tools.DNSRecordType
+	Err  error
+}
+
+func (e *ResolverError) Error() string {
+	return fmt.Sprintf("failed to query %s records of %s: %v", e.Type, e.Name, e.Err)
+}
+
+func (e *ResolverError) Unwrap() error {
+	return e.Err
+}
+
+// Resolver queries DNS records. It is safe for concurrent use.
+type Resolver struct {
+	servers        []string
+	network        string
+	timeout        time.Duration
+	retries        int
+	recordTypes    []tools.DNSRecordType
+	dkimSelectors  []string
+	now            func() time.Time
+	udpClient      *dns.Client
+	tcpClient      *dns.Client
+	maxConcurrency int
+	transferPort   string
+	// transferDialer replaces the dialer of zone transfers in tests.
+	transferDialer func(ctx context.Context, addr string) (net.Conn, error)
+}
+
+// Option configures optional behaviour of a Resolver.
+type Option func(*Resolver)
+
+// WithServers sets the resolvers to query, as "host:port" addresses. They
+// are tried in order until one answers. Defaults to tools.GoogleResolver.
+func WithServers(servers ...string) Option {
+	return func(r *Resolver) {
+		r.servers = slices.Clone(servers)
+	}
+}
+
+// WithNetwork sets the network used to query resolvers, NetworkUDP or
+// NetworkTCP. Truncated UDP answers are retried over TCP. Defaults to NetworkUDP.
+func WithNetwork(network string) Option {
+	return func(r *Resolver) {
+		r.network = network
+	}
+}
+
+// WithTimeout sets the timeout of each query attempt.
+func WithTimeout(d time.Duration) Option {
+	return func(r *Resolver) {
+		r.timeout = d
+	}
+}
+
+// WithRetries sets how many times a query is retried against a resolver
+// which does not respond before moving on to the next one.
+func WithRetries(n int) Option {
+	return func(r *Resolver) {
+		r.retries = n
+	}
+}
+
+// WithRecordTypes sets the record types queried by Lookup.
+func WithRecordTypes(types ...tools.DNSRecordType) Option {
+	return func(r *Resolver) {
+		r.recordTypes = slices.Clone(types)
+	}
+}
+
+// WithDKIMSelectors makes Lookup query the DKIM records of the given
+// selectors, e.g., tools.CommonDKIMSelectors.
+func WithDKIMSelectors(selectors ...string) Option {
+	return func(r *Resolver) {
+		r.dkimSelectors = slices.Clone(selectors)
+	}
+}
+
+// WithMaxConcurrency sets how many queries Lookup runs at once.
+func WithMaxConcurrency(n int) Option {
+	return func(r *Resolver) {
+		r.maxConcurrency = n
+	}
+}
+
+// WithClock sets the function used to get the current time.
+func WithClock(now func() time.Time) Option {
+	return func(r *Resolver) {
+		r.now = now
+	}
+}
+
+// NewResolver creates a Resolver.
+func NewResolver(opts ...Option) *Resolver {
+	r := &Resolver{
+		servers:        []string{tools.GoogleResolver},
+		network:        NetworkUDP,
+		timeout:        defaultTimeout,
+		retries:        defaultRetries,
+		recordTypes:    slices.Clone(DefaultRecordTypes),
+		now:            time.Now,
+		maxConcurrency: 8,
+		transferPort:   defaultTransferPort,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.udpClient = &dns.Client{Net: NetworkUDP, Timeout: r.timeout, UDPSize: ednsBufferSize}
+	r.tcpClient = &dns.Client{Net: NetworkTCP, Timeout: r.timeout}
+	return r
+}
+
+// Lookup queries the records of the domain, its DMARC record and the DKIM
+// records of the configured selectors. The result holds the records of the
+// successful queries even when some fail, in which case the returned error
+// joins the failures and is reported in the result Error.
+func (r *Resolver) Lookup(ctx context.Context, domain string) (*tools.DNSLookupResult, error) {
+	domain = trimDot(domain)
+	start := r.now()
+
+	type query struct {
+		name string
+		t    tools.DNSRecordType
+	}
+	queries := make([]query, 0, len(r.recordTypes)+1+len(r.dkimSelectors))
+	for _, t := range r.recordTypes {
+		queries = append(queries, query{domain, t})
+	}
+	queries = append(queries, query{tools.DMARCRecordName(domain), tools.TXTRecord})
+	for _, selector := range r.dkimSelectors {
+		queries = append(queries, query{tools.DKIMRecordName(selector, domain), tools.TXTRecord})
+	}
+
+	answers := make([][]tools.DNSRecord, len(queries))
+	errs := make([]error, len(queries))
+	sem := make(chan struct{}, max(r.maxConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			answers[i], errs[i] = r.Query(ctx, q.name, q.t)
+		}()
+	}
+	wg.Wait()
+
+	res := &tools.DNSLookupResult{
+		Domain:     domain,
+		DNSRecords: []tools.DNSRecord{},
+		CreatedAt:  start,
+	}
+	for _, records := range answers {
+		for _, record := range records {
+			if !containsRecord(res.DNSRecords, record) {
+				res.DNSRecords = append(res.DNSRecords, record)
+			}
+		}
+	}
+	res.UpdateDNSSEC(start)
+	res.LookupDuration = r.now().Sub(start)
+
+	err := errors.Join(errs...)
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res, err
+}
+
+// Query queries the records of the given type. Signatures and denial of
+// existence records returned along are included. A name without records of
+// the type yields no records and no error.
+func (r *Resolver) Query(ctx context.Context, name string, t tools.DNSRecordType) ([]tools.DNSRecord, error) {
+	qtype, ok := recordTypes[t]
+	if !ok {
+		return nil, &ResolverError{Name: name, Type: t, Err: ErrUnsupportedRecordType}
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	// Ask for RRSIG and NSEC records
+	msg.SetEdns0(ednsBufferSize, true)
+
+	resp, err := r.exchange(ctx, msg)
+	if err != nil {
+		return nil, &ResolverError{Name: name, Type: t, Err: err}
+	}
+
+	records := []tools.DNSRecord{}
+	for _, rr := range resp.Answer {
+		if record, ok := toDNSRecord(rr); ok {
+			records = append(records, record)
+		}
+	}
+	for _, rr := range resp.Ns {
+		switch rr.(type) {
+		case *dns.NSEC, *dns.NSEC3:
+			if record, ok := toDNSRecord(rr); ok {
+				records = append(records, record)
+			}
+		}
+	}
+	return records, nil
+}
+
+// exchange sends the query to each resolver in turn until one answers.
+func (r *Resolver) exchange(ctx context.Context, msg *dns.Msg) (*dns.Msg, error) {
+	if len(r.servers) == 0 {
+		return nil, errors.New("no resolvers configured")
+	}
+
+	var errs []error
+	for _, server := range r.servers {
+		resp, err := r.exchangeWithRetries(ctx, msg, server)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", server, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (r *Resolver) exchangeWithRetries(ctx context.Context, msg *dns.Msg, server string) (*dns.Msg, error) {
+	client := r.udpClient
+	if r.network == NetworkTCP {
+		client = r.tcpClient
+	}
+
+	var err error
+	for attempt := 0; attempt <= r.retries; attempt++ {
+		var resp *dns.Msg
+		resp, _, err = client.ExchangeContext(ctx, msg, server)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if resp.Truncated && client == r.udpClient {
+			if resp, _, err = r.tcpClient.ExchangeContext(ctx, msg, server); err != nil {
+				continue
+			}
+		}
+
+		switch resp.Rcode {
+		case dns.RcodeSuccess, dns.RcodeNameError:
+			return resp, nil
+		default:
+			// Resolvers failing or refusing to answer are not retried
+			return nil, fmt.Errorf("resolver answered %s", dns.RcodeToString[resp.Rcode])
+		}
+	}
+	return nil, err
+}
+
+func containsRecord(records []tools.DNSRecord, record tools.DNSRecord) bool {
+	for _, r := range records {
+		if r.Type == record.Type && r.Name == record.Name && r.TTL == record.TTL && valuesEqual(r.Value, record.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuesEqual compares record values, which hold comparable types except for
+// the slices and maps of NSEC, NSEC3 and service binding records.
+func valuesEqual(a, b any) bool {
+	switch va := a.(type) {
+	case tools.NextSecure:
+		vb, ok := b.(tools.NextSecure)
+		return ok && va.NextDomain == vb.NextDomain && slices.Equal(va.Types, vb.Types)
+	case tools.NextSecure3:
+		vb, ok := b.(tools.NextSecure3)
+		return ok && va.NextHashed == vb.NextHashed && va.Salt == vb.Salt && slices.Equal(va.Types, vb.Types)
+	case tools.ServiceBinding:
+		vb, ok := b.(tools.ServiceBinding)
+		return ok && va.Priority == vb.Priority && va.Target == vb.Target && maps.Equal(va.Params, vb.Params)
+	default:
+		return a == b
+	}
+}
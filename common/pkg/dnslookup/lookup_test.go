@@ -0,0 +1,346 @@
+package dnslookup
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testZone = `
+example.com.             300   IN  A      93.184.216.34
+example.com.             300   IN  AAAA   2606:2800:220:1:248:1893:25c8:1946
+example.com.             300   IN  TXT    "v=spf1 include:_spf.google.com " "-all"
+example.com.             300   IN  TXT    "google-site-verification=abc"
+example.com.             86400 IN  NS     a.iana-servers.net.
+example.com.             300   IN  MX     10 mail.example.com.
+example.com.             3600  IN  SOA    ns.icann.org. noc.dns.icann.org. 2024081426 7200 3600 1209600 3600
+example.com.             3600  IN  CAA    0 issue "letsencrypt.org"
+example.com.             300   IN  HTTPS  1 . alpn="h2,h3" ipv4hint=93.184.216.34
+example.com.             3600  IN  DNSKEY 257 3 13 mdsswUyr3DPW132mOi8V9xESWE8jTo0dxCjjnopKl+GqJxpVXckHAeF+KkxLbxILfDLUT0rAK9iUzy1L53eKGQ==
+_dmarc.example.com.      300   IN  TXT    "v=DMARC1; p=reject; rua=mailto:dmarc@example.com"
+_sip._tcp.example.com.   300   IN  SRV    10 60 5060 sip.example.com.
+www.example.com.         300   IN  CNAME  example.com.
+`
+
+// fakeDNS is an authoritative DNS server for testZone listening on the same
+// UDP and TCP port.
+type fakeDNS struct {
+	t       *testing.T
+	records []dns.RR
+	// handle overrides the answers of the zone when set.
+	handle  func(w dns.ResponseWriter, req *dns.Msg) bool
+	queries atomic.Int32
+}
+
+func newFakeDNS(t *testing.T) *fakeDNS {
+	t.Helper()
+
+	f := &fakeDNS{t: t}
+	zp := dns.NewZoneParser(strings.NewReader(testZone), "", "")
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		f.records = append(f.records, rr)
+	}
+	require.NoError(t, zp.Err())
+
+	// The DS record and the signature of the A record refer to the zone key
+	key := f.records[9].(*dns.DNSKEY)
+	f.records = append(f.records, key.ToDS(dns.SHA256), &dns.RRSIG{
+		Hdr:         dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: 300},
+		TypeCovered: dns.TypeA,
+		Algorithm:   dns.ECDSAP256SHA256,
+		Labels:      2,
+		OrigTtl:     300,
+		Expiration:  uint32(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC).Unix()),
+		Inception:   uint32(time.Date(2025, 1, 11, 0, 0, 0, 0, time.UTC).Unix()),
+		KeyTag:      key.KeyTag(),
+		SignerName:  "example.com.",
+		Signature:   "c2lnbmF0dXJl",
+	})
+	return f
+}
+
+func (f *fakeDNS) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	f.queries.Add(1)
+	if f.handle != nil && f.handle(w, req) {
+		return
+	}
+
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	resp.Authoritative = true
+
+	q := req.Question[0]
+	exists := false
+	for _, rr := range f.records {
+		hdr := rr.Header()
+		if !strings.EqualFold(hdr.Name, q.Name) {
+			continue
+		}
+		exists = true
+		if hdr.Rrtype == q.Qtype {
+			resp.Answer = append(resp.Answer, rr)
+		}
+	}
+	// Signatures are only sent to clients asking for them
+	if opt := req.IsEdns0(); opt != nil && opt.Do() {
+		for _, rr := range f.records {
+			if sig, ok := rr.(*dns.RRSIG); ok && strings.EqualFold(sig.Hdr.Name, q.Name) && sig.TypeCovered == q.Qtype {
+				resp.Answer = append(resp.Answer, sig)
+			}
+		}
+	}
+	if !exists {
+		resp.Rcode = dns.RcodeNameError
+	}
+	_ = w.WriteMsg(resp)
+}
+
+// start serves the zone on a random port and returns its address.
+func (f *fakeDNS) start() string {
+	f.t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(f.t, err)
+	l, err := net.Listen("tcp", pc.LocalAddr().String())
+	require.NoError(f.t, err)
+
+	f.serve(&dns.Server{PacketConn: pc, Handler: f})
+	f.serve(&dns.Server{Listener: l, Handler: f})
+	return pc.LocalAddr().String()
+}
+
+// startTCP serves the zone over TCP only, and returns its address.
+func (f *fakeDNS) startTCP() string {
+	f.t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(f.t, err)
+	f.serve(&dns.Server{Listener: l, Handler: f})
+	return l.Addr().String()
+}
+
+func (f *fakeDNS) serve(srv *dns.Server) {
+	started := make(chan struct{})
+	srv.NotifyStartedFunc = func() { close(started) }
+	go func() { _ = srv.ActivateAndServe() }()
+	<-started
+	f.t.Cleanup(func() { _ = srv.Shutdown() })
+}
+
+// closedAddr returns the address of a port nobody listens on.
+func closedAddr(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := pc.LocalAddr().String()
+	require.NoError(t, pc.Close())
+	return addr
+}
+
+func isUDP(w dns.ResponseWriter) bool {
+	_, ok := w.RemoteAddr().(*net.UDPAddr)
+	return ok
+}
+
+func Test_Lookup(t *testing.T) {
+	addr := newFakeDNS(t).start()
+	start := time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	clock := func() time.Time {
+		calls++
+		return start.Add(time.Duration(calls-1) * 250 * time.Millisecond)
+	}
+
+	r := NewResolver(WithServers(addr), WithTimeout(time.Second), WithClock(clock), WithDKIMSelectors("google", "selector1"))
+	res, err := r.Lookup(context.Background(), "example.com.")
+	require.NoError(t, err)
+
+	assert.Equal(t, "example.com", res.Domain)
+	assert.Equal(t, start, res.CreatedAt)
+	assert.Equal(t, 250*time.Millisecond, res.LookupDuration)
+	assert.Empty(t, res.Error)
+	assert.Equal(t, tools.DNSSECStatusDelegated, res.DNSSECStatus)
+	assert.True(t, res.DNSSECEnabled)
+
+	byType := map[tools.DNSRecordType][]tools.DNSRecord{}
+	for _, record := range res.DNSRecords {
+		byType[record.Type] = append(byType[record.Type], record)
+	}
+	assert.Equal(t, []tools.DNSRecord{{Type: tools.ARecord, Name: "example.com", TTL: 300, Value: "93.184.216.34"}}, byType[tools.ARecord])
+	assert.Len(t, byType[tools.AAAARecord], 1)
+	assert.Empty(t, byType[tools.SRVRecord], "SRV records are not queried by default")
+
+	mx, ok := byType[tools.MXRecord][0].AsMX()
+	require.True(t, ok)
+	assert.Equal(t, tools.MailExchange{Host: "mail.example.com", Priority: 10}, mx)
+	assert.Equal(t, 10, *byType[tools.MXRecord][0].Priority)
+
+	soa, ok := byType[tools.SOARecord][0].AsSOA()
+	require.True(t, ok)
+	assert.Equal(t, "ns.icann.org", soa.PrimaryNS)
+	assert.Equal(t, 2024081426, soa.Serial)
+
+	caa, ok := byType[tools.CAARecord][0].AsCAA()
+	require.True(t, ok)
+	assert.Equal(t, "letsencrypt.org", caa.Value)
+
+	https, ok := byType[tools.HTTPSRecord][0].AsServiceBinding()
+	require.True(t, ok)
+	assert.Equal(t, tools.ServiceBinding{Priority: 1, Target: ".", Params: map[string]string{"alpn": "h2,h3", "ipv4hint": "93.184.216.34"}}, https)
+
+	key, ok := byType[tools.DNSKeyRecord][0].AsDNSKey()
+	require.True(t, ok)
+	ds, ok := byType[tools.DSRecord][0].AsDS()
+	require.True(t, ok)
+	assert.Equal(t, key.KeyTag, ds.KeyTag)
+	assert.NotZero(t, key.KeyTag)
+
+	sig, ok := byType[tools.RRSIGRecord][0].AsRRSIG()
+	require.True(t, ok)
+	assert.Equal(t, tools.ARecord, sig.TypeCovered)
+	assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), sig.Expiration)
+
+	// TXT strings are joined, and DMARC records are looked up along
+	var txts []string
+	for _, record := range byType[tools.TXTRecord] {
+		txt, _ := record.AsString()
+		txts = append(txts, record.Name+" "+txt)
+	}
+	assert.Equal(t, []string{
+		"example.com v=spf1 include:_spf.google.com -all",
+		"example.com google-site-verification=abc",
+		"_dmarc.example.com v=DMARC1; p=reject; rua=mailto:dmarc@example.com",
+	}, txts)
+	assert.Empty(t, res.EmailSecurity().Findings)
+}
+
+func Test_Query(t *testing.T) {
+	addr := newFakeDNS(t).start()
+	r := NewResolver(WithServers(addr), WithTimeout(time.Second))
+
+	records, err := r.Query(context.Background(), "_sip._tcp.example.com", tools.SRVRecord)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	srv, ok := records[0].AsSRV()
+	require.True(t, ok)
+	assert.Equal(t, tools.ServiceLocation{Priority: 10, Weight: 60, Port: 5060, Target: "sip.example.com"}, srv)
+
+	// Names without records are not errors
+	records, err = r.Query(context.Background(), "missing.example.com", tools.ARecord)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	records, err = r.Query(context.Background(), "www.example.com", tools.CNAMERecord)
+	require.NoError(t, err)
+	assert.Equal(t, []tools.DNSRecord{{Type: tools.CNAMERecord, Name: "www.example.com", TTL: 300, Value: "example.com"}}, records)
+
+	_, err = r.Query(context.Background(), "example.com", "TLSA")
+	assert.ErrorIs(t, err, ErrUnsupportedRecordType)
+}
+
+func Test_QueryTransport(t *testing.T) {
+	t.Run("Truncated answers are retried over TCP", func(t *testing.T) {
+		f := newFakeDNS(t)
+		f.handle = func(w dns.ResponseWriter, req *dns.Msg) bool {
+			if !isUDP(w) {
+				return false
+			}
+			resp := new(dns.Msg)
+			resp.SetReply(req)
+			resp.Truncated = true
+			_ = w.WriteMsg(resp)
+			return true
+		}
+		r := NewResolver(WithServers(f.start()), WithTimeout(time.Second))
+
+		records, err := r.Query(context.Background(), "example.com", tools.AAAARecord)
+		require.NoError(t, err)
+		assert.Len(t, records, 1)
+		assert.Equal(t, int32(2), f.queries.Load())
+	})
+
+	t.Run("TCP only", func(t *testing.T) {
+		f := newFakeDNS(t)
+		f.handle = func(w dns.ResponseWriter, req *dns.Msg) bool {
+			if isUDP(w) {
+				t.Errorf("unexpected UDP query")
+			}
+			return false
+		}
+		r := NewResolver(WithServers(f.start()), WithNetwork(NetworkTCP), WithTimeout(time.Second))
+
+		records, err := r.Query(context.Background(), "example.com", tools.NSRecord)
+		require.NoError(t, err)
+		assert.Len(t, records, 1)
+	})
+
+	t.Run("Unanswered queries are retried", func(t *testing.T) {
+		f := newFakeDNS(t)
+		f.handle = func(w dns.ResponseWriter, req *dns.Msg) bool {
+			// Drop the first query
+			return f.queries.Load() == 1
+		}
+		r := NewResolver(WithServers(f.start()), WithTimeout(100*time.Millisecond), WithRetries(1))
+
+		records, err := r.Query(context.Background(), "example.com", tools.AAAARecord)
+		require.NoError(t, err)
+		assert.Len(t, records, 1)
+		assert.Equal(t, int32(2), f.queries.Load())
+	})
+}
+
+func Test_QueryFailover(t *testing.T) {
+	failing := newFakeDNS(t)
+	failing.handle = func(w dns.ResponseWriter, req *dns.Msg) bool {
+		resp := new(dns.Msg)
+		resp.SetRcode(req, dns.RcodeServerFailure)
+		_ = w.WriteMsg(resp)
+		return true
+	}
+	failingAddr := failing.start()
+	working := newFakeDNS(t)
+
+	r := NewResolver(WithServers(closedAddr(t), failingAddr, working.start()), WithTimeout(200*time.Millisecond), WithRetries(0))
+	records, err := r.Query(context.Background(), "example.com", tools.AAAARecord)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, int32(1), failing.queries.Load(), "failing resolvers are not retried")
+
+	// All resolvers failing
+	r = NewResolver(WithServers(failingAddr), WithRetries(0))
+	_, err = r.Query(context.Background(), "example.com", tools.ARecord)
+	var resolverErr *ResolverError
+	require.ErrorAs(t, err, &resolverErr)
+	assert.Equal(t, tools.ARecord, resolverErr.Type)
+	assert.ErrorContains(t, err, "SERVFAIL")
+
+	// Partial failures are reported in the result
+	refusing := newFakeDNS(t)
+	refusing.handle = func(w dns.ResponseWriter, req *dns.Msg) bool {
+		if req.Question[0].Qtype != dns.TypeMX {
+			return false
+		}
+		resp := new(dns.Msg)
+		resp.SetRcode(req, dns.RcodeRefused)
+		_ = w.WriteMsg(resp)
+		return true
+	}
+	r = NewResolver(WithServers(refusing.start()), WithRecordTypes(tools.ARecord, tools.MXRecord))
+	res, err := r.Lookup(context.Background(), "example.com")
+	require.Error(t, err)
+	assert.Contains(t, res.Error, "failed to query MX records of example.com")
+	assert.Len(t, res.DNSRecords, 3, "A record, its signature and the DMARC record")
+	assert.Equal(t, tools.DNSSECStatusUnsigned, res.DNSSECStatus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewResolver(WithServers(working.start())).Query(ctx, "example.com", tools.ARecord)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,122 @@
+package dnslookup
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+)
+
+const (
+	// wildcardProbes is how many random names are resolved to detect
+	// wildcard DNS records.
+	wildcardProbes = 2
+
+	enrichConcurrency = 8
+)
+
+// HostResolver resolves host names to IP addresses. It is implemented by
+// *net.Resolver and *Resolver. Hosts without addresses are reported with a
+// *net.DNSError whose IsNotFound is true.
+type HostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// LookupHost returns the IPv4 and IPv6 addresses of the host.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return r.resolveHost(ctx, trimDot(host))
+}
+
+// EnrichSubdomains resolves the subdomains of the domain, e.g., the ones
+// harvested by OSINT sources, to tell the live ones from the dead ones and
+// from wildcard DNS noise. Names outside the domain are ignored.
+func EnrichSubdomains(ctx context.Context, resolver HostResolver, domain string, subdomains []string) *tools.SubdomainEnrichment {
+	domain = strings.ToLower(trimDot(domain))
+
+	var names []string
+	for _, name := range subdomains {
+		name = strings.ToLower(trimDot(strings.TrimSpace(name)))
+		if (name == domain || strings.HasSuffix(name, "."+domain)) && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	enriched := make([]tools.EnrichedSubdomain, len(names))
+	sem := make(chan struct{}, enrichConcurrency)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			enriched[i] = resolveSubdomain(ctx, resolver, name)
+		}()
+	}
+	wildcardIPs, wildcardErr := detectWildcard(ctx, resolver, domain)
+	wg.Wait()
+
+	res := tools.NewSubdomainEnrichment(domain, wildcardIPs, enriched)
+	if wildcardErr != nil {
+		res.WildcardError = wildcardErr.Error()
+	}
+	return res
+}
+
+func resolveSubdomain(ctx context.Context, resolver HostResolver, name string) tools.EnrichedSubdomain {
+	s := tools.EnrichedSubdomain{Name: name}
+	addrs, err := resolver.LookupHost(ctx, name)
+	switch {
+	case err == nil && len(addrs) > 0:
+		s.IPs, s.Status = addrs, tools.SubdomainLive
+	case err == nil || isNotFound(err):
+		s.Status = tools.SubdomainDead
+	default:
+		s.Status, s.Error = tools.SubdomainUnresolved, err.Error()
+	}
+	return s
+}
+
+// detectWildcard returns the addresses random names of the domain resolve
+// to, if any. It returns the error of the last probe when no probe could be
+// resolved, in which case whether the domain has wildcard records is unknown.
+func detectWildcard(ctx context.Context, resolver HostResolver, domain string) ([]string, error) {
+	var ips []string
+	var lastErr error
+	resolved := false
+	for range wildcardProbes {
+		addrs, err := resolver.LookupHost(ctx, randomLabel()+"."+domain)
+		if err != nil && !isNotFound(err) {
+			lastErr = err
+			continue
+		}
+		resolved = true
+		for _, addr := range addrs {
+			if !slices.Contains(ips, addr) {
+				ips = append(ips, addr)
+			}
+		}
+	}
+	if !resolved {
+		return nil, fmt.Errorf("failed to detect wildcard records: %w", lastErr)
+	}
+	return ips, nil
+}
+
+func randomLabel() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "kptm-" + hex.EncodeToString(b)
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
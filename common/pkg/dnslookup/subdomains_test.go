@@ -0,0 +1,127 @@
+package dnslookup
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHostResolver answers from hosts, and with wildcard for the other names
+// of its domain.
+type fakeHostResolver struct {
+	mu      sync.Mutex
+	hosts   map[string][]string
+	failing map[string]bool
+	// probesFail makes the lookups of the wildcard probes time out
+	probesFail bool
+	domain     string
+	wildcard   []string
+	lookups    []string
+}
+
+func (f *fakeHostResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lookups = append(f.lookups, host)
+	if f.failing[host] || (f.probesFail && strings.HasPrefix(host, "kptm-")) {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: host, IsTimeout: true}
+	}
+	if addrs, ok := f.hosts[host]; ok {
+		return addrs, nil
+	}
+	if len(f.wildcard) > 0 && strings.HasSuffix(host, "."+f.domain) {
+		return f.wildcard, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func Test_EnrichSubdomains(t *testing.T) {
+	resolver := &fakeHostResolver{
+		hosts: map[string][]string{
+			"www.example.com":     {"93.184.216.34"},
+			"vpn.example.com":     {"10.0.0.1"},
+			"staging.example.com": {"192.0.2.1"},
+			"gone.example.com":    {},
+		},
+		failing:  map[string]bool{"slow.example.com": true},
+		domain:   "example.com",
+		wildcard: []string{"192.0.2.1"},
+	}
+
+	res := EnrichSubdomains(context.Background(), resolver, "example.com.", []string{
+		"WWW.example.com.", "www.example.com", "vpn.example.com", "staging.example.com",
+		"gone.example.com", "random.example.com", "slow.example.com", "other.org",
+	})
+
+	assert.Equal(t, []string{"192.0.2.1"}, res.WildcardIPs)
+	assert.Empty(t, res.WildcardError)
+	require.Len(t, res.Subdomains, 6)
+	statuses := map[string]tools.SubdomainStatus{}
+	for _, s := range res.Subdomains {
+		statuses[s.Name] = s.Status
+	}
+	assert.Equal(t, map[string]tools.SubdomainStatus{
+		"www.example.com":     tools.SubdomainLive,
+		"vpn.example.com":     tools.SubdomainLive,
+		"staging.example.com": tools.SubdomainWildcard,
+		"gone.example.com":    tools.SubdomainDead,
+		"random.example.com":  tools.SubdomainWildcard,
+		"slow.example.com":    tools.SubdomainUnresolved,
+	}, statuses)
+	assert.Equal(t, 2, res.Live)
+	assert.Contains(t, res.Subdomains[5].Error, "i/o timeout")
+	assert.NotContains(t, resolver.lookups, "other.org")
+	// The wildcard probes and each distinct subdomain
+	assert.Len(t, resolver.lookups, wildcardProbes+6)
+}
+
+func Test_EnrichSubdomainsWithoutWildcard(t *testing.T) {
+	resolver := &fakeHostResolver{hosts: map[string][]string{"www.example.com": {"93.184.216.34"}}}
+
+	res := EnrichSubdomains(context.Background(), resolver, "example.com", []string{"www.example.com", "random.example.com"})
+	assert.Empty(t, res.WildcardIPs)
+	assert.Equal(t, []string{"www.example.com"}, res.LiveSubdomains())
+	assert.Equal(t, 1, res.Dead)
+}
+
+func Test_EnrichSubdomainsWildcardProbesFailing(t *testing.T) {
+	resolver := &fakeHostResolver{
+		hosts:      map[string][]string{"www.example.com": {"93.184.216.34"}},
+		probesFail: true,
+		domain:     "example.com",
+		wildcard:   []string{"192.0.2.1"},
+	}
+
+	res := EnrichSubdomains(context.Background(), resolver, "example.com", []string{"www.example.com", "random.example.com"})
+	assert.Empty(t, res.WildcardIPs)
+	assert.Contains(t, res.WildcardError, "i/o timeout")
+	// Wildcard names cannot be told apart
+	assert.Equal(t, 2, res.Live)
+}
+
+func Test_ResolverLookupHost(t *testing.T) {
+	srv := newFakeDNS(t)
+	r := NewResolver(WithServers(srv.start()), WithTimeout(time.Second), WithRetries(0))
+
+	addrs, err := r.LookupHost(context.Background(), "example.com.")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"}, addrs)
+
+	_, err = r.LookupHost(context.Background(), "missing.example.com")
+	var dnsErr *net.DNSError
+	require.True(t, errors.As(err, &dnsErr))
+	assert.True(t, dnsErr.IsNotFound)
+
+	res := EnrichSubdomains(context.Background(), r, "example.com", []string{"example.com", "missing.example.com"})
+	assert.Equal(t, 1, res.Live)
+	assert.Equal(t, 1, res.Dead)
+}
@@ -0,0 +1,296 @@
+// Package encryption provides field-level envelope encryption for sensitive
+// values, such as PII, carried by tool results.
+//
+// Each call to EncryptFields generates a random data key, encrypts the
+// sensitive fields with it using AES-256-GCM, and wraps the data key with the
+// KeyProvider. Encrypted fields are replaced in place by self-contained strings
+// of the form:
+//
+//	enc:v1:<key ID>:<wrapped data key>:<ciphertext>
+//
+// so results keep their shape on the wire and services that do not hold the
+// key can still process the non-sensitive fields.
+//
+// Fields are marked sensitive with the `sensitive:"true"` struct tag on string,
+// []string, map or struct fields (every string within a tagged struct or map
+// value is sensitive). Types whose sensitive values live in third-party structs
+// implement SensitiveFielder instead.
+//
+// Ciphertexts are bound to the field they were encrypted in, i.e., the type
+// owning the field, its name and, within maps, the key, so that a ciphertext
+// moved to another field fails to decrypt. Values of the same slice share
+// their field and can be reordered.
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// EncryptedPrefix prefixes every encrypted field value.
+	EncryptedPrefix = "enc:v1:"
+
+	// SensitiveTag is the struct tag marking a field as sensitive.
+	SensitiveTag = "sensitive"
+
+	envelopeSeparator = ":"
+	dataKeySize       = 32
+)
+
+// ErrMalformedCiphertext is returned when an encrypted value cannot be parsed.
+var ErrMalformedCiphertext = errors.New("malformed encrypted value")
+
+// SensitiveFielder is implemented by types holding sensitive values in fields
+// that cannot carry a `sensitive` tag, e.g., fields of third-party structs.
+type SensitiveFielder interface {
+	// SensitiveFields returns pointers to the sensitive string values.
+	SensitiveFields() []*string
+}
+
+// IsEncrypted reports whether value was produced by an Encryptor.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix)
+}
+
+// Encryptor encrypts and decrypts sensitive fields using envelope encryption.
+type Encryptor struct {
+	provider KeyProvider
+}
+
+// NewEncryptor creates an Encryptor wrapping data keys with the given provider.
+func NewEncryptor(provider KeyProvider) *Encryptor {
+	return &Encryptor{
+		provider: provider,
+	}
+}
+
+// EncryptFields encrypts, in place, the sensitive fields of v, which must be a
+// pointer. Empty and already encrypted values are left unchanged.
+func (e *Encryptor) EncryptFields(v any) error {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	keyID, wrapped, err := e.provider.WrapKey(dataKey)
+	if err != nil {
+		return err
+	}
+	header := EncryptedPrefix + keyID + envelopeSeparator + base64.RawURLEncoding.EncodeToString(wrapped) + envelopeSeparator
+
+	return walkSensitive(v, func(s *string, field string) error {
+		if *s == "" || IsEncrypted(*s) {
+			return nil
+		}
+
+		ciphertext, err := seal(dataKey, []byte(*s), additionalData(keyID, field))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt field: %w", err)
+		}
+		*s = header + base64.RawURLEncoding.EncodeToString(ciphertext)
+		return nil
+	})
+}
+
+// DecryptFields decrypts, in place, the encrypted sensitive fields of v, which
+// must be a pointer. It returns ErrKeyUnavailable (wrapped) if the provider
+// does not hold the key used to encrypt them.
+func (e *Encryptor) DecryptFields(v any) error {
+	dataKeys := make(map[string][]byte)
+
+	return walkSensitive(v, func(s *string, field string) error {
+		if !IsEncrypted(*s) {
+			return nil
+		}
+
+		plaintext, err := e.decrypt(*s, field, dataKeys)
+		if err != nil {
+			return err
+		}
+		*s = plaintext
+		return nil
+	})
+}
+
+// decrypt decrypts the value of field, caching unwrapped data keys in
+// dataKeys since all the fields encrypted together share the same data key.
+func (e *Encryptor) decrypt(value, field string, dataKeys map[string][]byte) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(value, EncryptedPrefix), envelopeSeparator)
+	if !IsEncrypted(value) || len(parts) != 3 {
+		return "", ErrMalformedCiphertext
+	}
+	keyID, encodedKey, encodedCiphertext := parts[0], parts[1], parts[2]
+
+	cacheKey := keyID + envelopeSeparator + encodedKey
+	dataKey, ok := dataKeys[cacheKey]
+	if !ok {
+		wrapped, err := base64.RawURLEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrMalformedCiphertext, err.Error())
+		}
+
+		dataKey, err = e.provider.UnwrapKey(keyID, wrapped)
+		if err != nil {
+			return "", err
+		}
+		dataKeys[cacheKey] = dataKey
+	}
+
+	ciphertext, err := base64.RawURLEncoding.DecodeString(encodedCiphertext)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrMalformedCiphertext, err.Error())
+	}
+
+	plaintext, err := open(dataKey, ciphertext, additionalData(keyID, field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt field: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// additionalData returns the GCM additional data binding a ciphertext to the
+// key and the field it was encrypted with.
+func additionalData(keyID, field string) []byte {
+	return []byte(keyID + envelopeSeparator + field)
+}
+
+// walkSensitive calls fn with a pointer to every sensitive string reachable
+// from v, along with the field it belongs to.
+func walkSensitive(v any, fn func(s *string, field string) error) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("expected a non-nil pointer, got %T", v)
+	}
+	return walk(rv, false, rv.Type().Elem().String(), fn)
+}
+
+func walk(v reflect.Value, sensitive bool, field string, fn func(*string, string) error) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return walk(v.Elem(), sensitive, field, fn)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if elem := v.Elem(); elem.Kind() == reflect.Pointer || !v.CanSet() {
+			return walk(elem, sensitive, field, fn)
+		}
+		// Values held by interfaces are not addressable, so a copy is walked
+		// and stored back
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		if err := walk(elem, sensitive, field, fn); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+
+	case reflect.Struct:
+		if v.CanAddr() {
+			if sf, ok := v.Addr().Interface().(SensitiveFielder); ok {
+				fields := make(map[uintptr]string)
+				stringFields(v, make(map[uintptr]bool), fields)
+				for _, s := range sf.SensitiveFields() {
+					if s == nil {
+						continue
+					}
+					name, ok := fields[reflect.ValueOf(s).Pointer()]
+					if !ok {
+						name = v.Type().String()
+					}
+					if err := fn(s, name); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			tagged := f.Tag.Get(SensitiveTag) == "true"
+			if err := walk(v.Field(i), sensitive || tagged, t.String()+"."+f.Name, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walk(v.Index(i), sensitive, field, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map values are not addressable, so a copy is walked and stored back
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			if err := walk(elem, sensitive, fmt.Sprintf("%s[%v]", field, iter.Key()), fn); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+		return nil
+
+	case reflect.String:
+		if !sensitive || !v.CanSet() {
+			return nil
+		}
+		// Copy through a plain string so that named string types are supported
+		s := v.String()
+		if err := fn(&s, field); err != nil {
+			return err
+		}
+		v.SetString(s)
+		return nil
+
+	default:
+		return nil
+	}
+}
+
+// stringFields maps the address of every string field reachable from the
+// addressable value v to the name of the field, so that the values returned
+// by SensitiveFields can be bound to their field.
+func stringFields(v reflect.Value, visited map[uintptr]bool, fields map[uintptr]string) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || visited[v.Pointer()] {
+			return
+		}
+		visited[v.Pointer()] = true
+		stringFields(v.Elem(), visited, fields)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := v.Field(i)
+			if f.Kind() == reflect.String {
+				fields[f.UnsafeAddr()] = t.String() + "." + t.Field(i).Name
+				continue
+			}
+			stringFields(f, visited, fields)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			stringFields(v.Index(i), visited, fields)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	whoisparser "github.com/likexian/whois-parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProvider(t *testing.T, activeKeyID string, keyIDs ...string) *LocalKeyProvider {
+	t.Helper()
+
+	dir := t.TempDir()
+	kf := keyFile{ActiveKeyID: activeKeyID, Keys: map[string]string{}}
+	for i, id := range keyIDs {
+		kf.Keys[id] = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{byte(i + 1)}, dataKeySize))
+	}
+
+	data, err := json.Marshal(kf)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "keys.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	provider, err := NewFileKeyProvider(path)
+	require.NoError(t, err)
+	return provider
+}
+
+func Test_EncryptDecryptToolResult(t *testing.T) {
+	encryptor := NewEncryptor(newTestProvider(t, "k1", "k1"))
+
+	testCases := []struct {
+		name      string
+		result    tools.ToolResult
+		sensitive func(r tools.IToolResult) []string
+		public    func(r tools.IToolResult) []string
+	}{
+		{
+			name: "Harvester emails",
+			result: tools.ToolResult{
+				Tool: enums.ToolHarvester,
+				Result: &tools.HarvesterResult{
+					Emails:     []string{"jane.doe@example.com", "john.roe@example.com"},
+					Subdomains: []string{"www.example.com"},
+				},
+			},
+			sensitive: func(r tools.IToolResult) []string {
+				return r.(*tools.HarvesterResult).Emails
+			},
+			public: func(r tools.IToolResult) []string {
+				return r.(*tools.HarvesterResult).Subdomains
+			},
+		},
+		{
+			name: "WhoIs registrant contact",
+			result: tools.ToolResult{
+				Tool: enums.ToolWhoIs,
+				Result: &tools.WhoIsResult{
+					RawData: &whoisparser.WhoisInfo{
+						Domain:     &whoisparser.Domain{Domain: "example.com"},
+						Registrar:  &whoisparser.Contact{Name: "Example Registrar, Inc."},
+						Registrant: &whoisparser.Contact{Name: "Jane Doe", Email: "jane.doe@example.com", Phone: "+1.5555550100"},
+					},
+				},
+			},
+			sensitive: func(r tools.IToolResult) []string {
+				c := r.(*tools.WhoIsResult).RawData.Registrant
+				return []string{c.Name, c.Email, c.Phone}
+			},
+			public: func(r tools.IToolResult) []string {
+				raw := r.(*tools.WhoIsResult).RawData
+				return []string{raw.Domain.Domain, raw.Registrar.Name}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plainSensitive := append([]string(nil), tc.sensitive(tc.result.Result)...)
+			plainPublic := append([]string(nil), tc.public(tc.result.Result)...)
+
+			require.NoError(t, encryptor.EncryptFields(&tc.result))
+			for i, v := range tc.sensitive(tc.result.Result) {
+				assert.True(t, IsEncrypted(v), "expected field %d to be encrypted", i)
+				assert.NotContains(t, v, plainSensitive[i])
+			}
+			assert.Equal(t, plainPublic, tc.public(tc.result.Result))
+
+			// Encrypted values must survive the bus
+			data, err := json.Marshal(&tc.result)
+			require.NoError(t, err)
+			var received tools.ToolResult
+			require.NoError(t, json.Unmarshal(data, &received))
+
+			require.NoError(t, encryptor.DecryptFields(&received))
+			assert.Equal(t, plainSensitive, tc.sensitive(received.Result))
+			assert.Equal(t, plainPublic, tc.public(received.Result))
+		})
+	}
+}
+
+func Test_DecryptFields(t *testing.T) {
+	provider := newTestProvider(t, "k1", "k1")
+	encrypted := &tools.HarvesterResult{Emails: []string{"jane.doe@example.com"}}
+	require.NoError(t, NewEncryptor(provider).EncryptFields(encrypted))
+
+	t.Run("Encrypting twice is a no-op", func(t *testing.T) {
+		again := &tools.HarvesterResult{Emails: append([]string(nil), encrypted.Emails...)}
+		require.NoError(t, NewEncryptor(provider).EncryptFields(again))
+		assert.Equal(t, encrypted.Emails, again.Emails)
+	})
+
+	t.Run("Rotated provider still holds the old key", func(t *testing.T) {
+		rotated := newTestProvider(t, "k2", "k1", "k2")
+		res := &tools.HarvesterResult{Emails: append([]string(nil), encrypted.Emails...)}
+		require.NoError(t, NewEncryptor(rotated).DecryptFields(res))
+		assert.Equal(t, []string{"jane.doe@example.com"}, res.Emails)
+	})
+
+	t.Run("Provider without the key", func(t *testing.T) {
+		other := newTestProvider(t, "k2", "k2")
+		res := &tools.HarvesterResult{Emails: append([]string(nil), encrypted.Emails...)}
+		err := NewEncryptor(other).DecryptFields(res)
+		assert.ErrorIs(t, err, ErrKeyUnavailable)
+		assert.Equal(t, encrypted.Emails, res.Emails)
+	})
+
+	t.Run("Tampered ciphertext", func(t *testing.T) {
+		value := encrypted.Emails[0]
+		tampered := value[:len(value)-2] + "AA"
+		if tampered == value {
+			tampered = value[:len(value)-2] + "BB"
+		}
+		res := &tools.HarvesterResult{Emails: []string{tampered}}
+		assert.Error(t, NewEncryptor(provider).DecryptFields(res))
+	})
+
+	t.Run("Malformed value", func(t *testing.T) {
+		res := &tools.HarvesterResult{Emails: []string{EncryptedPrefix + "k1"}}
+		assert.ErrorIs(t, NewEncryptor(provider).DecryptFields(res), ErrMalformedCiphertext)
+	})
+
+	t.Run("Ciphertext moved to another field", func(t *testing.T) {
+		res := &tools.HarvesterResult{EmailDetails: []tools.HarvestedEmail{{Address: encrypted.Emails[0]}}}
+		assert.Error(t, NewEncryptor(provider).DecryptFields(res))
+	})
+
+	t.Run("Non-pointer value", func(t *testing.T) {
+		assert.Error(t, NewEncryptor(provider).DecryptFields(tools.HarvesterResult{}))
+	})
+}
+
+type contact struct {
+	Name  string
+	Email string
+}
+
+type profile struct {
+	Contacts map[string]contact `sensitive:"true"`
+	Notes    map[string]string  `sensitive:"true"`
+	Extra    map[string]any     `sensitive:"true"`
+	Labels   map[string]string
+}
+
+func Test_EncryptFieldsMaps(t *testing.T) {
+	encryptor := NewEncryptor(newTestProvider(t, "k1", "k1"))
+	p := &profile{
+		Contacts: map[string]contact{"billing": {Name: "Jane Doe", Email: "jane.doe@example.com"}},
+		Notes:    map[string]string{"home": "Main St.", "work": "Market St."},
+		Extra:    map[string]any{"phone": "+1.5555550100"},
+		Labels:   map[string]string{"env": "prod"},
+	}
+
+	require.NoError(t, encryptor.EncryptFields(p))
+	assert.True(t, IsEncrypted(p.Contacts["billing"].Name))
+	assert.True(t, IsEncrypted(p.Contacts["billing"].Email))
+	assert.True(t, IsEncrypted(p.Notes["home"]))
+	assert.True(t, IsEncrypted(p.Extra["phone"].(string)))
+	assert.Equal(t, map[string]string{"env": "prod"}, p.Labels)
+
+	t.Run("Ciphertext moved to another key", func(t *testing.T) {
+		swapped := &profile{Notes: map[string]string{"home": p.Notes["work"], "work": p.Notes["home"]}}
+		assert.Error(t, encryptor.DecryptFields(swapped))
+	})
+
+	t.Run("Ciphertext moved to another field", func(t *testing.T) {
+		c := p.Contacts["billing"]
+		swapped := &profile{Contacts: map[string]contact{"billing": {Name: c.Email, Email: c.Name}}}
+		assert.Error(t, encryptor.DecryptFields(swapped))
+	})
+
+	require.NoError(t, encryptor.DecryptFields(p))
+	assert.Equal(t, contact{Name: "Jane Doe", Email: "jane.doe@example.com"}, p.Contacts["billing"])
+	assert.Equal(t, map[string]string{"home": "Main St.", "work": "Market St."}, p.Notes)
+	assert.Equal(t, "+1.5555550100", p.Extra["phone"])
+}
+
+func Test_DecryptFieldsSwappedContacts(t *testing.T) {
+	encryptor := NewEncryptor(newTestProvider(t, "k1", "k1"))
+	res := &tools.WhoIsResult{RawData: &whoisparser.WhoisInfo{
+		Registrant: &whoisparser.Contact{Name: "Jane Doe", Email: "jane.doe@example.com"},
+	}}
+	require.NoError(t, encryptor.EncryptFields(res))
+
+	// Third-party fields returned by SensitiveFields are bound too
+	c := res.RawData.Registrant
+	c.Name, c.Email = c.Email, c.Name
+	assert.Error(t, encryptor.DecryptFields(res))
+}
+
+func Test_NewLocalKeyProvider(t *testing.T) {
+	testCases := []struct {
+		name        string
+		activeKeyID string
+		keys        map[string][]byte
+		expectError bool
+	}{
+		{
+			name:        "Valid key",
+			activeKeyID: "k1",
+			keys:        map[string][]byte{"k1": make([]byte, dataKeySize)},
+		},
+		{
+			name:        "Missing active key",
+			activeKeyID: "k2",
+			keys:        map[string][]byte{"k1": make([]byte, dataKeySize)},
+			expectError: true,
+		},
+		{
+			name:        "Short key",
+			activeKeyID: "k1",
+			keys:        map[string][]byte{"k1": make([]byte, 16)},
+			expectError: true,
+		},
+		{
+			name:        "Key ID with separator",
+			activeKeyID: "k:1",
+			keys:        map[string][]byte{"k:1": make([]byte, dataKeySize)},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewLocalKeyProvider(tc.activeKeyID, tc.keys)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrKeyUnavailable is returned when a KeyProvider does not hold the key
+// needed to unwrap a data key.
+var ErrKeyUnavailable = errors.New("key encryption key is not available")
+
+// KeyProvider wraps and unwraps data encryption keys with key encryption keys
+// (KEKs) it holds, e.g., in a KMS or a local file.
+type KeyProvider interface {
+	// WrapKey encrypts dataKey with the active KEK, returning the ID of that KEK
+	// along with the wrapped key.
+	WrapKey(dataKey []byte) (keyID string, wrapped []byte, err error)
+
+	// UnwrapKey decrypts a data key wrapped with the KEK identified by keyID.
+	// It returns ErrKeyUnavailable if the provider does not hold that KEK.
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// keyFile is the on-disk format read by NewFileKeyProvider.
+type keyFile struct {
+	ActiveKeyID string            `json:"active_key_id"`
+	Keys        map[string]string `json:"keys"` // Base64 encoded 256-bit keys, by key ID.
+}
+
+// LocalKeyProvider is a KeyProvider holding its KEKs in memory. Data keys are
+// wrapped with AES-256-GCM. It is meant for tests and local development.
+type LocalKeyProvider struct {
+	activeKeyID string
+	keys        map[string][]byte
+}
+
+// NewLocalKeyProvider creates a LocalKeyProvider from 256-bit keys indexed by
+// key ID. New data keys are wrapped with the activeKeyID key.
+func NewLocalKeyProvider(activeKeyID string, keys map[string][]byte) (*LocalKeyProvider, error) {
+	p := &LocalKeyProvider{
+		activeKeyID: activeKeyID,
+		keys:        make(map[string][]byte, len(keys)),
+	}
+
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, envelopeSeparator) {
+			return nil, fmt.Errorf("invalid key ID `%s`", id)
+		}
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("invalid key size for `%s`: expected %d bytes, got %d", id, dataKeySize, len(key))
+		}
+		p.keys[id] = key
+	}
+
+	if _, ok := p.keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active key `%s` not found", activeKeyID)
+	}
+
+	return p, nil
+}
+
+// NewFileKeyProvider creates a LocalKeyProvider from a JSON key file, e.g.:
+//
+//	{"active_key_id": "2025-01", "keys": {"2025-01": "<base64 encoded 32 bytes>"}}
+func NewFileKeyProvider(path string) (*LocalKeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(kf.Keys))
+	for id, encoded := range kf.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key `%s`: %w", id, err)
+		}
+		keys[id] = key
+	}
+
+	return NewLocalKeyProvider(kf.ActiveKeyID, keys)
+}
+
+func (p *LocalKeyProvider) WrapKey(dataKey []byte) (string, []byte, error) {
+	wrapped, err := seal(p.keys[p.activeKeyID], dataKey, []byte(p.activeKeyID))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return p.activeKeyID, wrapped, nil
+}
+
+func (p *LocalKeyProvider) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyUnavailable, keyID)
+	}
+
+	dataKey, err := open(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// seal encrypts plaintext with AES-GCM, prefixing the random nonce to the ciphertext.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a ciphertext produced by seal.
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -1,6 +1,10 @@
 package enums
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
 
 // CVSSVersion representa la versión de CVSS que aceptamos en nuestros métricos.
 type CVSSVersion string
@@ -32,3 +36,121 @@ func ParseCVSSVersion(s string) (CVSSVersion, error) {
 	}
 	return "", fmt.Errorf("versión CVSS inválida: %q", s)
 }
+
+// PortState is the state of a port as reported by Nmap.
+type PortState string
+
+const (
+	PortStateOpen           PortState = "open"
+	PortStateClosed         PortState = "closed"
+	PortStateFiltered       PortState = "filtered"
+	PortStateUnfiltered     PortState = "unfiltered"
+	PortStateOpenFiltered   PortState = "open|filtered"
+	PortStateClosedFiltered PortState = "closed|filtered"
+	PortStateUnknown        PortState = "unknown"
+)
+
+func (s PortState) String() string {
+	return string(s)
+}
+
+// IsPossiblyOpen reports whether the port may be open, i.e., it is open or
+// Nmap could not tell open from filtered, as is common for UDP ports.
+func (s PortState) IsPossiblyOpen() bool {
+	return s == PortStateOpen || s == PortStateOpenFiltered
+}
+
+// ParsePortState converts a string to PortState, ignoring case and spaces.
+func ParsePortState(s string, defaultVal PortState) PortState {
+	val := PortState(strings.ToLower(strings.ReplaceAll(s, " ", "")))
+	switch val {
+	case PortStateOpen, PortStateClosed, PortStateFiltered, PortStateUnfiltered,
+		PortStateOpenFiltered, PortStateClosedFiltered:
+		return val
+	default:
+		return defaultVal
+	}
+}
+
+// UnmarshalJSON decodes port states regardless of case, so that older
+// payloads such as "Open" are understood. Unknown states decode to PortStateUnknown.
+func (s *PortState) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("invalid port state: %w", err)
+	}
+	*s = ParsePortState(str, PortStateUnknown)
+	return nil
+}
+
+// HostState is the state of a host as reported by Nmap.
+type HostState string
+
+const (
+	HostStateUp      HostState = "up"
+	HostStateDown    HostState = "down"
+	HostStateSkipped HostState = "skipped"
+	HostStateUnknown HostState = "unknown"
+)
+
+func (s HostState) String() string {
+	return string(s)
+}
+
+// ParseHostState converts a string to HostState, ignoring case.
+func ParseHostState(s string, defaultVal HostState) HostState {
+	val := HostState(strings.ToLower(strings.TrimSpace(s)))
+	switch val {
+	case HostStateUp, HostStateDown, HostStateSkipped:
+		return val
+	default:
+		return defaultVal
+	}
+}
+
+// UnmarshalJSON decodes host states regardless of case. Unknown states
+// decode to HostStateUnknown.
+func (s *HostState) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("invalid host state: %w", err)
+	}
+	*s = ParseHostState(str, HostStateUnknown)
+	return nil
+}
+
+// PortProtocol is the transport protocol of a port.
+type PortProtocol string
+
+const (
+	PortProtocolTCP     PortProtocol = "tcp"
+	PortProtocolUDP     PortProtocol = "udp"
+	PortProtocolSCTP    PortProtocol = "sctp"
+	PortProtocolUnknown PortProtocol = "unknown"
+)
+
+func (p PortProtocol) String() string {
+	return string(p)
+}
+
+// ParsePortProtocol converts a string to PortProtocol, ignoring case.
+func ParsePortProtocol(s string, defaultVal PortProtocol) PortProtocol {
+	val := PortProtocol(strings.ToLower(strings.TrimSpace(s)))
+	switch val {
+	case PortProtocolTCP, PortProtocolUDP, PortProtocolSCTP:
+		return val
+	default:
+		return defaultVal
+	}
+}
+
+// UnmarshalJSON decodes protocols regardless of case. Unknown protocols
+// decode to PortProtocolUnknown.
+func (p *PortProtocol) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("invalid port protocol: %w", err)
+	}
+	*p = ParsePortProtocol(str, PortProtocolUnknown)
+	return nil
+}
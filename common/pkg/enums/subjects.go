@@ -11,4 +11,5 @@ const (
 	HarvesterEventSubject     EventSubjectName = "event.harvester"
 	NmapEventSubject          EventSubjectName = "event.nmap"
 	WebScanEventSubject       EventSubjectName = "event.webscan"
+	NmapScanEventSubject      EventSubjectName = "event.nmapscan"
 )
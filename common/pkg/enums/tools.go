@@ -2,6 +2,8 @@ package enums
 
 import (
 	"fmt"
+	"maps"
+	"sync"
 )
 
 type ToolName string
@@ -12,22 +14,53 @@ const (
 	ToolDNSLookup ToolName = "DNSLookup"
 	ToolNmap      ToolName = "Nmap"
 	ToolWebScan   ToolName = "WebScan"
+	ToolNmapScan  ToolName = "NmapScan"
 )
 
-var ToolSubjectMap = map[ToolName]EventSubjectName{
+// toolSubjects maps each tool to the subject its results are published on.
+// Tools registered with tools.RegisterTool are added to it, under toolSubjectMu.
+var toolSubjects = map[ToolName]EventSubjectName{
 	ToolWhoIs:     WhoIsEventSubject,
 	ToolHarvester: HarvesterEventSubject,
 	ToolDNSLookup: DNSLookupEventSubject,
 	ToolNmap:      NmapEventSubject,
 	ToolWebScan:   WebScanEventSubject,
+	ToolNmapScan:  NmapScanEventSubject,
 }
 
 func (t ToolName) String() string {
 	return string(t)
 }
 
+var toolSubjectMu sync.RWMutex
+
+// RegisterToolSubject sets the subject the results of a tool are published on.
+func RegisterToolSubject(toolName ToolName, subject EventSubjectName) {
+	toolSubjectMu.Lock()
+	defer toolSubjectMu.Unlock()
+	toolSubjects[toolName] = subject
+}
+
+// UnregisterToolSubject removes the subject of a tool set by
+// RegisterToolSubject.
+func UnregisterToolSubject(toolName ToolName) {
+	toolSubjectMu.Lock()
+	defer toolSubjectMu.Unlock()
+	delete(toolSubjects, toolName)
+}
+
+// ToolSubjects returns a snapshot of the subject each tool's results are
+// published on, including the tools registered with RegisterToolSubject.
+func ToolSubjects() map[ToolName]EventSubjectName {
+	toolSubjectMu.RLock()
+	defer toolSubjectMu.RUnlock()
+	return maps.Clone(toolSubjects)
+}
+
 func GetToolSubjectName(toolName ToolName) (string, error) {
-	subject, exists := ToolSubjectMap[toolName]
+	toolSubjectMu.RLock()
+	defer toolSubjectMu.RUnlock()
+	subject, exists := toolSubjects[toolName]
 	if !exists {
 		return "", fmt.Errorf("invalid tool: %s", toolName)
 	}
@@ -1,5 +1,7 @@
 package enums
 
+import "strings"
+
 type MethodType string
 
 const (
@@ -33,3 +35,36 @@ const (
 )
 
 func (c ConfidenceWebScanType) String() string { return string(c) }
+
+// ParseRiskCode converts a ZAP riskcode ("0" to "3") or risk name to RiskCodeType.
+func ParseRiskCode(s string, defaultVal RiskCodeType) RiskCodeType {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "0", "informational", "info":
+		return RiskCodeInformational
+	case "1", "low":
+		return RiskCodeLow
+	case "2", "medium":
+		return RiskCodeMedium
+	case "3", "high":
+		return RiskCodeHigh
+	default:
+		return defaultVal
+	}
+}
+
+// ParseConfidenceWebScan converts a ZAP confidence ("0" to "4") or confidence
+// name to ConfidenceWebScanType. User confirmed alerts ("4") map to ConfidenceHigh.
+func ParseConfidenceWebScan(s string, defaultVal ConfidenceWebScanType) ConfidenceWebScanType {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), " ", "")) {
+	case "0", "falsepositive":
+		return ConfidenceFalsePositive
+	case "1", "low":
+		return ConfidenceLow
+	case "2", "medium":
+		return ConfidenceMedium
+	case "3", "high", "4", "confirmed", "userconfirmed":
+		return ConfidenceHigh
+	default:
+		return defaultVal
+	}
+}
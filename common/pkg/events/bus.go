@@ -1,8 +1,13 @@
 package events
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/nats-io/nats.go"
 )
@@ -18,7 +23,7 @@ type EventBus interface {
 	Close() error
 
 	// Subscribe subscribes to an event subject with a provided handler function.
-	// The handler is invoked when a message is received.
+	// The handler is invoked when a message is received, with its raw payload.
 	Subscribe(subject string, handler func(msg *nats.Msg)) error
 
 	// Publish publishes a message to the specified subject.
@@ -26,26 +31,118 @@ type EventBus interface {
 	Publish(subject string, payload []byte) error
 }
 
+// EventCodecBus is an EventBus encoding events with a codec, whose
+// subscribers decode them with DecodeMsg, or subscribe with SubscribeEvent.
+type EventCodecBus interface {
+	EventBus
+
+	// PublishEvent encodes the event with the bus codec and publishes it to
+	// the specified subject.
+	PublishEvent(subject string, event any) error
+
+	// DecodeMsg decodes the payload of a received message into v, using the
+	// codec the message advertises.
+	DecodeMsg(msg *nats.Msg, v any) error
+}
+
+var _ EventCodecBus = (*NatsEventBus)(nil)
+
 // NatsEventBus implements the EventBus interface using NATS as the message broker.
 // It provides functionality for subscribing to events, publishing messages,
 // and managing connections to NATS servers.
 type NatsEventBus struct {
-	nc     *nats.Conn   // NATS connection object.
-	Logger *slog.Logger // Logger used for logging event-related information
+	nc          *nats.Conn        // NATS connection object.
+	codec       Codec             // Codec used to encode events in PublishEvent.
+	signer      *Signer           // Signs published messages when set.
+	verifier    *Verifier         // Verifies received messages when set.
+	middlewares []Middleware      // Wrap the handlers of every subscription.
+	observers   []PublishObserver // Notified of every published message.
+	Logger      *slog.Logger      // Logger used for logging event-related information
+
+	poolConfig *WorkerPoolConfig    // Default worker pool of the subscriptions, if any.
+	mu         sync.Mutex           // Guards subs and pools.
+	subs       []*nats.Subscription // Active subscriptions.
+	pools      []*workerPool        // Worker pools of the subscriptions.
+
+	lastMessageAt atomic.Int64 // Unix nanoseconds of the last message received.
+}
+
+// BusStats is a snapshot of the state of a NatsEventBus, e.g., for health checks.
+type BusStats struct {
+	Connected     bool      // Whether the connection to NATS is up
+	Status        string    // Connection status, e.g., CONNECTED or RECONNECTING
+	Subscriptions int       // Number of active subscriptions
+	LastMessageAt time.Time // When the last message was received, zero if none
+}
+
+// NatsEventBusOption configures optional behaviour of a NatsEventBus.
+type NatsEventBusOption func(*NatsEventBus)
+
+// WithCodec sets the codec used by PublishEvent. Defaults to DefaultCodec.
+func WithCodec(codec Codec) NatsEventBusOption {
+	return func(n *NatsEventBus) {
+		n.codec = codec
+	}
+}
+
+// WithSigner signs every message published by the bus.
+func WithSigner(signer *Signer) NatsEventBusOption {
+	return func(n *NatsEventBus) {
+		n.signer = signer
+	}
+}
+
+// WithVerifier verifies the signature of every message received by the bus
+// subscriptions. Messages rejected by the verifier policy are dropped and logged.
+func WithVerifier(verifier *Verifier) NatsEventBusOption {
+	return func(n *NatsEventBus) {
+		n.verifier = verifier
+	}
+}
+
+// PublishObserver is notified of every message published by the bus, e.g.,
+// to collect metrics. err is the publishing error, if any.
+type PublishObserver func(subject string, size int, elapsed time.Duration, err error)
+
+// WithPublishObserver adds an observer of the messages published by the bus.
+func WithPublishObserver(observer PublishObserver) NatsEventBusOption {
+	return func(n *NatsEventBus) {
+		n.observers = append(n.observers, observer)
+	}
 }
 
 // NewNatsEventBus creates a new nats event bus with the specified connStr
 // e.g., NewNatsEventBus("http://nats:4222")
-func NewNatsEventBus(connStr string) (*NatsEventBus, error) {
-	nc, err := nats.Connect(connStr)
+func NewNatsEventBus(connStr string, opts ...NatsEventBusOption) (*NatsEventBus, error) {
+	n := &NatsEventBus{
+		codec:  DefaultCodec,
+		Logger: slog.New(slog.Default().Handler()),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	if n.poolConfig != nil {
+		if err := n.poolConfig.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	nc, err := nats.Connect(connStr, nats.ErrorHandler(n.handleAsyncError))
 	if err != nil {
 		return nil, err
 	}
+	n.nc = nc
 
-	return &NatsEventBus{
-		nc:     nc,
-		Logger: slog.New(slog.Default().Handler()),
-	}, nil
+	return n, nil
+}
+
+// Codec returns the codec used by the bus to encode events.
+func (n *NatsEventBus) Codec() Codec {
+	if n.codec == nil {
+		return DefaultCodec
+	}
+	return n.codec
 }
 
 // Init sets up the event bus by subscribing to necessary events
@@ -57,7 +154,23 @@ func (n *NatsEventBus) Init(setupSubscriptions func() error) error {
 	return setupSubscriptions()
 }
 
+// Close stops the subscriptions, waits for their worker pools to handle the
+// messages already queued, and closes the connection.
 func (n *NatsEventBus) Close() error {
+	n.mu.Lock()
+	subs, pools := n.subs, n.pools
+	n.subs, n.pools = nil, nil
+	n.mu.Unlock()
+
+	for _, sub := range subs {
+		if err := sub.Unsubscribe(); err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+			n.Logger.Warn("Failed to unsubscribe", slog.String("subject", sub.Subject), slog.String("error", err.Error()))
+		}
+	}
+	for _, pool := range pools {
+		pool.close()
+	}
+
 	if n.nc != nil {
 		n.nc.Close()
 		return nil
@@ -69,20 +182,137 @@ func (n *NatsEventBus) Close() error {
 // to process the incoming messages for that event. The handler is invoked whenever
 // a message is received on the specified subject.
 //
+// Messages are handled serially, unless the bus was created WithWorkerPool.
+// The handler gets the raw payload: events published with PublishEvent are
+// decoded with DecodeMsg, or subscribed to with SubscribeEvent instead.
+//
 // subject: The subject/topic to subscribe to.
 // handler: The callback function to handle incoming messages for the subject.
 //
 // Returns an error if the subscription fails.
 func (n *NatsEventBus) Subscribe(subject string, handler func(msg *nats.Msg)) error {
-	_, err := n.nc.Subscribe(subject, handler)
+	return n.subscribe(subject, handler, n.poolConfig)
+}
+
+// SubscribeWithWorkers subscribes to the given event subject like Subscribe,
+// handling its messages with a dedicated worker pool of the given configuration.
+func (n *NatsEventBus) SubscribeWithWorkers(subject string, handler func(msg *nats.Msg), cfg WorkerPoolConfig) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	return n.subscribe(subject, handler, &cfg)
+}
+
+func (n *NatsEventBus) subscribe(subject string, handler func(msg *nats.Msg), poolConfig *WorkerPoolConfig) error {
+	handler = Chain(handler, n.middlewares...)
+	if n.verifier != nil {
+		handler = n.verifySignature(handler)
+	}
+
+	var pool *workerPool
+	if poolConfig != nil {
+		pool = newWorkerPool(subject, handler, *poolConfig, n.Logger)
+		handler = pool.dispatch
+	}
+	handler = n.recordActivity(handler)
+
+	sub, err := n.nc.Subscribe(subject, handler)
 	if err != nil {
+		if pool != nil {
+			pool.close()
+		}
 		return fmt.Errorf("Failed to subscribe to `%s`: %s", subject, err.Error())
 	}
 
+	n.mu.Lock()
+	n.subs = append(n.subs, sub)
+	if pool != nil {
+		n.pools = append(n.pools, pool)
+	}
+	n.mu.Unlock()
+
 	n.Logger.Info("Subscribed to subject successfully.", slog.String("subject", subject))
 	return nil
 }
 
+// Stats returns a snapshot of the bus state.
+func (n *NatsEventBus) Stats() BusStats {
+	n.mu.Lock()
+	subscriptions := len(n.subs)
+	n.mu.Unlock()
+
+	stats := BusStats{
+		Subscriptions: subscriptions,
+		Status:        nats.CLOSED.String(),
+	}
+	if n.nc != nil {
+		stats.Connected = n.nc.IsConnected()
+		stats.Status = n.nc.Status().String()
+	}
+	if last := n.lastMessageAt.Load(); last != 0 {
+		stats.LastMessageAt = time.Unix(0, last)
+	}
+	return stats
+}
+
+// recordActivity wraps handler to record when the last message was received.
+func (n *NatsEventBus) recordActivity(handler func(msg *nats.Msg)) func(msg *nats.Msg) {
+	return func(msg *nats.Msg) {
+		n.lastMessageAt.Store(time.Now().UnixNano())
+		handler(msg)
+	}
+}
+
+// WorkerPoolStats returns the activity of the subscription worker pools.
+func (n *NatsEventBus) WorkerPoolStats() []WorkerPoolStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	stats := make([]WorkerPoolStats, 0, len(n.pools))
+	for _, pool := range n.pools {
+		stats = append(stats, pool.stats())
+	}
+	return stats
+}
+
+// SubscribeEvent subscribes to the given event subject on bus, and invokes the
+// handler with each message decoded into a new T with the codec it advertises.
+// Messages that cannot be decoded are logged and dropped.
+func SubscribeEvent[T any](bus EventCodecBus, subject string, handler func(msg *nats.Msg, event *T)) error {
+	logger := slog.Default()
+	if n, ok := bus.(*NatsEventBus); ok && n.Logger != nil {
+		logger = n.Logger
+	}
+
+	return bus.Subscribe(subject, func(msg *nats.Msg) {
+		event := new(T)
+		if err := bus.DecodeMsg(msg, event); err != nil {
+			logger.Warn("Dropped message that cannot be decoded",
+				slog.String("subject", msg.Subject),
+				slog.String("error", err.Error()))
+			return
+		}
+		handler(msg, event)
+	})
+}
+
+// SubscribeContext subscribes to the given event subject like Subscribe, but
+// invokes the handler with a context carrying the message metadata, such as
+// its tenant (see TenantFromContext).
+func (n *NatsEventBus) SubscribeContext(subject string, handler func(ctx context.Context, msg *nats.Msg)) error {
+	return n.Subscribe(subject, func(msg *nats.Msg) {
+		handler(MsgContext(context.Background(), msg), msg)
+	})
+}
+
+// MsgContext returns a copy of ctx carrying the metadata of msg.
+func MsgContext(ctx context.Context, msg *nats.Msg) context.Context {
+	if tenantID, err := TenantFromMsg(msg); err == nil && tenantID != "" {
+		ctx = ContextWithTenant(ctx, tenantID)
+	}
+	return ctx
+}
+
 // Publish sends a message to the specified subject with the given payload.
 //
 // subject: The subject/topic to publish the message to.
@@ -90,12 +320,114 @@ func (n *NatsEventBus) Subscribe(subject string, handler func(msg *nats.Msg)) er
 //
 // Returns an error if the publishing process fails.
 func (n *NatsEventBus) Publish(subject string, payload []byte) error {
+	msg := nats.NewMsg(subject)
+	msg.Data = payload
+
+	if err := n.publishMsg(msg); err != nil {
+		return err
+	}
 
-	err := n.nc.Publish(subject, payload)
+	n.Logger.Debug("Published message", slog.String("subject", subject), slog.Int("size", len(payload)))
+	return nil
+}
+
+// PublishEvent encodes the event with the bus codec and publishes it to the
+// specified subject, advertising the codec in the ContentTypeHeader.
+// Subscribers should decode it with DecodeMsg. Events belonging to a tenant
+// also carry the TenantHeader; use TenantSubject to scope the subject.
+//
+// subject: The subject/topic to publish the message to.
+// event: The event payload, e.g., a ToolResultEvent.
+//
+// Returns an error if encoding or publishing fails.
+func (n *NatsEventBus) PublishEvent(subject string, event any) error {
+	codec := n.Codec()
+	payload, err := codec.Marshal(event)
 	if err != nil {
+		return fmt.Errorf("failed to encode event for `%s`: %w", subject, err)
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = payload
+	msg.Header.Set(ContentTypeHeader, codec.ContentType())
+	if scoped, ok := event.(TenantScoped); ok && scoped.GetTenantID() != "" {
+		msg.Header.Set(TenantHeader, scoped.GetTenantID())
+	}
+
+	if err := n.publishMsg(msg); err != nil {
 		return err
 	}
 
-	n.Logger.Debug("Published message", slog.String("subject", subject), slog.String("payload", string(payload)))
+	n.Logger.Debug("Published event", slog.String("subject", subject), slog.String("content_type", codec.ContentType()), slog.Int("size", len(payload)))
 	return nil
 }
+
+// DecodeMsg decodes the payload of msg into v like the package-level
+// DecodeMsg, so that consumers holding an EventCodecBus can decode the events
+// published with PublishEvent.
+func (n *NatsEventBus) DecodeMsg(msg *nats.Msg, v any) error {
+	return DecodeMsg(msg, v)
+}
+
+// publishMsg signs msg if the bus has a signer, publishes it and notifies the observers.
+func (n *NatsEventBus) publishMsg(msg *nats.Msg) error {
+	start := time.Now()
+	err := n.signAndPublish(msg)
+	for _, observe := range n.observers {
+		observe(msg.Subject, len(msg.Data), time.Since(start), err)
+	}
+	return err
+}
+
+func (n *NatsEventBus) signAndPublish(msg *nats.Msg) error {
+	if n.signer != nil {
+		if err := n.signer.Sign(msg); err != nil {
+			return err
+		}
+	}
+	return n.nc.PublishMsg(msg)
+}
+
+// verifySignature wraps handler so that it is only invoked for messages
+// accepted by the bus verifier.
+func (n *NatsEventBus) verifySignature(handler func(msg *nats.Msg)) func(msg *nats.Msg) {
+	return func(msg *nats.Msg) {
+		accepted, err := n.verifier.Accept(msg)
+		if !accepted {
+			n.Logger.Warn("Rejected message with invalid signature",
+				slog.String("subject", msg.Subject),
+				slog.String("key_id", msg.Header.Get(SignatureKeyIDHeader)),
+				slog.String("policy", n.verifier.Policy.String()),
+				slog.String("error", err.Error()))
+			return
+		}
+		if err != nil {
+			n.Logger.Warn("Accepted message failing signature verification",
+				slog.String("subject", msg.Subject),
+				slog.String("key_id", msg.Header.Get(SignatureKeyIDHeader)),
+				slog.String("policy", n.verifier.Policy.String()),
+				slog.String("error", err.Error()))
+		}
+		handler(msg)
+	}
+}
+
+// handleAsyncError logs the asynchronous errors of the connection, such as
+// subscriptions falling behind and being flagged as slow consumers.
+func (n *NatsEventBus) handleAsyncError(_ *nats.Conn, sub *nats.Subscription, err error) {
+	var subject string
+	var pendingMsgs int
+	if sub != nil {
+		subject = sub.Subject
+		pendingMsgs, _, _ = sub.Pending()
+	}
+
+	if errors.Is(err, nats.ErrSlowConsumer) {
+		n.Logger.Warn("Slow consumer detected, messages are being dropped",
+			slog.String("subject", subject),
+			slog.Int("pending_messages", pendingMsgs))
+		return
+	}
+
+	n.Logger.Error("Asynchronous NATS error", slog.String("subject", subject), slog.String("error", err.Error()))
+}
@@ -0,0 +1,115 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+
+	"github.com/nats-io/nats.go"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ContentTypeHeader is the message header advertising the codec used to encode
+// the payload, so that consumers can decode messages from producers running a
+// different codec.
+const ContentTypeHeader = "Content-Type"
+
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeMsgpack = "application/msgpack"
+)
+
+// Codec encodes and decodes event payloads sent over the EventBus.
+type Codec interface {
+	// ContentType returns the MIME type advertised in the ContentTypeHeader.
+	ContentType() string
+
+	// Marshal encodes v into its wire representation.
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal decodes data into v.
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec encodes events as JSON. It is the default codec, and is assumed
+// for messages that carry no ContentTypeHeader.
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string { return ContentTypeJSON }
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// MsgpackCodec encodes events as MessagePack. Field names are taken from the
+// `json` struct tags, so both codecs produce the same logical document.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) ContentType() string { return ContentTypeMsgpack }
+
+func (MsgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// DefaultCodec is used when no codec is configured.
+var DefaultCodec Codec = JSONCodec{}
+
+var codecs = map[string]Codec{
+	ContentTypeJSON:    JSONCodec{},
+	ContentTypeMsgpack: MsgpackCodec{},
+}
+
+// CodecForContentType returns the codec registered for the given content type.
+// An empty content type resolves to the JSONCodec.
+func CodecForContentType(contentType string) (Codec, error) {
+	if contentType == "" {
+		return JSONCodec{}, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type `%s`: %w", contentType, err)
+	}
+
+	codec, ok := codecs[mediaType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported content type: %s", contentType)
+	}
+	return codec, nil
+}
+
+// DecodeMsg decodes the payload of msg into v, using the codec advertised in
+// its ContentTypeHeader.
+func DecodeMsg(msg *nats.Msg, v any) error {
+	var contentType string
+	if msg.Header != nil {
+		contentType = msg.Header.Get(ContentTypeHeader)
+	}
+
+	codec, err := CodecForContentType(contentType)
+	if err != nil {
+		return err
+	}
+
+	if err := codec.Unmarshal(msg.Data, v); err != nil {
+		return fmt.Errorf("failed to decode `%s` message on `%s`: %w", codec.ContentType(), msg.Subject, err)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CodecRoundTrip(t *testing.T) {
+	timestamp := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	results := []tools.ToolResult{
+		{
+			Tool: enums.ToolNmap,
+			Result: &tools.NmapResult{
+				HostName:    "example.com",
+				HostAddress: "93.184.216.34",
+				ScannedPorts: []tools.PortData{
+					{
+						ID:       443,
+						Protocol: "tcp",
+						State:    "open",
+						Service:  tools.Service{Name: "https", Version: "1.25", CPE: "cpe:/a:nginx:nginx:1.25"},
+						Vulnerabilities: []tools.Vulnerability{
+							{CveID: "CVE-2023-44487", BaseCVSSScore: 7.5, BaseSeverity: enums.SeverityTypeHigh},
+						},
+					},
+				},
+				MostLikelyOS: tools.OSData{Name: "Linux 5.X", Accuracy: 95},
+			},
+			Timestamp: timestamp,
+		},
+		{
+			Tool: enums.ToolWebScan,
+			Result: &tools.WebScanResult{
+				ScanType: "baseline",
+				WebVulnerabilities: []tools.WebVulnerability{
+					{
+						Name:       "Missing Anti-clickjacking Header",
+						Risk:       enums.RiskCodeMedium,
+						Confidence: enums.ConfidenceMedium,
+						Instances:  []tools.InstanceAlert{{URI: "https://example.com", Method: enums.MethodGet}},
+						CweID:      "1021",
+						WascID:     "15",
+					},
+				},
+			},
+			Timestamp: timestamp,
+		},
+		{
+			Tool: enums.ToolDNSLookup,
+			Result: &tools.DNSLookupResult{
+				Domain: "example.com",
+				DNSRecords: []tools.DNSRecord{
+					{Type: tools.ARecord, Name: "example.com", TTL: 300, Value: "93.184.216.34"},
+					{Type: tools.MXRecord, Name: "example.com", TTL: 300, Value: tools.MailExchange{Host: "mail.example.com", Priority: 10}},
+					{Type: tools.SOARecord, Name: "example.com", TTL: 3600, Value: tools.StartOfAuthority{PrimaryNS: "ns1.example.com", Serial: 2025011001}},
+					{Type: tools.DNSKeyRecord, Name: "example.com", TTL: 3600, Value: tools.DNSKey{Flags: 257, Protocol: 3, Algorithm: 13}},
+				},
+				DNSSECEnabled: true,
+			},
+			Timestamp: timestamp,
+		},
+		{
+			Tool:      enums.ToolHarvester,
+			Result:    &tools.HarvesterResult{Emails: []string{"info@example.com"}, Subdomains: []string{"www.example.com"}},
+			Timestamp: timestamp,
+		},
+		{
+			Tool:      enums.ToolDNSLookup,
+			Err:       &tools.ToolError{Code: enums.TimeoutError, Message: "lookup timed out"},
+			Timestamp: timestamp,
+		},
+	}
+
+	codecs := []Codec{JSONCodec{}, MsgpackCodec{}}
+
+	for _, codec := range codecs {
+		for _, res := range results {
+			t.Run(codec.ContentType()+"/"+res.Tool.String(), func(t *testing.T) {
+				evt := NewToolResultEvent(uuid.New(), res)
+
+				data, err := codec.Marshal(evt)
+				require.NoError(t, err)
+
+				var got ToolResultEvent
+				require.NoError(t, codec.Unmarshal(data, &got))
+
+				assert.Equal(t, evt.ScanID, got.ScanID)
+				assert.True(t, evt.Timestamp.Equal(got.Timestamp))
+				assert.Equal(t, res.Tool, got.ToolResult.Tool)
+				assert.Equal(t, res.Result, got.ToolResult.Result)
+				assert.Equal(t, res.Err, got.ToolResult.Err)
+			})
+		}
+	}
+}
+
+func Test_DecodeMsg(t *testing.T) {
+	evt := NewScanFailedEvent(uuid.New(), "target unreachable")
+
+	testCases := []struct {
+		name        string
+		contentType string
+		codec       Codec
+		expectError bool
+	}{
+		{
+			name:  "No content type falls back to JSON",
+			codec: JSONCodec{},
+		},
+		{
+			name:        "JSON with charset parameter",
+			contentType: "application/json; charset=utf-8",
+			codec:       JSONCodec{},
+		},
+		{
+			name:        "MessagePack",
+			contentType: ContentTypeMsgpack,
+			codec:       MsgpackCodec{},
+		},
+		{
+			name:        "Unsupported content type",
+			contentType: "application/xml",
+			codec:       JSONCodec{},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.codec.Marshal(evt)
+			require.NoError(t, err)
+
+			msg := nats.NewMsg(string(enums.ScanFailedEventSubject))
+			msg.Data = data
+			if tc.contentType != "" {
+				msg.Header.Set(ContentTypeHeader, tc.contentType)
+			}
+
+			var got ScanFailedEvent
+			err = DecodeMsg(msg, &got)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, evt.ScanID, got.ScanID)
+			assert.Equal(t, evt.Reason, got.Reason)
+		})
+	}
+}
+
+func Test_EventBusDecodeMsg(t *testing.T) {
+	evt := NewScanFailedEvent(uuid.New(), "target unreachable")
+	codec := MsgpackCodec{}
+	payload, err := codec.Marshal(evt)
+	require.NoError(t, err)
+
+	msg := nats.NewMsg("event.scanfailed")
+	msg.Data = payload
+	msg.Header.Set(ContentTypeHeader, codec.ContentType())
+
+	// Consumers only holding the interface decode with the advertised codec
+	var bus EventCodecBus = &NatsEventBus{}
+	var got ScanFailedEvent
+	require.NoError(t, bus.DecodeMsg(msg, &got))
+	assert.Equal(t, evt.ScanID, got.ScanID)
+	assert.Equal(t, evt.Reason, got.Reason)
+}
+
+// handlerBus records the handlers subscribed to, instead of a NATS connection.
+type handlerBus struct {
+	NatsEventBus
+	handlers map[string]func(msg *nats.Msg)
+}
+
+func (b *handlerBus) Subscribe(subject string, handler func(msg *nats.Msg)) error {
+	b.handlers[subject] = handler
+	return nil
+}
+
+func Test_SubscribeEvent(t *testing.T) {
+	evt := NewScanFailedEvent(uuid.New(), "target unreachable")
+
+	testCases := []struct {
+		name        string
+		codec       Codec
+		payload     func(t *testing.T, codec Codec) []byte
+		wantHandled bool
+	}{
+		{
+			name:  "JSON event",
+			codec: JSONCodec{},
+			payload: func(t *testing.T, codec Codec) []byte {
+				payload, err := codec.Marshal(evt)
+				require.NoError(t, err)
+				return payload
+			},
+			wantHandled: true,
+		},
+		{
+			name:  "Msgpack event",
+			codec: MsgpackCodec{},
+			payload: func(t *testing.T, codec Codec) []byte {
+				payload, err := codec.Marshal(evt)
+				require.NoError(t, err)
+				return payload
+			},
+			wantHandled: true,
+		},
+		{
+			name:  "Undecodable payload is dropped",
+			codec: JSONCodec{},
+			payload: func(_ *testing.T, _ Codec) []byte {
+				return []byte("{not json")
+			},
+			wantHandled: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bus := &handlerBus{handlers: map[string]func(msg *nats.Msg){}}
+
+			var got *ScanFailedEvent
+			err := SubscribeEvent(bus, "event.scanfailed", func(_ *nats.Msg, event *ScanFailedEvent) {
+				got = event
+			})
+			require.NoError(t, err)
+			require.Contains(t, bus.handlers, "event.scanfailed")
+
+			msg := nats.NewMsg("event.scanfailed")
+			msg.Data = tc.payload(t, tc.codec)
+			msg.Header.Set(ContentTypeHeader, tc.codec.ContentType())
+			bus.handlers["event.scanfailed"](msg)
+
+			if !tc.wantHandled {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			assert.Equal(t, evt.ScanID, got.ScanID)
+			assert.Equal(t, evt.Reason, got.Reason)
+		})
+	}
+}
@@ -14,6 +14,14 @@ type BaseEvent struct {
 
 	// Timestamp is the UTC timestamp when the scan started
 	Timestamp time.Time `json:"timestamp"`
+
+	// TenantID is the ID of the organization the scan belongs to
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// GetTenantID returns the ID of the tenant the event belongs to.
+func (e BaseEvent) GetTenantID() string {
+	return e.TenantID
 }
 
 // ScanStartedEvent represents the payload for a scan initiation event.
@@ -50,26 +58,41 @@ func NewScanStartedEvent(scanID uuid.UUID, target results.Target) ScanStartedEve
 		BaseEvent: BaseEvent{
 			ScanID:    scanID,
 			Timestamp: time.Now().UTC(),
+			TenantID:  target.TenantID,
 		},
 		Target: target,
 	}
 }
 
 func NewScanFailedEvent(scanID uuid.UUID, reason string) ScanFailedEvent {
+	return NewScanFailedEventForTenant("", scanID, reason)
+}
+
+// NewScanFailedEventForTenant returns the event like NewScanFailedEvent, scoped
+// to the given tenant.
+func NewScanFailedEventForTenant(tenantID string, scanID uuid.UUID, reason string) ScanFailedEvent {
 	return ScanFailedEvent{
 		BaseEvent: BaseEvent{
 			ScanID:    scanID,
 			Timestamp: time.Now().UTC(),
+			TenantID:  tenantID,
 		},
 		Reason: reason,
 	}
 }
 
 func NewScanCancelledEvent(scanID uuid.UUID) ScanCancelledEvent {
+	return NewScanCancelledEventForTenant("", scanID)
+}
+
+// NewScanCancelledEventForTenant returns the event like NewScanCancelledEvent,
+// scoped to the given tenant.
+func NewScanCancelledEventForTenant(tenantID string, scanID uuid.UUID) ScanCancelledEvent {
 	return ScanCancelledEvent{
 		BaseEvent: BaseEvent{
 			ScanID:    scanID,
 			Timestamp: time.Now().UTC(),
+			TenantID:  tenantID,
 		},
 	}
 }
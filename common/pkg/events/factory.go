@@ -1,25 +1,45 @@
 package events
 
 import (
-	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/kptm-tools/common/common/pkg/results/tools"
 )
 
-type ToolEventFactory struct{}
+type ToolEventFactory struct {
+	// Codec used to encode the events. Defaults to DefaultCodec when nil.
+	Codec Codec
+}
 
 func (f *ToolEventFactory) BuildEvent(scanID uuid.UUID, toolResult tools.ToolResult) ([]byte, error) {
-	evt := NewToolResultEvent(scanID, toolResult)
-	return json.Marshal(evt)
+	return f.marshal(NewToolResultEvent(scanID, toolResult))
+}
+
+// BuildEventForTenant builds the event like BuildEvent, scoped to the given tenant.
+func (f *ToolEventFactory) BuildEventForTenant(tenantID string, scanID uuid.UUID, toolResult tools.ToolResult) ([]byte, error) {
+	return f.marshal(NewToolResultEventForTenant(tenantID, scanID, toolResult))
+}
+
+func (f *ToolEventFactory) marshal(evt ToolResultEvent) ([]byte, error) {
+	if f.Codec == nil {
+		return DefaultCodec.Marshal(evt)
+	}
+	return f.Codec.Marshal(evt)
 }
 
 func NewToolResultEvent(scanID uuid.UUID, toolResult tools.ToolResult) ToolResultEvent {
+	return NewToolResultEventForTenant("", scanID, toolResult)
+}
+
+// NewToolResultEventForTenant returns the event like NewToolResultEvent, scoped
+// to the given tenant.
+func NewToolResultEventForTenant(tenantID string, scanID uuid.UUID, toolResult tools.ToolResult) ToolResultEvent {
 	return ToolResultEvent{
 		BaseEvent: BaseEvent{
 			ScanID:    scanID,
 			Timestamp: time.Now().UTC(),
+			TenantID:  tenantID,
 		},
 		ToolResult: toolResult,
 	}
@@ -0,0 +1,23 @@
+package events
+
+import "github.com/nats-io/nats.go"
+
+// Middleware wraps a subscription handler, e.g., to filter, decorate or
+// instrument the messages delivered to it.
+type Middleware func(next nats.MsgHandler) nats.MsgHandler
+
+// WithMiddleware adds middlewares to every subscription made through the bus.
+// Middlewares run in the order they are given, after signature verification.
+func WithMiddleware(middlewares ...Middleware) NatsEventBusOption {
+	return func(n *NatsEventBus) {
+		n.middlewares = append(n.middlewares, middlewares...)
+	}
+}
+
+// Chain wraps handler with the given middlewares, the first one being the outermost.
+func Chain(handler nats.MsgHandler, middlewares ...Middleware) nats.MsgHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,266 @@
+package events
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+)
+
+const (
+	// SignatureHeader carries the base64 encoded signature of a message.
+	SignatureHeader = "Kptm-Signature"
+
+	// SignatureKeyIDHeader carries the ID of the key that signed a message.
+	SignatureKeyIDHeader = "Kptm-Key-Id"
+)
+
+var (
+	// ErrUnsignedMessage is returned when a message carries no signature.
+	ErrUnsignedMessage = errors.New("message is not signed")
+
+	// ErrUnknownSigningKey is returned when a message is signed by a key that is not in the Keyring.
+	ErrUnknownSigningKey = errors.New("message is signed by an untrusted key")
+
+	// ErrInvalidSignature is returned when a signature does not match the message.
+	ErrInvalidSignature = errors.New("message signature is invalid")
+)
+
+// signingPayload returns the bytes covered by a signature: the subject, the
+// content type, the tenant and the payload. Covering the subject and the
+// tenant prevents a signed message from being replayed on a different subject
+// or rerouted to a different tenant.
+func signingPayload(msg *nats.Msg) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(msg.Subject)
+	buf.WriteByte('\n')
+	buf.WriteString(signedHeader(msg, ContentTypeHeader))
+	buf.WriteByte('\n')
+	buf.WriteString(signedHeader(msg, TenantHeader))
+	buf.WriteByte('\n')
+	buf.Write(msg.Data)
+	return buf.Bytes()
+}
+
+// signedHeader returns every value of a signed header, so that a value added
+// to a signed message breaks its signature too.
+func signedHeader(msg *nats.Msg, key string) string {
+	if msg.Header == nil {
+		return ""
+	}
+	return strings.Join(msg.Header.Values(key), ",")
+}
+
+// Signer signs outgoing messages with an Ed25519 key.
+type Signer struct {
+	keyID string
+	sign  func(data []byte) ([]byte, error)
+}
+
+// NewEd25519Signer creates a Signer from an Ed25519 private key.
+// keyID identifies the key to consumers and must match their Keyring entry.
+func NewEd25519Signer(keyID string, key ed25519.PrivateKey) *Signer {
+	return &Signer{
+		keyID: keyID,
+		sign: func(data []byte) ([]byte, error) {
+			return ed25519.Sign(key, data), nil
+		},
+	}
+}
+
+// NewNkeySigner creates a Signer from a NATS nkey pair, e.g., one loaded with
+// nkeys.FromSeed. The public key is used as the key ID.
+func NewNkeySigner(kp nkeys.KeyPair) (*Signer, error) {
+	publicKey, err := kp.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nkey public key: %w", err)
+	}
+
+	return &Signer{
+		keyID: publicKey,
+		sign:  kp.Sign,
+	}, nil
+}
+
+// KeyID returns the ID of the signing key.
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// Sign signs msg and sets the SignatureHeader and SignatureKeyIDHeader.
+// Headers that are part of the signed content must be set before signing.
+func (s *Signer) Sign(msg *nats.Msg) error {
+	sig, err := s.sign(signingPayload(msg))
+	if err != nil {
+		return fmt.Errorf("failed to sign message: %w", err)
+	}
+
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+	msg.Header.Set(SignatureKeyIDHeader, s.keyID)
+	msg.Header.Set(SignatureHeader, base64.StdEncoding.EncodeToString(sig))
+	return nil
+}
+
+// Keyring holds the public keys of trusted producers, indexed by key ID.
+// It is safe for concurrent use, so keys can be rotated at runtime.
+type Keyring struct {
+	mu   sync.RWMutex
+	keys map[string]func(data, sig []byte) error
+}
+
+// NewKeyring creates an empty Keyring.
+func NewKeyring() *Keyring {
+	return &Keyring{
+		keys: make(map[string]func(data, sig []byte) error),
+	}
+}
+
+// AddEd25519 trusts an Ed25519 public key under the given key ID.
+func (k *Keyring) AddEd25519(keyID string, key ed25519.PublicKey) error {
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid Ed25519 public key size for `%s`: %d", keyID, len(key))
+	}
+
+	k.add(keyID, func(data, sig []byte) error {
+		if !ed25519.Verify(key, data, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	})
+	return nil
+}
+
+// AddNkey trusts a NATS nkey public key, e.g., "UD...". The public key is the key ID.
+func (k *Keyring) AddNkey(publicKey string) error {
+	kp, err := nkeys.FromPublicKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid nkey public key `%s`: %w", publicKey, err)
+	}
+
+	k.add(publicKey, func(data, sig []byte) error {
+		if err := kp.Verify(data, sig); err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	})
+	return nil
+}
+
+// Remove revokes trust in the given key ID.
+func (k *Keyring) Remove(keyID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.keys, keyID)
+}
+
+// Has reports whether the given key ID is trusted.
+func (k *Keyring) Has(keyID string) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	_, ok := k.keys[keyID]
+	return ok
+}
+
+func (k *Keyring) add(keyID string, verify func(data, sig []byte) error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[keyID] = verify
+}
+
+func (k *Keyring) lookup(keyID string) (func(data, sig []byte) error, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	verify, ok := k.keys[keyID]
+	return verify, ok
+}
+
+// SignaturePolicy defines which messages a Verifier rejects.
+type SignaturePolicy int
+
+const (
+	// SignaturePolicyRequire rejects unsigned messages and messages with invalid signatures.
+	SignaturePolicyRequire SignaturePolicy = iota
+
+	// SignaturePolicyOptional accepts unsigned messages, but rejects messages with invalid signatures.
+	// It is meant for rollouts where not every producer signs yet.
+	SignaturePolicyOptional
+
+	// SignaturePolicyAudit accepts every message, only reporting verification failures.
+	SignaturePolicyAudit
+)
+
+var signaturePolicyStrings = map[SignaturePolicy]string{
+	SignaturePolicyRequire:  "Require",
+	SignaturePolicyOptional: "Optional",
+	SignaturePolicyAudit:    "Audit",
+}
+
+func (p SignaturePolicy) String() string {
+	if str, exists := signaturePolicyStrings[p]; exists {
+		return str
+	}
+	return "Unknown"
+}
+
+// Verifier checks message signatures against a Keyring.
+type Verifier struct {
+	Keyring *Keyring
+	Policy  SignaturePolicy
+}
+
+// NewVerifier creates a Verifier for the given keyring and policy.
+func NewVerifier(keyring *Keyring, policy SignaturePolicy) *Verifier {
+	return &Verifier{
+		Keyring: keyring,
+		Policy:  policy,
+	}
+}
+
+// Verify checks the signature of msg. It returns ErrUnsignedMessage,
+// ErrUnknownSigningKey or ErrInvalidSignature (possibly wrapped) on failure.
+func (v *Verifier) Verify(msg *nats.Msg) error {
+	var keyID, encodedSig string
+	if msg.Header != nil {
+		keyID = msg.Header.Get(SignatureKeyIDHeader)
+		encodedSig = msg.Header.Get(SignatureHeader)
+	}
+	if keyID == "" || encodedSig == "" {
+		return ErrUnsignedMessage
+	}
+
+	verify, ok := v.Keyring.lookup(keyID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownSigningKey, keyID)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err.Error())
+	}
+
+	return verify(signingPayload(msg), sig)
+}
+
+// Accept verifies msg and reports whether it should be delivered under the
+// verifier policy, along with the verification error, if any.
+func (v *Verifier) Accept(msg *nats.Msg) (bool, error) {
+	err := v.Verify(msg)
+	switch {
+	case err == nil:
+		return true, nil
+	case v.Policy == SignaturePolicyAudit:
+		return true, err
+	case v.Policy == SignaturePolicyOptional && errors.Is(err, ErrUnsignedMessage):
+		return true, nil
+	default:
+		return false, err
+	}
+}
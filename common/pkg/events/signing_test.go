@@ -0,0 +1,193 @@
+package events
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMsg(subject string) *nats.Msg {
+	msg := nats.NewMsg(subject)
+	msg.Data = []byte(`{"scan_id":"a0e5c5e4-7a2c-4a7e-9a43-1d0e9f3c6b11"}`)
+	msg.Header.Set(ContentTypeHeader, ContentTypeJSON)
+	return msg
+}
+
+func Test_VerifierVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, untrustedPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keyring := NewKeyring()
+	require.NoError(t, keyring.AddEd25519("worker-1", pub))
+
+	signer := NewEd25519Signer("worker-1", priv)
+	verifier := NewVerifier(keyring, SignaturePolicyRequire)
+
+	testCases := []struct {
+		name     string
+		msg      func() *nats.Msg
+		expected error
+	}{
+		{
+			name: "Valid signature",
+			msg: func() *nats.Msg {
+				msg := newTestMsg("event.nmap")
+				require.NoError(t, signer.Sign(msg))
+				return msg
+			},
+		},
+		{
+			name: "Unsigned message",
+			msg: func() *nats.Msg {
+				return newTestMsg("event.nmap")
+			},
+			expected: ErrUnsignedMessage,
+		},
+		{
+			name: "Tampered payload",
+			msg: func() *nats.Msg {
+				msg := newTestMsg("event.nmap")
+				require.NoError(t, signer.Sign(msg))
+				msg.Data = append(msg.Data, ' ')
+				return msg
+			},
+			expected: ErrInvalidSignature,
+		},
+		{
+			name: "Replayed on another subject",
+			msg: func() *nats.Msg {
+				msg := newTestMsg("event.nmap")
+				require.NoError(t, signer.Sign(msg))
+				msg.Subject = "event.webscan"
+				return msg
+			},
+			expected: ErrInvalidSignature,
+		},
+		{
+			name: "Valid signature with tenant",
+			msg: func() *nats.Msg {
+				msg := newTestMsg("event.nmap")
+				msg.Header.Set(TenantHeader, "acme")
+				require.NoError(t, signer.Sign(msg))
+				return msg
+			},
+		},
+		{
+			name: "Tenant header added",
+			msg: func() *nats.Msg {
+				msg := newTestMsg("event.nmap")
+				require.NoError(t, signer.Sign(msg))
+				msg.Header.Set(TenantHeader, "acme")
+				return msg
+			},
+			expected: ErrInvalidSignature,
+		},
+		{
+			name: "Tenant header altered",
+			msg: func() *nats.Msg {
+				msg := newTestMsg("event.nmap")
+				msg.Header.Set(TenantHeader, "acme")
+				require.NoError(t, signer.Sign(msg))
+				msg.Header.Set(TenantHeader, "globex")
+				return msg
+			},
+			expected: ErrInvalidSignature,
+		},
+		{
+			name: "Tenant header value added",
+			msg: func() *nats.Msg {
+				msg := newTestMsg("event.nmap")
+				msg.Header.Set(TenantHeader, "acme")
+				require.NoError(t, signer.Sign(msg))
+				msg.Header.Add(TenantHeader, "globex")
+				return msg
+			},
+			expected: ErrInvalidSignature,
+		},
+		{
+			name: "Untrusted key",
+			msg: func() *nats.Msg {
+				msg := newTestMsg("event.nmap")
+				require.NoError(t, NewEd25519Signer("worker-2", untrustedPriv).Sign(msg))
+				return msg
+			},
+			expected: ErrUnknownSigningKey,
+		},
+		{
+			name: "Trusted key ID with foreign key",
+			msg: func() *nats.Msg {
+				msg := newTestMsg("event.nmap")
+				require.NoError(t, NewEd25519Signer("worker-1", untrustedPriv).Sign(msg))
+				return msg
+			},
+			expected: ErrInvalidSignature,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifier.Verify(tc.msg())
+			if tc.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func Test_VerifierAccept(t *testing.T) {
+	kp, err := nkeys.CreateAccount()
+	require.NoError(t, err)
+	publicKey, err := kp.PublicKey()
+	require.NoError(t, err)
+
+	keyring := NewKeyring()
+	require.NoError(t, keyring.AddNkey(publicKey))
+
+	signer, err := NewNkeySigner(kp)
+	require.NoError(t, err)
+	assert.Equal(t, publicKey, signer.KeyID())
+
+	signed := newTestMsg("event.whois")
+	require.NoError(t, signer.Sign(signed))
+
+	tampered := newTestMsg("event.whois")
+	require.NoError(t, signer.Sign(tampered))
+	tampered.Data = []byte(`{}`)
+
+	unsigned := newTestMsg("event.whois")
+
+	testCases := []struct {
+		name     string
+		policy   SignaturePolicy
+		msg      *nats.Msg
+		accepted bool
+	}{
+		{name: "Require accepts signed", policy: SignaturePolicyRequire, msg: signed, accepted: true},
+		{name: "Require rejects unsigned", policy: SignaturePolicyRequire, msg: unsigned, accepted: false},
+		{name: "Require rejects tampered", policy: SignaturePolicyRequire, msg: tampered, accepted: false},
+		{name: "Optional accepts unsigned", policy: SignaturePolicyOptional, msg: unsigned, accepted: true},
+		{name: "Optional rejects tampered", policy: SignaturePolicyOptional, msg: tampered, accepted: false},
+		{name: "Audit accepts tampered", policy: SignaturePolicyAudit, msg: tampered, accepted: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			accepted, _ := NewVerifier(keyring, tc.policy).Accept(tc.msg)
+			assert.Equal(t, tc.accepted, accepted)
+		})
+	}
+
+	keyring.Remove(publicKey)
+	accepted, err := NewVerifier(keyring, SignaturePolicyRequire).Accept(signed)
+	assert.False(t, accepted)
+	assert.ErrorIs(t, err, ErrUnknownSigningKey)
+}
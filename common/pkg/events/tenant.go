@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// TenantHeader carries the ID of the tenant a message belongs to.
+	TenantHeader = "Kptm-Tenant-Id"
+
+	// tenantSubjectPrefix is the first token of tenant-scoped subjects,
+	// e.g., "tenant.acme.event.nmap".
+	tenantSubjectPrefix = "tenant"
+)
+
+var (
+	// ErrInvalidTenantID is returned for tenant IDs that cannot be used as a subject token.
+	ErrInvalidTenantID = errors.New("invalid tenant ID")
+
+	// ErrTenantMismatch is returned when the tenant of a message is inconsistent
+	// across its subject, header and payload.
+	ErrTenantMismatch = errors.New("tenant mismatch")
+)
+
+// TenantScoped is implemented by events that belong to a tenant.
+type TenantScoped interface {
+	GetTenantID() string
+}
+
+// ValidateTenantID checks that tenantID is a single, non-wildcard subject token.
+func ValidateTenantID(tenantID string) error {
+	if tenantID == "" || strings.ContainsAny(tenantID, ".*> \t\r\n") {
+		return fmt.Errorf("%w: `%s`", ErrInvalidTenantID, tenantID)
+	}
+	return nil
+}
+
+// TenantSubject returns the subject of the given event subject scoped to a tenant,
+// e.g., TenantSubject("acme", enums.NmapEventSubject) returns "tenant.acme.event.nmap".
+func TenantSubject(tenantID string, subject enums.EventSubjectName) (string, error) {
+	if err := ValidateTenantID(tenantID); err != nil {
+		return "", err
+	}
+	return tenantSubjectPrefix + "." + tenantID + "." + string(subject), nil
+}
+
+// AllTenantsSubject returns the subject matching the given event subject for
+// every tenant, e.g., "tenant.*.event.nmap". It is meant for shared services
+// that process events of all tenants.
+func AllTenantsSubject(subject enums.EventSubjectName) string {
+	return tenantSubjectPrefix + ".*." + string(subject)
+}
+
+// ParseTenantSubject splits a tenant-scoped subject into its tenant ID and
+// event subject. ok is false if the subject is not tenant-scoped.
+func ParseTenantSubject(subject string) (tenantID string, eventSubject enums.EventSubjectName, ok bool) {
+	parts := strings.SplitN(subject, ".", 3)
+	if len(parts) != 3 || parts[0] != tenantSubjectPrefix || ValidateTenantID(parts[1]) != nil {
+		return "", "", false
+	}
+	return parts[1], enums.EventSubjectName(parts[2]), true
+}
+
+// TenantFromMsg returns the tenant of msg, taken from its subject or TenantHeader.
+// It returns ErrTenantMismatch if they disagree, and an empty ID for messages
+// that are not tenant-scoped.
+func TenantFromMsg(msg *nats.Msg) (string, error) {
+	subjectTenant, _, _ := ParseTenantSubject(msg.Subject)
+
+	var headerTenant string
+	if msg.Header != nil {
+		headerTenant = msg.Header.Get(TenantHeader)
+	}
+
+	if subjectTenant != "" && headerTenant != "" && subjectTenant != headerTenant {
+		return "", fmt.Errorf("%w: subject tenant `%s`, header tenant `%s`", ErrTenantMismatch, subjectTenant, headerTenant)
+	}
+	if subjectTenant != "" {
+		return subjectTenant, nil
+	}
+	return headerTenant, nil
+}
+
+type tenantContextKey struct{}
+
+// ContextWithTenant returns a copy of ctx carrying the tenant ID.
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ID carried by ctx, e.g., the context
+// given to handlers registered with SubscribeContext.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// tenantPayload decodes only the tenant of an event payload.
+type tenantPayload struct {
+	TenantID string `json:"tenant_id"`
+}
+
+// TenantIsolation returns a Middleware refusing to deliver messages across
+// tenants. A message is dropped when the tenant in its subject, TenantHeader
+// and payload disagree, or, if allowedTenants is not empty, when its tenant is
+// not one of them (including messages without a tenant).
+func TenantIsolation(logger *slog.Logger, allowedTenants ...string) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next nats.MsgHandler) nats.MsgHandler {
+		return func(msg *nats.Msg) {
+			tenantID, err := checkMsgTenant(msg)
+			if err == nil && len(allowedTenants) > 0 && !slices.Contains(allowedTenants, tenantID) {
+				err = fmt.Errorf("%w: tenant `%s` is not allowed", ErrTenantMismatch, tenantID)
+			}
+			if err != nil {
+				logger.Warn("Refused to deliver message across tenants",
+					slog.String("subject", msg.Subject),
+					slog.String("tenant_id", tenantID),
+					slog.String("error", err.Error()))
+				return
+			}
+			next(msg)
+		}
+	}
+}
+
+// checkMsgTenant returns the tenant of msg after checking that its payload
+// belongs to the same tenant as its subject and header.
+func checkMsgTenant(msg *nats.Msg) (string, error) {
+	tenantID, err := TenantFromMsg(msg)
+	if err != nil {
+		return "", err
+	}
+
+	var payload tenantPayload
+	if err := DecodeMsg(msg, &payload); err != nil {
+		return tenantID, fmt.Errorf("failed to decode tenant: %w", err)
+	}
+
+	if payload.TenantID != tenantID {
+		return tenantID, fmt.Errorf("%w: message tenant `%s`, payload tenant `%s`", ErrTenantMismatch, tenantID, payload.TenantID)
+	}
+	return tenantID, nil
+}
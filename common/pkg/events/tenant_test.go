@@ -0,0 +1,196 @@
+package events
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/results"
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TenantSubject(t *testing.T) {
+	subject, err := TenantSubject("acme", enums.NmapEventSubject)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant.acme.event.nmap", subject)
+
+	tenantID, eventSubject, ok := ParseTenantSubject(subject)
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenantID)
+	assert.Equal(t, enums.NmapEventSubject, eventSubject)
+
+	_, _, ok = ParseTenantSubject(string(enums.NmapEventSubject))
+	assert.False(t, ok)
+
+	for _, invalid := range []string{"", "ac.me", "*", ">", "ac me"} {
+		_, err := TenantSubject(invalid, enums.NmapEventSubject)
+		assert.ErrorIs(t, err, ErrInvalidTenantID, "tenant %q", invalid)
+	}
+
+	assert.Equal(t, "tenant.*.event.nmap", AllTenantsSubject(enums.NmapEventSubject))
+}
+
+func Test_TenantIsolation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	newMsg := func(subject, headerTenant, payloadTenant string) *nats.Msg {
+		evt := NewScanStartedEvent(uuid.New(), results.Target{Value: "example.com", Type: enums.Domain, TenantID: payloadTenant})
+		data, err := JSONCodec{}.Marshal(evt)
+		require.NoError(t, err)
+
+		msg := nats.NewMsg(subject)
+		msg.Data = data
+		if headerTenant != "" {
+			msg.Header.Set(TenantHeader, headerTenant)
+		}
+		return msg
+	}
+
+	acmeSubject, err := TenantSubject("acme", enums.ScanStartedEventSubject)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		allowed   []string
+		msg       *nats.Msg
+		delivered bool
+	}{
+		{
+			name:      "Consistent tenant",
+			msg:       newMsg(acmeSubject, "acme", "acme"),
+			delivered: true,
+		},
+		{
+			name:      "Legacy message without tenant",
+			msg:       newMsg(string(enums.ScanStartedEventSubject), "", ""),
+			delivered: true,
+		},
+		{
+			name:      "Payload of another tenant",
+			msg:       newMsg(acmeSubject, "acme", "globex"),
+			delivered: false,
+		},
+		{
+			name:      "Header of another tenant",
+			msg:       newMsg(acmeSubject, "globex", "acme"),
+			delivered: false,
+		},
+		{
+			name:      "Tenant payload on shared subject",
+			msg:       newMsg(string(enums.ScanStartedEventSubject), "", "acme"),
+			delivered: false,
+		},
+		{
+			name:      "Allowed tenant",
+			allowed:   []string{"acme"},
+			msg:       newMsg(acmeSubject, "acme", "acme"),
+			delivered: true,
+		},
+		{
+			name:      "Tenant not allowed",
+			allowed:   []string{"globex"},
+			msg:       newMsg(acmeSubject, "acme", "acme"),
+			delivered: false,
+		},
+		{
+			name:      "Message without tenant when tenants are restricted",
+			allowed:   []string{"acme"},
+			msg:       newMsg(string(enums.ScanStartedEventSubject), "", ""),
+			delivered: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			delivered := false
+			handler := Chain(func(msg *nats.Msg) { delivered = true }, TenantIsolation(logger, tc.allowed...))
+
+			handler(tc.msg)
+			assert.Equal(t, tc.delivered, delivered)
+		})
+	}
+}
+
+func Test_MsgContext(t *testing.T) {
+	msg := nats.NewMsg("tenant.acme.event.whois")
+
+	tenantID, ok := TenantFromContext(MsgContext(context.Background(), msg))
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenantID)
+
+	_, ok = TenantFromContext(MsgContext(context.Background(), nats.NewMsg("event.whois")))
+	assert.False(t, ok)
+}
+
+func Test_Chain(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next nats.MsgHandler) nats.MsgHandler {
+			return func(msg *nats.Msg) {
+				calls = append(calls, name)
+				next(msg)
+			}
+		}
+	}
+
+	handler := Chain(func(msg *nats.Msg) { calls = append(calls, "handler") }, record("first"), record("second"))
+	handler(nats.NewMsg("event.nmap"))
+
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func Test_TenantScopedEvents(t *testing.T) {
+	scanID := uuid.New()
+	toolResult := tools.ToolResult{Tool: enums.ToolNmap, Result: &tools.NmapResult{HostName: "example.com"}}
+
+	testCases := []struct {
+		name       string
+		event      TenantScoped
+		wantTenant string
+	}{
+		{name: "Scan failed", event: NewScanFailedEvent(scanID, "target unreachable")},
+		{name: "Scan failed for tenant", event: NewScanFailedEventForTenant("acme", scanID, "target unreachable"), wantTenant: "acme"},
+		{name: "Scan cancelled", event: NewScanCancelledEvent(scanID)},
+		{name: "Scan cancelled for tenant", event: NewScanCancelledEventForTenant("acme", scanID), wantTenant: "acme"},
+		{name: "Tool result", event: NewToolResultEvent(scanID, toolResult)},
+		{name: "Tool result for tenant", event: NewToolResultEventForTenant("acme", scanID, toolResult), wantTenant: "acme"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantTenant, tc.event.GetTenantID())
+		})
+	}
+}
+
+func Test_ToolEventFactoryForTenant(t *testing.T) {
+	scanID := uuid.New()
+	toolResult := tools.ToolResult{Tool: enums.ToolNmap, Result: &tools.NmapResult{HostName: "example.com"}}
+
+	for _, codec := range []Codec{nil, JSONCodec{}, MsgpackCodec{}} {
+		factory := &ToolEventFactory{Codec: codec}
+		decodeWith := codec
+		if decodeWith == nil {
+			decodeWith = DefaultCodec
+		}
+
+		payload, err := factory.BuildEventForTenant("acme", scanID, toolResult)
+		require.NoError(t, err)
+		var got ToolResultEvent
+		require.NoError(t, decodeWith.Unmarshal(payload, &got))
+		assert.Equal(t, "acme", got.TenantID)
+		assert.Equal(t, scanID, got.ScanID)
+
+		payload, err = factory.BuildEvent(scanID, toolResult)
+		require.NoError(t, err)
+		got = ToolResultEvent{}
+		require.NoError(t, decodeWith.Unmarshal(payload, &got))
+		assert.Empty(t, got.TenantID)
+	}
+}
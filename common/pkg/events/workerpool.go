@@ -0,0 +1,185 @@
+package events
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// OverflowPolicy defines what a worker pool does with a message when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the subscription until a worker frees a queue slot.
+	// Messages then pile up in the NATS client pending buffer until the
+	// subscription is flagged as a slow consumer, pushing back on the server.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowReject drops the message and logs it.
+	OverflowReject
+)
+
+var overflowPolicyStrings = map[OverflowPolicy]string{
+	OverflowBlock:  "Block",
+	OverflowReject: "Reject",
+}
+
+func (p OverflowPolicy) String() string {
+	if str, exists := overflowPolicyStrings[p]; exists {
+		return str
+	}
+	return "Unknown"
+}
+
+// ErrInvalidWorkerPoolConfig is returned for worker pool configurations that cannot run.
+var ErrInvalidWorkerPoolConfig = errors.New("invalid worker pool config")
+
+// WorkerPoolConfig configures the workers processing the messages of a subscription.
+type WorkerPoolConfig struct {
+	// Concurrency is the number of messages handled in parallel.
+	Concurrency int
+
+	// QueueDepth is the number of received messages waiting for a worker.
+	QueueDepth int
+
+	// Overflow defines what happens to messages received when the queue is full.
+	Overflow OverflowPolicy
+
+	// SlowHandlerThreshold logs handlers running longer than it. Zero disables it.
+	SlowHandlerThreshold time.Duration
+}
+
+func (c WorkerPoolConfig) validate() error {
+	if c.Concurrency < 1 || c.QueueDepth < 0 {
+		return ErrInvalidWorkerPoolConfig
+	}
+	return nil
+}
+
+// WithWorkerPool makes every subscription of the bus handle its messages with
+// a worker pool of the given configuration, instead of serially.
+func WithWorkerPool(cfg WorkerPoolConfig) NatsEventBusOption {
+	return func(n *NatsEventBus) {
+		n.poolConfig = &cfg
+	}
+}
+
+// WorkerPoolStats is a snapshot of the activity of a worker pool.
+type WorkerPoolStats struct {
+	Subject  string
+	Queued   int    // Messages waiting for a worker
+	Busy     int64  // Workers handling a message
+	Handled  uint64 // Messages handled
+	Rejected uint64 // Messages dropped because the queue was full
+	Slow     uint64 // Messages whose handler exceeded the SlowHandlerThreshold
+}
+
+// workerPool dispatches the messages of a subscription to a fixed number of workers.
+type workerPool struct {
+	subject string
+	cfg     WorkerPoolConfig
+	handler nats.MsgHandler
+	logger  *slog.Logger
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *nats.Msg
+	wg     sync.WaitGroup
+
+	busy     atomic.Int64
+	handled  atomic.Uint64
+	rejected atomic.Uint64
+	slow     atomic.Uint64
+}
+
+func newWorkerPool(subject string, handler nats.MsgHandler, cfg WorkerPoolConfig, logger *slog.Logger) *workerPool {
+	p := &workerPool{
+		subject: subject,
+		cfg:     cfg,
+		handler: handler,
+		logger:  logger,
+		queue:   make(chan *nats.Msg, cfg.QueueDepth),
+	}
+
+	p.wg.Add(cfg.Concurrency)
+	for i := 0; i < cfg.Concurrency; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// dispatch queues msg for the workers. It is the NATS subscription handler.
+func (p *workerPool) dispatch(msg *nats.Msg) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return
+	}
+
+	if p.cfg.Overflow == OverflowBlock {
+		p.queue <- msg
+		return
+	}
+
+	select {
+	case p.queue <- msg:
+	default:
+		rejected := p.rejected.Add(1)
+		p.logger.Warn("Rejected message, worker pool is saturated",
+			slog.String("subject", msg.Subject),
+			slog.Int("concurrency", p.cfg.Concurrency),
+			slog.Int("queue_depth", p.cfg.QueueDepth),
+			slog.Uint64("rejected_total", rejected))
+	}
+}
+
+func (p *workerPool) work() {
+	defer p.wg.Done()
+
+	for msg := range p.queue {
+		p.busy.Add(1)
+		start := time.Now()
+		p.handler(msg)
+		elapsed := time.Since(start)
+		p.busy.Add(-1)
+		p.handled.Add(1)
+
+		if p.cfg.SlowHandlerThreshold > 0 && elapsed > p.cfg.SlowHandlerThreshold {
+			p.slow.Add(1)
+			p.logger.Warn("Slow message handler",
+				slog.String("subject", msg.Subject),
+				slog.String("elapsed", elapsed.String()),
+				slog.String("threshold", p.cfg.SlowHandlerThreshold.String()),
+				slog.Int("queued", len(p.queue)))
+		}
+	}
+}
+
+// close stops accepting messages and waits for the queued ones to be handled.
+func (p *workerPool) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+func (p *workerPool) stats() WorkerPoolStats {
+	return WorkerPoolStats{
+		Subject:  p.subject,
+		Queued:   len(p.queue),
+		Busy:     p.busy.Load(),
+		Handled:  p.handled.Load(),
+		Rejected: p.rejected.Load(),
+		Slow:     p.slow.Load(),
+	}
+}
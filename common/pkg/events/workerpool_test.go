@@ -0,0 +1,122 @@
+package events
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WorkerPool(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("Bounds concurrency", func(t *testing.T) {
+		var running, maxRunning atomic.Int64
+		release := make(chan struct{})
+
+		pool := newWorkerPool("event.nmap", func(msg *nats.Msg) {
+			current := running.Add(1)
+			for {
+				prev := maxRunning.Load()
+				if current <= prev || maxRunning.CompareAndSwap(prev, current) {
+					break
+				}
+			}
+			<-release
+			running.Add(-1)
+		}, WorkerPoolConfig{Concurrency: 3, QueueDepth: 10}, logger)
+
+		for i := 0; i < 10; i++ {
+			pool.dispatch(nats.NewMsg("event.nmap"))
+		}
+
+		assert.Eventually(t, func() bool { return running.Load() == 3 }, time.Second, time.Millisecond)
+		assert.Equal(t, 7, pool.stats().Queued)
+
+		close(release)
+		pool.close()
+
+		assert.Equal(t, int64(3), maxRunning.Load())
+		assert.Equal(t, uint64(10), pool.stats().Handled)
+	})
+
+	t.Run("Rejects when saturated", func(t *testing.T) {
+		release := make(chan struct{})
+		started := make(chan struct{}, 10)
+
+		pool := newWorkerPool("event.nmap", func(msg *nats.Msg) {
+			started <- struct{}{}
+			<-release
+		}, WorkerPoolConfig{Concurrency: 1, QueueDepth: 2, Overflow: OverflowReject}, logger)
+
+		pool.dispatch(nats.NewMsg("event.nmap"))
+		<-started
+		for i := 0; i < 5; i++ {
+			pool.dispatch(nats.NewMsg("event.nmap"))
+		}
+
+		stats := pool.stats()
+		assert.Equal(t, 2, stats.Queued)
+		assert.Equal(t, uint64(3), stats.Rejected)
+
+		close(release)
+		pool.close()
+		assert.Equal(t, uint64(3), pool.stats().Handled)
+	})
+
+	t.Run("Blocks when saturated", func(t *testing.T) {
+		release := make(chan struct{})
+
+		pool := newWorkerPool("event.nmap", func(msg *nats.Msg) {
+			<-release
+		}, WorkerPoolConfig{Concurrency: 1, QueueDepth: 1, Overflow: OverflowBlock}, logger)
+
+		// One message handled, one queued
+		pool.dispatch(nats.NewMsg("event.nmap"))
+		pool.dispatch(nats.NewMsg("event.nmap"))
+		assert.Eventually(t, func() bool { return pool.stats().Busy == 1 && pool.stats().Queued == 1 }, time.Second, time.Millisecond)
+
+		var wg sync.WaitGroup
+		var dispatched atomic.Bool
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.dispatch(nats.NewMsg("event.nmap"))
+			dispatched.Store(true)
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		assert.False(t, dispatched.Load(), "dispatch must block while the queue is full")
+
+		close(release)
+		wg.Wait()
+		pool.close()
+		assert.Equal(t, uint64(3), pool.stats().Handled)
+		assert.Equal(t, uint64(0), pool.stats().Rejected)
+	})
+
+	t.Run("Counts slow handlers", func(t *testing.T) {
+		pool := newWorkerPool("event.nmap", func(msg *nats.Msg) {
+			time.Sleep(5 * time.Millisecond)
+		}, WorkerPoolConfig{Concurrency: 1, QueueDepth: 1, SlowHandlerThreshold: time.Millisecond}, logger)
+
+		pool.dispatch(nats.NewMsg("event.nmap"))
+		pool.close()
+		assert.Equal(t, uint64(1), pool.stats().Slow)
+
+		// Closed pools ignore late messages
+		pool.dispatch(nats.NewMsg("event.nmap"))
+		assert.Equal(t, uint64(1), pool.stats().Handled)
+	})
+}
+
+func Test_WorkerPoolConfigValidate(t *testing.T) {
+	assert.NoError(t, WorkerPoolConfig{Concurrency: 1}.validate())
+	assert.ErrorIs(t, WorkerPoolConfig{Concurrency: 0, QueueDepth: 10}.validate(), ErrInvalidWorkerPoolConfig)
+	assert.ErrorIs(t, WorkerPoolConfig{Concurrency: 2, QueueDepth: -1}.validate(), ErrInvalidWorkerPoolConfig)
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/events"
+)
+
+// BusStatsProvider exposes the state of an event bus, e.g., *events.NatsEventBus.
+type BusStatsProvider interface {
+	Stats() events.BusStats
+}
+
+// BusChecker reports the health of an event bus. It is down when the bus is
+// disconnected, and degraded when it has fewer subscriptions than expected or
+// has not received a message for longer than MaxMessageAge.
+type BusChecker struct {
+	Bus BusStatsProvider
+
+	// MinSubscriptions is the number of subscriptions the service is expected to hold.
+	MinSubscriptions int
+
+	// MaxMessageAge is the time after which a bus without incoming messages is
+	// reported degraded. Zero disables the check.
+	MaxMessageAge time.Duration
+
+	now func() time.Time
+}
+
+// NewBusChecker creates a BusChecker for the given bus.
+func NewBusChecker(bus BusStatsProvider, minSubscriptions int, maxMessageAge time.Duration) *BusChecker {
+	return &BusChecker{
+		Bus:              bus,
+		MinSubscriptions: minSubscriptions,
+		MaxMessageAge:    maxMessageAge,
+	}
+}
+
+func (c *BusChecker) Check(_ context.Context) CheckResult {
+	now := time.Now()
+	if c.now != nil {
+		now = c.now()
+	}
+
+	stats := c.Bus.Stats()
+	details := map[string]any{
+		"connection_status": stats.Status,
+		"subscriptions":     stats.Subscriptions,
+	}
+
+	var lastMessageAge time.Duration
+	if !stats.LastMessageAt.IsZero() {
+		lastMessageAge = now.Sub(stats.LastMessageAt)
+		details["last_message_at"] = stats.LastMessageAt.UTC()
+		details["last_message_age_seconds"] = lastMessageAge.Seconds()
+	}
+
+	switch {
+	case !stats.Connected:
+		return CheckResult{Status: StatusDown, Message: "not connected to NATS", Details: details}
+	case stats.Subscriptions < c.MinSubscriptions:
+		return CheckResult{
+			Status:  StatusDegraded,
+			Message: fmt.Sprintf("%d subscriptions, expected at least %d", stats.Subscriptions, c.MinSubscriptions),
+			Details: details,
+		}
+	case c.MaxMessageAge > 0 && stats.LastMessageAt.IsZero():
+		return CheckResult{Status: StatusDegraded, Message: "no message received yet", Details: details}
+	case c.MaxMessageAge > 0 && lastMessageAge > c.MaxMessageAge:
+		return CheckResult{
+			Status:  StatusDegraded,
+			Message: fmt.Sprintf("no message received for %s", lastMessageAge.Round(time.Second)),
+			Details: details,
+		}
+	default:
+		return CheckResult{Status: StatusUp, Details: details}
+	}
+}
@@ -0,0 +1,225 @@
+// Package health aggregates pluggable health checks into liveness and
+// readiness reports served as JSON over HTTP.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultCheckTimeout = 5 * time.Second
+
+// Status is the health status of a check or of a whole report.
+type Status string
+
+const (
+	// StatusUp means the component works as expected.
+	StatusUp Status = "UP"
+
+	// StatusDegraded means the component works, but not as expected, e.g., it
+	// has not received messages for a while. Degraded services still serve traffic.
+	StatusDegraded Status = "DEGRADED"
+
+	// StatusDown means the component does not work.
+	StatusDown Status = "DOWN"
+)
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// severity orders statuses from best to worst.
+func (s Status) severity() int {
+	switch s {
+	case StatusUp:
+		return 0
+	case StatusDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// CheckResult is the outcome of a single check.
+type CheckResult struct {
+	Status   Status         `json:"status"`
+	Message  string         `json:"message,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+	Duration time.Duration  `json:"duration"`
+}
+
+// Checker checks the health of a component.
+type Checker interface {
+	// Check returns the health of the component. It must honor ctx cancellation.
+	Check(ctx context.Context) CheckResult
+}
+
+// CheckerFunc adapts a function to the Checker interface.
+type CheckerFunc func(ctx context.Context) CheckResult
+
+func (f CheckerFunc) Check(ctx context.Context) CheckResult {
+	return f(ctx)
+}
+
+// Report aggregates the results of several checks. Its status is the worst
+// status of its checks.
+type Report struct {
+	Status    Status                 `json:"status"`
+	Checks    map[string]CheckResult `json:"checks"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// Health holds the liveness and readiness checks of a service.
+//
+// Liveness checks tell whether the process must be restarted, readiness
+// checks whether it can take work, e.g., whether it is connected to the bus.
+type Health struct {
+	mu        sync.RWMutex
+	liveness  map[string]Checker
+	readiness map[string]Checker
+	timeout   time.Duration
+}
+
+// Option configures optional behaviour of Health.
+type Option func(*Health)
+
+// WithCheckTimeout sets the time after which a check is reported as down.
+func WithCheckTimeout(d time.Duration) Option {
+	return func(h *Health) {
+		h.timeout = d
+	}
+}
+
+// New creates a Health without checks. A Health without checks reports up.
+func New(opts ...Option) *Health {
+	h := &Health{
+		liveness:  make(map[string]Checker),
+		readiness: make(map[string]Checker),
+		timeout:   defaultCheckTimeout,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// AddLivenessCheck registers a liveness check under the given name.
+func (h *Health) AddLivenessCheck(name string, c Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness[name] = c
+}
+
+// AddReadinessCheck registers a readiness check under the given name.
+func (h *Health) AddReadinessCheck(name string, c Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness[name] = c
+}
+
+// Liveness runs the liveness checks.
+func (h *Health) Liveness(ctx context.Context) Report {
+	return h.run(ctx, h.liveness)
+}
+
+// Readiness runs the readiness checks.
+func (h *Health) Readiness(ctx context.Context) Report {
+	return h.run(ctx, h.readiness)
+}
+
+// LivenessHandler serves the liveness report, e.g., on /healthz.
+func (h *Health) LivenessHandler() http.Handler {
+	return reportHandler(h.Liveness)
+}
+
+// ReadinessHandler serves the readiness report, e.g., on /readyz.
+func (h *Health) ReadinessHandler() http.Handler {
+	return reportHandler(h.Readiness)
+}
+
+// Handler serves the liveness report on /healthz and the readiness report on /readyz.
+func (h *Health) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", h.LivenessHandler())
+	mux.Handle("/readyz", h.ReadinessHandler())
+	return mux
+}
+
+// run runs checks concurrently, each with the configured timeout.
+func (h *Health) run(ctx context.Context, checks map[string]Checker) Report {
+	h.mu.RLock()
+	names := make([]string, 0, len(checks))
+	checkers := make([]Checker, 0, len(checks))
+	for name, c := range checks {
+		names = append(names, name)
+		checkers = append(checkers, c)
+	}
+	h.mu.RUnlock()
+
+	results := make([]CheckResult, len(checkers))
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c Checker) {
+			defer wg.Done()
+			results[i] = h.check(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusUp,
+		Checks:    make(map[string]CheckResult, len(checkers)),
+		Timestamp: time.Now().UTC(),
+	}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status.severity() > report.Status.severity() {
+			report.Status = results[i].Status
+		}
+	}
+	return report
+}
+
+// check runs c, reporting it down if it does not complete within the timeout.
+func (h *Health) check(ctx context.Context, c Checker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan CheckResult, 1)
+	go func() {
+		done <- c.Check(ctx)
+	}()
+
+	var res CheckResult
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res = CheckResult{Status: StatusDown, Message: "check timed out: " + ctx.Err().Error()}
+	}
+	res.Duration = time.Since(start)
+	if res.Status == "" {
+		res.Status = StatusDown
+	}
+	return res
+}
+
+func reportHandler(run func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := run(r.Context())
+
+		code := http.StatusOK
+		if report.Status == StatusDown {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
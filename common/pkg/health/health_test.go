@@ -0,0 +1,141 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func staticCheck(status Status) Checker {
+	return CheckerFunc(func(ctx context.Context) CheckResult {
+		return CheckResult{Status: status}
+	})
+}
+
+func Test_HealthReport(t *testing.T) {
+	testCases := []struct {
+		name     string
+		checks   map[string]Checker
+		expected Status
+		code     int
+	}{
+		{
+			name:     "No checks",
+			checks:   map[string]Checker{},
+			expected: StatusUp,
+			code:     http.StatusOK,
+		},
+		{
+			name:     "All up",
+			checks:   map[string]Checker{"a": staticCheck(StatusUp), "b": staticCheck(StatusUp)},
+			expected: StatusUp,
+			code:     http.StatusOK,
+		},
+		{
+			name:     "Degraded still serves",
+			checks:   map[string]Checker{"a": staticCheck(StatusUp), "b": staticCheck(StatusDegraded)},
+			expected: StatusDegraded,
+			code:     http.StatusOK,
+		},
+		{
+			name:     "Down wins",
+			checks:   map[string]Checker{"a": staticCheck(StatusDown), "b": staticCheck(StatusDegraded)},
+			expected: StatusDown,
+			code:     http.StatusServiceUnavailable,
+		},
+		{
+			name: "Timed out check is down",
+			checks: map[string]Checker{"slow": CheckerFunc(func(ctx context.Context) CheckResult {
+				<-ctx.Done()
+				time.Sleep(10 * time.Millisecond)
+				return CheckResult{Status: StatusUp}
+			})},
+			expected: StatusDown,
+			code:     http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(WithCheckTimeout(20 * time.Millisecond))
+			for name, c := range tc.checks {
+				h.AddReadinessCheck(name, c)
+			}
+
+			rec := httptest.NewRecorder()
+			h.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tc.code, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var report Report
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Equal(t, tc.expected, report.Status)
+			assert.Len(t, report.Checks, len(tc.checks))
+
+			// Liveness checks are independent from readiness ones
+			assert.Equal(t, StatusUp, h.Liveness(context.Background()).Status)
+		})
+	}
+}
+
+type fakeBus struct {
+	stats events.BusStats
+}
+
+func (b *fakeBus) Stats() events.BusStats { return b.stats }
+
+func Test_BusChecker(t *testing.T) {
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		stats    events.BusStats
+		expected Status
+	}{
+		{
+			name:     "Connected with recent message",
+			stats:    events.BusStats{Connected: true, Status: "CONNECTED", Subscriptions: 2, LastMessageAt: now.Add(-time.Minute)},
+			expected: StatusUp,
+		},
+		{
+			name:     "Disconnected",
+			stats:    events.BusStats{Connected: false, Status: "RECONNECTING", Subscriptions: 2, LastMessageAt: now},
+			expected: StatusDown,
+		},
+		{
+			name:     "Missing subscriptions",
+			stats:    events.BusStats{Connected: true, Status: "CONNECTED", Subscriptions: 1, LastMessageAt: now},
+			expected: StatusDegraded,
+		},
+		{
+			name:     "Stale messages",
+			stats:    events.BusStats{Connected: true, Status: "CONNECTED", Subscriptions: 2, LastMessageAt: now.Add(-time.Hour)},
+			expected: StatusDegraded,
+		},
+		{
+			name:     "No message yet",
+			stats:    events.BusStats{Connected: true, Status: "CONNECTED", Subscriptions: 2},
+			expected: StatusDegraded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := NewBusChecker(&fakeBus{stats: tc.stats}, 2, 10*time.Minute)
+			checker.now = func() time.Time { return now }
+
+			res := checker.Check(context.Background())
+			assert.Equal(t, tc.expected, res.Status, res.Message)
+			assert.Equal(t, tc.stats.Subscriptions, res.Details["subscriptions"])
+			assert.Equal(t, tc.stats.Status, res.Details["connection_status"])
+		})
+	}
+}
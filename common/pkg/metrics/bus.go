@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/events"
+	"github.com/nats-io/nats.go"
+)
+
+// BusMetrics instruments the messages published and handled through an events.NatsEventBus:
+//
+//	m := metrics.NewBusMetrics(registry)
+//	bus, err := events.NewNatsEventBus(connStr,
+//		events.WithMiddleware(m.Middleware()),
+//		events.WithPublishObserver(m.ObservePublish),
+//	)
+type BusMetrics struct {
+	published       *CounterVec
+	publishErrors   *CounterVec
+	publishedBytes  *CounterVec
+	received        *CounterVec
+	handlerDuration *HistogramVec
+	handlerPanics   *CounterVec
+}
+
+// NewBusMetrics registers the bus metrics in reg.
+func NewBusMetrics(reg *Registry) *BusMetrics {
+	return &BusMetrics{
+		published: reg.NewCounterVec("kptm_bus_messages_published_total",
+			"Number of messages published, by subject.", "subject"),
+		publishErrors: reg.NewCounterVec("kptm_bus_publish_errors_total",
+			"Number of messages that failed to publish, by subject.", "subject"),
+		publishedBytes: reg.NewCounterVec("kptm_bus_published_bytes_total",
+			"Size of the payloads published, by subject.", "subject"),
+		received: reg.NewCounterVec("kptm_bus_messages_received_total",
+			"Number of messages delivered to subscription handlers, by subject.", "subject"),
+		handlerDuration: reg.NewHistogramVec("kptm_bus_handler_duration_seconds",
+			"Time spent by subscription handlers, by subject.", nil, "subject"),
+		handlerPanics: reg.NewCounterVec("kptm_bus_handler_panics_total",
+			"Number of subscription handlers that panicked, by subject.", "subject"),
+	}
+}
+
+// ObservePublish records a published message. It is an events.PublishObserver.
+func (m *BusMetrics) ObservePublish(subject string, size int, _ time.Duration, err error) {
+	subject = normalizeSubject(subject)
+	if err != nil {
+		m.publishErrors.WithLabelValues(subject).Inc()
+		return
+	}
+	m.published.WithLabelValues(subject).Inc()
+	m.publishedBytes.WithLabelValues(subject).Add(float64(size))
+}
+
+// Middleware returns an events.Middleware recording the messages delivered to
+// the subscription handlers and the time spent handling them. Panics are
+// counted and propagated.
+func (m *BusMetrics) Middleware() events.Middleware {
+	return func(next nats.MsgHandler) nats.MsgHandler {
+		return func(msg *nats.Msg) {
+			subject := normalizeSubject(msg.Subject)
+			m.received.WithLabelValues(subject).Inc()
+
+			start := time.Now()
+			defer func() {
+				m.handlerDuration.WithLabelValues(subject).Observe(time.Since(start).Seconds())
+				if r := recover(); r != nil {
+					m.handlerPanics.WithLabelValues(subject).Inc()
+					panic(r)
+				}
+			}()
+
+			next(msg)
+		}
+	}
+}
+
+// normalizeSubject strips the tenant from tenant-scoped subjects, so that the
+// number of series does not grow with the number of tenants.
+func normalizeSubject(subject string) string {
+	if _, eventSubject, ok := events.ParseTenantSubject(subject); ok {
+		return string(eventSubject)
+	}
+	return subject
+}
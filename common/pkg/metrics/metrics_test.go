@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RegistryWriteTo(t *testing.T) {
+	reg := NewRegistry()
+
+	requests := reg.NewCounterVec("requests_total", "Number of requests.\nBy path.", "path")
+	requests.WithLabelValues("/b").Add(2)
+	requests.WithLabelValues(`/a"quoted"`).Inc()
+
+	inFlight := reg.NewGaugeVec("in_flight", "Requests in flight.")
+	inFlight.WithLabelValues().Set(3)
+	inFlight.WithLabelValues().Add(-1)
+
+	latency := reg.NewHistogramVec("latency_seconds", "Request latency.", []float64{1, 0.1}, "path")
+	for _, v := range []float64{0.05, 0.1, 0.5, 2} {
+		latency.WithLabelValues("/a").Observe(v)
+	}
+
+	var sb strings.Builder
+	_, err := reg.WriteTo(&sb)
+	require.NoError(t, err)
+
+	expected := `# HELP in_flight Requests in flight.
+# TYPE in_flight gauge
+in_flight 2
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{path="/a",le="0.1"} 2
+latency_seconds_bucket{path="/a",le="1"} 3
+latency_seconds_bucket{path="/a",le="+Inf"} 4
+latency_seconds_sum{path="/a"} 2.65
+latency_seconds_count{path="/a"} 4
+# HELP requests_total Number of requests.\nBy path.
+# TYPE requests_total counter
+requests_total{path="/a\"quoted\""} 1
+requests_total{path="/b"} 2
+`
+	assert.Equal(t, expected, sb.String())
+
+	assert.Panics(t, func() { reg.NewCounterVec("requests_total", "Duplicate.") })
+	assert.Panics(t, func() { requests.WithLabelValues("/a", "extra") })
+	assert.Panics(t, func() { requests.WithLabelValues("/a").Add(-1) })
+}
+
+func Test_RegistryHandler(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounterVec("events_total", "Number of events.").WithLabelValues().Inc()
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "events_total 1\n")
+}
+
+func Test_BusMetrics(t *testing.T) {
+	reg := NewRegistry()
+	m := NewBusMetrics(reg)
+
+	m.ObservePublish("tenant.acme.event.nmap", 120, time.Millisecond, nil)
+	m.ObservePublish("tenant.globex.event.nmap", 80, time.Millisecond, nil)
+	m.ObservePublish("event.whois", 10, time.Millisecond, errors.New("nats: connection closed"))
+
+	handler := m.Middleware()(func(msg *nats.Msg) {
+		if string(msg.Data) == "panic" {
+			panic("handler failure")
+		}
+	})
+	handler(nats.NewMsg("event.nmap"))
+
+	panicking := nats.NewMsg("event.nmap")
+	panicking.Data = []byte("panic")
+	assert.Panics(t, func() { handler(panicking) })
+
+	var sb strings.Builder
+	_, err := reg.WriteTo(&sb)
+	require.NoError(t, err)
+	out := sb.String()
+
+	assert.Contains(t, out, `kptm_bus_messages_published_total{subject="event.nmap"} 2`)
+	assert.Contains(t, out, `kptm_bus_published_bytes_total{subject="event.nmap"} 200`)
+	assert.Contains(t, out, `kptm_bus_publish_errors_total{subject="event.whois"} 1`)
+	assert.Contains(t, out, `kptm_bus_messages_received_total{subject="event.nmap"} 2`)
+	assert.Contains(t, out, `kptm_bus_handler_duration_seconds_count{subject="event.nmap"} 2`)
+	assert.Contains(t, out, `kptm_bus_handler_panics_total{subject="event.nmap"} 1`)
+	assert.NotContains(t, out, "acme")
+}
+
+func Test_ToolMetrics(t *testing.T) {
+	reg := NewRegistry()
+	m := NewToolMetrics(reg)
+
+	m.ObserveResult(tools.ToolResult{Tool: enums.ToolDNSLookup, Result: &tools.DNSLookupResult{LookupDuration: 250 * time.Millisecond}})
+	m.ObserveResult(tools.ToolResult{Tool: enums.ToolNmap, Err: &tools.ToolError{Code: enums.TimeoutError}})
+	m.ObserveDuration(enums.ToolNmap, 90*time.Second)
+
+	var sb strings.Builder
+	_, err := reg.WriteTo(&sb)
+	require.NoError(t, err)
+	out := sb.String()
+
+	assert.Contains(t, out, `kptm_tool_results_total{tool="DNSLookup",status="SUCCESS"} 1`)
+	assert.Contains(t, out, `kptm_tool_results_total{tool="Nmap",status="TIMEOUT_ERROR"} 1`)
+	assert.Contains(t, out, `kptm_tool_duration_seconds_bucket{tool="DNSLookup",le="0.5"} 1`)
+	assert.Contains(t, out, `kptm_tool_duration_seconds_bucket{tool="Nmap",le="60"} 0`)
+	assert.Contains(t, out, `kptm_tool_duration_seconds_bucket{tool="Nmap",le="120"} 1`)
+}
@@ -0,0 +1,333 @@
+// Package metrics collects counters, gauges and histograms about the bus and
+// tool activity, and exposes them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are the default histogram buckets, in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300}
+
+type metricType string
+
+const (
+	counterType   metricType = "counter"
+	gaugeType     metricType = "gauge"
+	histogramType metricType = "histogram"
+)
+
+// labelSeparator joins label values into series keys. It cannot appear in valid UTF-8.
+const labelSeparator = "\xff"
+
+// Registry holds metric families and writes them in the text exposition format.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+// family is a metric with all its labelled series.
+type family struct {
+	name       string
+	help       string
+	typ        metricType
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is a single labelled time series.
+type series struct {
+	labelValues []string
+
+	mu           sync.Mutex
+	value        float64  // Counter and gauge value
+	bucketCounts []uint64 // Histogram observations per bucket, not cumulative
+	sum          float64
+	count        uint64
+}
+
+func (r *Registry) register(name, help string, typ metricType, buckets []float64, labelNames []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.families[name]; exists {
+		panic(fmt.Sprintf("metrics: duplicate metric name %q", name))
+	}
+
+	f := &family{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+	r.families[name] = f
+	return f
+}
+
+func (f *family) with(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, labelSeparator)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: slices.Clone(labelValues)}
+		if f.typ == histogramType {
+			s.bucketCounts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct{ f *family }
+
+// Counter is a monotonically increasing value.
+type Counter struct{ s *series }
+
+// NewCounterVec registers a counter with the given label names.
+// It panics if the name is already registered.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{f: r.register(name, help, counterType, nil, labelNames)}
+}
+
+// WithLabelValues returns the counter for the given label values, in the order
+// of the label names.
+func (v *CounterVec) WithLabelValues(labelValues ...string) Counter {
+	return Counter{s: v.f.with(labelValues)}
+}
+
+// Inc increments the counter by one.
+func (c Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increases the counter by delta, which must not be negative.
+func (c Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.s.mu.Lock()
+	c.s.value += delta
+	c.s.mu.Unlock()
+}
+
+// GaugeVec is a gauge partitioned by labels.
+type GaugeVec struct{ f *family }
+
+// Gauge is a value that can go up and down.
+type Gauge struct{ s *series }
+
+// NewGaugeVec registers a gauge with the given label names.
+// It panics if the name is already registered.
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{f: r.register(name, help, gaugeType, nil, labelNames)}
+}
+
+// WithLabelValues returns the gauge for the given label values, in the order
+// of the label names.
+func (v *GaugeVec) WithLabelValues(labelValues ...string) Gauge {
+	return Gauge{s: v.f.with(labelValues)}
+}
+
+// Set sets the gauge to value.
+func (g Gauge) Set(value float64) {
+	g.s.mu.Lock()
+	g.s.value = value
+	g.s.mu.Unlock()
+}
+
+// Add adds delta, which may be negative, to the gauge.
+func (g Gauge) Add(delta float64) {
+	g.s.mu.Lock()
+	g.s.value += delta
+	g.s.mu.Unlock()
+}
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct{ f *family }
+
+// Histogram counts observations in buckets.
+type Histogram struct {
+	s       *series
+	buckets []float64
+}
+
+// NewHistogramVec registers a histogram with the given upper bucket bounds and
+// label names. DefBuckets is used when buckets is nil.
+// It panics if the name is already registered.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	buckets = slices.Clone(buckets)
+	sort.Float64s(buckets)
+	return &HistogramVec{f: r.register(name, help, histogramType, buckets, labelNames)}
+}
+
+// WithLabelValues returns the histogram for the given label values, in the
+// order of the label names.
+func (v *HistogramVec) WithLabelValues(labelValues ...string) Histogram {
+	return Histogram{s: v.f.with(labelValues), buckets: v.f.buckets}
+}
+
+// Observe adds an observation to the histogram.
+func (h Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.buckets, value)
+
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	if i < len(h.buckets) {
+		h.s.bucketCounts[i]++
+	}
+	h.s.sum += value
+	h.s.count++
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format, sorted
+// by name and label values.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(cw)
+	}
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// Handler returns an http.Handler serving the metrics, e.g., on /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_, _ = r.WriteTo(w)
+	})
+}
+
+func (f *family) write(w *countingWriter) {
+	f.mu.Lock()
+	all := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	f.mu.Unlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return slices.Compare(all[i].labelValues, all[j].labelValues) < 0
+	})
+
+	w.printf("# HELP %s %s\n", f.name, escapeHelp(f.help))
+	w.printf("# TYPE %s %s\n", f.name, f.typ)
+
+	for _, s := range all {
+		s.mu.Lock()
+		switch f.typ {
+		case histogramType:
+			var cumulative uint64
+			for i, upper := range f.buckets {
+				cumulative += s.bucketCounts[i]
+				w.printf("%s_bucket%s %d\n", f.name, f.labels(s.labelValues, formatFloat(upper)), cumulative)
+			}
+			w.printf("%s_bucket%s %d\n", f.name, f.labels(s.labelValues, "+Inf"), s.count)
+			w.printf("%s_sum%s %s\n", f.name, f.labels(s.labelValues, ""), formatFloat(s.sum))
+			w.printf("%s_count%s %d\n", f.name, f.labels(s.labelValues, ""), s.count)
+		default:
+			w.printf("%s%s %s\n", f.name, f.labels(s.labelValues, ""), formatFloat(s.value))
+		}
+		s.mu.Unlock()
+	}
+}
+
+// labels formats the label set of a series, with an additional "le" label for
+// histogram buckets when le is not empty.
+func (f *family) labels(values []string, le string) string {
+	if len(values) == 0 && le == "" {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(values)+1)
+	for i, name := range f.labelNames {
+		pairs = append(pairs, name+`="`+escapeLabelValue(values[i])+`"`)
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string       { return helpEscaper.Replace(s) }
+func escapeLabelValue(s string) string { return labelEscaper.Replace(s) }
+
+// countingWriter keeps the first write error and the number of bytes written.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) printf(format string, args ...any) {
+	if c.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(c.w, format, args...)
+	c.n += int64(n)
+	c.err = err
+}
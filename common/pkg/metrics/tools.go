@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+)
+
+// StatusSuccess is the status label of tool results without error.
+const StatusSuccess = "SUCCESS"
+
+// ToolBuckets are the histogram buckets of tool durations, in seconds. Tools
+// run from sub-second DNS lookups to hour-long network scans.
+var ToolBuckets = []float64{.1, .5, 1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}
+
+// ToolMetrics instruments tool runs and the tool results they produce.
+type ToolMetrics struct {
+	results  *CounterVec
+	duration *HistogramVec
+}
+
+// NewToolMetrics registers the tool metrics in reg.
+func NewToolMetrics(reg *Registry) *ToolMetrics {
+	return &ToolMetrics{
+		results: reg.NewCounterVec("kptm_tool_results_total",
+			"Number of tool results, by tool and status (SUCCESS or error code).", "tool", "status"),
+		duration: reg.NewHistogramVec("kptm_tool_duration_seconds",
+			"Time taken by tool runs, by tool.", ToolBuckets, "tool"),
+	}
+}
+
+// ObserveResult records a tool result. Results carrying their own duration,
+// such as DNS lookups, also record it.
+func (m *ToolMetrics) ObserveResult(r tools.ToolResult) {
+	status := StatusSuccess
+	if r.Err != nil {
+		status = string(r.Err.Code)
+	}
+	m.results.WithLabelValues(r.Tool.String(), status).Inc()
+
+	if dns, ok := r.Result.(*tools.DNSLookupResult); ok && dns.LookupDuration > 0 {
+		m.ObserveDuration(r.Tool, dns.LookupDuration)
+	}
+}
+
+// ObserveDuration records the duration of a tool run.
+func (m *ToolMetrics) ObserveDuration(tool enums.ToolName, d time.Duration) {
+	m.duration.WithLabelValues(tool.String()).Observe(d.Seconds())
+}
+
+// StartTimer starts timing a tool run. Call the returned function when the
+// run completes to record its duration:
+//
+//	defer m.StartTimer(enums.ToolNmap)()
+func (m *ToolMetrics) StartTimer(tool enums.ToolName) func() {
+	start := time.Now()
+	return func() {
+		m.ObserveDuration(tool, time.Since(start))
+	}
+}
@@ -7,11 +7,53 @@ import (
 	"github.com/kptm-tools/common/common/pkg/results/tools"
 )
 
+// ProtectionScoreOption adds optional inputs to CalculateProtectionScore.
+type ProtectionScoreOption func(*protectionScoreInputs)
+
+type protectionScoreInputs struct {
+	findings      []tools.Finding
+	emailExposure *tools.EmailExposure
+	subdomains    *tools.SubdomainEnrichment
+}
+
+// WithFindings adds findings, e.g., the email security findings, to the
+// vulnerabilities weighing on the score.
+func WithFindings(findings ...tools.Finding) ProtectionScoreOption {
+	return func(in *protectionScoreInputs) {
+		in.findings = append(in.findings, findings...)
+	}
+}
+
+// WithEmailExposure makes the email score count the deduplicated addresses
+// of the analyzed domain, rather than all the harvested emails.
+func WithEmailExposure(exposure *tools.EmailExposure) ProtectionScoreOption {
+	return func(in *protectionScoreInputs) {
+		in.emailExposure = exposure
+	}
+}
+
+// WithSubdomainEnrichment provides the live subdomains counted by the
+// subdomain score, see CalculateProtectionScore.
+func WithSubdomainEnrichment(enrichment *tools.SubdomainEnrichment) ProtectionScoreOption {
+	return func(in *protectionScoreInputs) {
+		in.subdomains = enrichment
+	}
+}
+
+// CalculateProtectionScore returns the protection score of a target, from 0 to
+// 100, from the results of the tools and the optional inputs.
+//
+// The subdomain score only counts confirmed live subdomains, given with
+// WithSubdomainEnrichment, e.g., the enrichment returned by
+// dnslookup.EnrichSubdomains for the harvested subdomains. Harvested
+// subdomains are never counted as is, since they include dead and wildcard
+// names, so without the enrichment the subdomain score is skipped.
 func CalculateProtectionScore(
 	whoisResult tools.WhoIsResult,
 	dnsLookupResult tools.DNSLookupResult,
 	harvesterResult tools.HarvesterResult,
 	nmapResult tools.NmapResult,
+	opts ...ProtectionScoreOption,
 ) (float64, error) {
 	const (
 		maxEmails      = 50
@@ -20,6 +62,11 @@ func CalculateProtectionScore(
 		vulnLimit      = 50
 	)
 
+	in := &protectionScoreInputs{}
+	for _, opt := range opts {
+		opt(in)
+	}
+
 	var emailCount, subdomainCount, dnsRecordCount int
 	var whoisSuccessful bool
 	var vulnResults []tools.Vulnerability
@@ -27,7 +74,12 @@ func CalculateProtectionScore(
 
 	// Extract relevant data
 	emailCount = len(harvesterResult.Emails)
-	subdomainCount = len(harvesterResult.Subdomains)
+	if in.emailExposure != nil {
+		emailCount = in.emailExposure.Personal + in.emailExposure.Role
+	}
+	if in.subdomains != nil {
+		subdomainCount = in.subdomains.Live
+	}
 	dnsRecordCount = len(dnsLookupResult.DNSRecords)
 	whoisSuccessful = whoisResult.Error == ""
 	openPorts := len(nmapResult.GetOpenPorts())
@@ -35,7 +87,7 @@ func CalculateProtectionScore(
 	// Extract vulnerability data
 	vulners := nmapResult.GetAllVulnerabilities()
 	vulnResults = append(vulnResults, vulners...)
-	vulnCounts := tools.GetSeverityCounts(vulnResults)
+	vulnCounts := tools.GetSeverityCounts(vulnResults).Add(tools.GetFindingSeverityCounts(in.findings))
 
 	// Calculate penalties
 	if nmapResult.MostLikelyOS.Accuracy > 1 {
@@ -55,6 +107,7 @@ func CalculateProtectionScore(
 	slog.Debug("Protection Score Calculation Data",
 		slog.Int("email_count", emailCount),
 		slog.Int("subdomain_count", subdomainCount),
+		slog.Bool("subdomains_enriched", in.subdomains != nil),
 		slog.Int("dns_record_count", dnsRecordCount),
 		slog.Bool("whois_successful", whoisSuccessful),
 		slog.Int("open_ports", openPorts),
@@ -0,0 +1,94 @@
+package results
+
+import (
+	"testing"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/results/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CalculateProtectionScoreWithFindings(t *testing.T) {
+	dns := tools.DNSLookupResult{
+		Domain: "example.com",
+		DNSRecords: []tools.DNSRecord{
+			{Type: tools.TXTRecord, Name: "example.com", Value: "v=spf1 +all"},
+		},
+	}
+
+	base, err := CalculateProtectionScore(tools.WhoIsResult{}, dns, tools.HarvesterResult{}, tools.NmapResult{})
+	require.NoError(t, err)
+
+	// Options without findings leave the score unchanged
+	same, err := CalculateProtectionScore(tools.WhoIsResult{}, dns, tools.HarvesterResult{}, tools.NmapResult{}, WithFindings())
+	require.NoError(t, err)
+	assert.Equal(t, base, same)
+
+	emailSecurity := dns.EmailSecurity()
+	require.NotEmpty(t, emailSecurity.Findings)
+	withFindings, err := CalculateProtectionScore(tools.WhoIsResult{}, dns, tools.HarvesterResult{}, tools.NmapResult{},
+		WithFindings(emailSecurity.Findings...),
+		WithFindings(tools.Finding{ID: "other", Severity: enums.SeverityTypeCritical}),
+	)
+	require.NoError(t, err)
+	assert.Less(t, withFindings, base)
+}
+
+func Test_CalculateProtectionScoreWithEmailExposure(t *testing.T) {
+	harvester := tools.HarvesterResult{Emails: []string{
+		"john.doe@example.com", "JOHN.DOE@example.com", "info@example.com",
+		"someone@gmail.com", "other@gmail.com", "third@gmail.com",
+	}}
+
+	raw, err := CalculateProtectionScore(tools.WhoIsResult{}, tools.DNSLookupResult{}, harvester, tools.NmapResult{})
+	require.NoError(t, err)
+
+	exposure := harvester.EmailExposure("example.com")
+	require.Equal(t, 2, exposure.Personal+exposure.Role)
+	withExposure, err := CalculateProtectionScore(tools.WhoIsResult{}, tools.DNSLookupResult{}, harvester, tools.NmapResult{},
+		WithEmailExposure(exposure))
+	require.NoError(t, err)
+	assert.Greater(t, withExposure, raw)
+
+	// Only the counts of the exposure are used
+	same, err := CalculateProtectionScore(tools.WhoIsResult{}, tools.DNSLookupResult{}, tools.HarvesterResult{}, tools.NmapResult{},
+		WithEmailExposure(exposure))
+	require.NoError(t, err)
+	assert.Equal(t, withExposure, same)
+}
+
+func Test_CalculateProtectionScoreWithSubdomainEnrichment(t *testing.T) {
+	harvester := tools.HarvesterResult{Subdomains: []string{"www.example.com", "old.example.com", "random.example.com"}}
+	enrichment := tools.NewSubdomainEnrichment("example.com", []string{"192.0.2.1"}, []tools.EnrichedSubdomain{
+		{Name: "www.example.com", IPs: []string{"93.184.216.34"}, Status: tools.SubdomainLive},
+		{Name: "old.example.com", Status: tools.SubdomainDead},
+		{Name: "random.example.com", IPs: []string{"192.0.2.1"}, Status: tools.SubdomainLive},
+	})
+	require.Equal(t, 1, enrichment.Live)
+
+	score, err := CalculateProtectionScore(tools.WhoIsResult{}, tools.DNSLookupResult{}, harvester, tools.NmapResult{},
+		WithSubdomainEnrichment(enrichment))
+	require.NoError(t, err)
+
+	// Only the live subdomains are counted, whatever the harvested ones
+	for _, subdomains := range [][]string{nil, {"www.example.com"}, {"a.example.com", "b.example.com", "c.example.com", "d.example.com"}} {
+		same, err := CalculateProtectionScore(tools.WhoIsResult{}, tools.DNSLookupResult{}, tools.HarvesterResult{Subdomains: subdomains}, tools.NmapResult{},
+			WithSubdomainEnrichment(enrichment))
+		require.NoError(t, err)
+		assert.Equal(t, score, same, subdomains)
+	}
+
+	noneLive := tools.NewSubdomainEnrichment("example.com", nil, []tools.EnrichedSubdomain{
+		{Name: "old.example.com", Status: tools.SubdomainDead},
+	})
+	withoutLive, err := CalculateProtectionScore(tools.WhoIsResult{}, tools.DNSLookupResult{}, harvester, tools.NmapResult{},
+		WithSubdomainEnrichment(noneLive))
+	require.NoError(t, err)
+	assert.Greater(t, withoutLive, score)
+
+	// Unconfirmed subdomains are not counted
+	unconfirmed, err := CalculateProtectionScore(tools.WhoIsResult{}, tools.DNSLookupResult{}, harvester, tools.NmapResult{})
+	require.NoError(t, err)
+	assert.Equal(t, withoutLive, unconfirmed)
+}
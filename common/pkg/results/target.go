@@ -12,4 +12,7 @@ type Target struct {
 
 	// Type specifies whether the target is an IP or a Domain.
 	Type enums.TargetType `json:"type"`
+
+	// TenantID is the ID of the organization owning the target.
+	TenantID string `json:"tenant_id,omitempty"`
 }
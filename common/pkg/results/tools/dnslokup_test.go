@@ -1,6 +1,14 @@
 package tools
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
 
 func Test_HasDNSKeyRecord(t *testing.T) {
 	var testCases = []struct {
@@ -59,3 +67,237 @@ func Test_HasDNSKeyRecord(t *testing.T) {
 		})
 	}
 }
+
+func Test_DNSRecordRoundTrip(t *testing.T) {
+	priority := 10
+	records := []DNSRecord{
+		{Type: ARecord, Name: "example.com", TTL: 300, Value: "93.184.216.34"},
+		{Type: AAAARecord, Name: "example.com", TTL: 300, Value: "2606:2800:220:1:248:1893:25c8:1946"},
+		{Type: CNAMERecord, Name: "www.example.com", TTL: 300, Value: "example.com"},
+		{Type: TXTRecord, Name: "example.com", TTL: 300, Value: "v=spf1 -all"},
+		{Type: NSRecord, Name: "example.com", TTL: 86400, Value: "a.iana-servers.net"},
+		{Type: MXRecord, Name: "example.com", TTL: 300, Value: MailExchange{Host: "mail.example.com", Priority: 10}, Priority: &priority},
+		{Type: SOARecord, Name: "example.com", TTL: 3600, Value: StartOfAuthority{
+			PrimaryNS: "ns.icann.org", AdminEmail: "noc.dns.icann.org", Serial: 2024081426,
+			Refresh: 7200, Retry: 3600, Expire: 1209600, MinimumTTL: 3600,
+		}},
+		{Type: DNSKeyRecord, Name: "example.com", TTL: 3600, Value: DNSKey{Flags: 257, Protocol: 3, Algorithm: 13}},
+		{Type: DNSKeyRecord, Name: "example.com", TTL: 3600, Value: DNSKey{Flags: 256, Protocol: 3, Algorithm: 13, KeyTag: 31589, PublicKey: "mdsswUyr3DPW132mOi8V9xESWE8jTo0d"}},
+		{Type: CAARecord, Name: "example.com", TTL: 3600, Value: CertificationAuthorityAuthorization{Flag: 0, Tag: "issue", Value: "letsencrypt.org"}},
+		{Type: SRVRecord, Name: "_sip._tcp.example.com", TTL: 300, Value: ServiceLocation{Priority: 10, Weight: 60, Port: 5060, Target: "sip.example.com"}},
+		{Type: PTRRecord, Name: "34.216.184.93.in-addr.arpa", TTL: 300, Value: "example.com"},
+		{Type: DSRecord, Name: "example.com", TTL: 86400, Value: DelegationSigner{KeyTag: 370, Algorithm: 13, DigestType: 2, Digest: "be74359954660069d5c63d200c39f5603827d7dd02b56f120ee9f3a86764247c"}},
+		{Type: RRSIGRecord, Name: "example.com", TTL: 3600, Value: RecordSignature{
+			TypeCovered: ARecord, Algorithm: 13, Labels: 2, OriginalTTL: 3600,
+			Expiration: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Inception: time.Date(2025, 1, 11, 0, 0, 0, 0, time.UTC),
+			KeyTag: 31589, SignerName: "example.com",
+		}},
+		{Type: NSECRecord, Name: "example.com", TTL: 3600, Value: NextSecure{NextDomain: "www.example.com", Types: []DNSRecordType{ARecord, NSRecord, SOARecord, RRSIGRecord, NSECRecord, DNSKeyRecord}}},
+		{Type: NSEC3Record, Name: "a1b2c3.example.com", TTL: 3600, Value: NextSecure3{HashAlgorithm: 1, Iterations: 0, NextHashed: "d4e5f6", Types: []DNSRecordType{ARecord}}},
+		{Type: HTTPSRecord, Name: "example.com", TTL: 300, Value: ServiceBinding{Priority: 1, Target: ".", Params: map[string]string{"alpn": "h2,h3", "ipv4hint": "93.184.216.34"}}},
+		{Type: SVCBRecord, Name: "_dns.example.com", TTL: 300, Value: ServiceBinding{Priority: 0, Target: "dns.example.net"}},
+		{Type: "SPF", Name: "example.com", TTL: 300, Value: map[string]interface{}{"raw": "v=spf1 -all"}},
+		{Type: ARecord, Name: "empty.example.com", TTL: 300},
+	}
+
+	codecs := map[string]struct {
+		marshal   func(v any) ([]byte, error)
+		unmarshal func(data []byte, v any) error
+	}{
+		"JSON": {marshal: json.Marshal, unmarshal: json.Unmarshal},
+		"Msgpack": {
+			marshal: func(v any) ([]byte, error) {
+				var buf bytes.Buffer
+				enc := msgpack.NewEncoder(&buf)
+				enc.SetCustomStructTag("json")
+				err := enc.Encode(v)
+				return buf.Bytes(), err
+			},
+			unmarshal: func(data []byte, v any) error {
+				dec := msgpack.NewDecoder(bytes.NewReader(data))
+				dec.SetCustomStructTag("json")
+				return dec.Decode(v)
+			},
+		},
+	}
+
+	for name, codec := range codecs {
+		for _, record := range records {
+			t.Run(name+"/"+string(record.Type)+"/"+record.Name, func(t *testing.T) {
+				data, err := codec.marshal(record)
+				if err != nil {
+					t.Fatalf("unexpected marshal error: %v", err)
+				}
+
+				var got DNSRecord
+				if err := codec.unmarshal(data, &got); err != nil {
+					t.Fatalf("unexpected unmarshal error: %v", err)
+				}
+				// msgpack decodes times in the local time zone
+				if sig, ok := got.Value.(RecordSignature); ok {
+					sig.Inception, sig.Expiration = sig.Inception.UTC(), sig.Expiration.UTC()
+					got.Value = sig
+				}
+				if !reflect.DeepEqual(record, got) {
+					t.Errorf("Incorrect result, expected `%#v`, got `%#v`", record, got)
+				}
+			})
+		}
+	}
+}
+
+func Test_DNSRecordAccessors(t *testing.T) {
+	var lookup DNSLookupResult
+	payload := `{"domain":"example.com","dns_records":[
+		{"type":"A","name":"example.com","ttl":300,"value":"93.184.216.34"},
+		{"type":"MX","name":"example.com","ttl":300,"value":{"host":"mail.example.com","priority":10}},
+		{"type":"SOA","name":"example.com","ttl":3600,"value":{"primary_ns":"ns.icann.org","serial":2024081426}},
+		{"type":"DNSKey","name":"example.com","ttl":3600,"value":{"flags":256,"protocol":3,"algorithm":8}}
+	]}`
+	if err := json.Unmarshal([]byte(payload), &lookup); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a, mx, soa, key := lookup.DNSRecords[0], lookup.DNSRecords[1], lookup.DNSRecords[2], lookup.DNSRecords[3]
+
+	if v, ok := a.AsString(); !ok || v != "93.184.216.34" {
+		t.Errorf("AsString() = %q, %v", v, ok)
+	}
+	if v, ok := mx.AsMX(); !ok || v != (MailExchange{Host: "mail.example.com", Priority: 10}) {
+		t.Errorf("AsMX() = %+v, %v", v, ok)
+	}
+	if v, ok := soa.AsSOA(); !ok || v.PrimaryNS != "ns.icann.org" || v.Serial != 2024081426 {
+		t.Errorf("AsSOA() = %+v, %v", v, ok)
+	}
+	if v, ok := key.AsDNSKey(); !ok || v != (DNSKey{Flags: 256, Protocol: 3, Algorithm: 8}) {
+		t.Errorf("AsDNSKey() = %+v, %v", v, ok)
+	}
+
+	// Accessors of other record types fail
+	if _, ok := a.AsMX(); ok {
+		t.Errorf("AsMX() succeeded on an A record")
+	}
+	if _, ok := mx.AsString(); ok {
+		t.Errorf("AsString() succeeded on an MX record")
+	}
+
+	// Values set by producers as pointers are accessible as well
+	ptr := DNSRecord{Type: MXRecord, Value: &MailExchange{Host: "mx.example.com", Priority: 5}}
+	if v, ok := ptr.AsMX(); !ok || v.Host != "mx.example.com" {
+		t.Errorf("AsMX() = %+v, %v", v, ok)
+	}
+	if _, ok := (DNSRecord{Type: MXRecord, Value: (*MailExchange)(nil)}).AsMX(); ok {
+		t.Errorf("AsMX() succeeded on a nil value")
+	}
+
+	// Values not matching their type are rejected
+	var invalid DNSRecord
+	if err := json.Unmarshal([]byte(`{"type":"MX","value":"mail.example.com"}`), &invalid); err == nil {
+		t.Errorf("expected an error for a string MX value")
+	}
+}
+
+func Test_ExtendedDNSRecordAccessors(t *testing.T) {
+	var lookup DNSLookupResult
+	payload := `{"domain":"example.com","dns_records":[
+		{"type":"CAA","name":"example.com","ttl":3600,"value":{"flag":128,"tag":"issue","value":"pki.goog"}},
+		{"type":"SRV","name":"_imaps._tcp.example.com","ttl":300,"value":{"priority":0,"weight":1,"port":993,"target":"imap.example.com"}},
+		{"type":"HTTPS","name":"example.com","ttl":300,"value":{"priority":1,"target":".","params":{"alpn":"h2,h3"}}},
+		{"type":"PTR","name":"1.2.0.192.in-addr.arpa","ttl":300,"value":"host.example.com"}
+	]}`
+	if err := json.Unmarshal([]byte(payload), &lookup); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	caa, srv, https, ptr := lookup.DNSRecords[0], lookup.DNSRecords[1], lookup.DNSRecords[2], lookup.DNSRecords[3]
+
+	if v, ok := caa.AsCAA(); !ok || v.Value != "pki.goog" || !v.IsCritical() {
+		t.Errorf("AsCAA() = %+v, %v", v, ok)
+	}
+	if v, ok := srv.AsSRV(); !ok || v != (ServiceLocation{Weight: 1, Port: 993, Target: "imap.example.com"}) {
+		t.Errorf("AsSRV() = %+v, %v", v, ok)
+	}
+	v, ok := https.AsServiceBinding()
+	if !ok || v.IsAlias() || !reflect.DeepEqual(v.ALPN(), []string{"h2", "h3"}) {
+		t.Errorf("AsServiceBinding() = %+v, %v", v, ok)
+	}
+	if (ServiceBinding{Target: "cdn.example.net"}).ALPN() != nil {
+		t.Errorf("ALPN() of a binding without alpn parameter is not nil")
+	}
+	if v, ok := ptr.AsString(); !ok || v != "host.example.com" {
+		t.Errorf("AsString() = %q, %v", v, ok)
+	}
+	if _, ok := ptr.AsDS(); ok {
+		t.Errorf("AsDS() succeeded on a PTR record")
+	}
+}
+
+func Test_GetDNSSECStatus(t *testing.T) {
+	now := time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)
+	ksk := DNSRecord{Type: DNSKeyRecord, Name: "example.com", Value: DNSKey{Flags: 257, Protocol: 3, Algorithm: 13, KeyTag: 370}}
+	zsk := DNSRecord{Type: DNSKeyRecord, Name: "example.com", Value: DNSKey{Flags: 256, Protocol: 3, Algorithm: 13}}
+	ds := DNSRecord{Type: DSRecord, Name: "example.com", Value: DelegationSigner{KeyTag: 370, Algorithm: 13, DigestType: 2}}
+	staleDS := DNSRecord{Type: DSRecord, Name: "example.com", Value: DelegationSigner{KeyTag: 2371, Algorithm: 8, DigestType: 2}}
+	sig := func(inception, expiration time.Time) DNSRecord {
+		return DNSRecord{Type: RRSIGRecord, Name: "example.com", Value: RecordSignature{
+			TypeCovered: DNSKeyRecord, Algorithm: 13, KeyTag: 370, Inception: inception, Expiration: expiration,
+		}}
+	}
+	validSig := sig(now.AddDate(0, 0, -7), now.AddDate(0, 0, 7))
+	expiredSig := sig(now.AddDate(0, 0, -30), now.AddDate(0, 0, -1))
+
+	var testCases = []struct {
+		name     string
+		input    []DNSRecord
+		expected DNSSECStatus
+	}{
+		{
+			name:     "No DNSSEC records",
+			input:    []DNSRecord{{Type: ARecord, Name: "example.com", Value: "93.184.216.34"}},
+			expected: DNSSECStatusUnsigned,
+		},
+		{
+			name:     "Keys without DS",
+			input:    []DNSRecord{ksk, zsk, validSig},
+			expected: DNSSECStatusSigned,
+		},
+		{
+			name:     "DS matching a key",
+			input:    []DNSRecord{ksk, zsk, ds, validSig},
+			expected: DNSSECStatusDelegated,
+		},
+		{
+			name:     "DS matching a key without tag",
+			input:    []DNSRecord{zsk, ds},
+			expected: DNSSECStatusDelegated,
+		},
+		{
+			name:     "DS matching no key",
+			input:    []DNSRecord{ksk, staleDS},
+			expected: DNSSECStatusBroken,
+		},
+		{
+			name:     "DS without keys",
+			input:    []DNSRecord{ds},
+			expected: DNSSECStatusBroken,
+		},
+		{
+			name:     "Expired signatures",
+			input:    []DNSRecord{ksk, ds, expiredSig},
+			expected: DNSSECStatusBroken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := GetDNSSECStatus(tc.input, now)
+			if res != tc.expected {
+				t.Errorf("Incorrect result, expected `%v`, got `%v`", tc.expected, res)
+			}
+		})
+	}
+
+	lookup := DNSLookupResult{DNSRecords: []DNSRecord{ksk, staleDS}}
+	lookup.UpdateDNSSEC(now)
+	if lookup.DNSSECStatus != DNSSECStatusBroken || !lookup.DNSSECEnabled {
+		t.Errorf("UpdateDNSSEC() set status `%v`, enabled `%v`", lookup.DNSSECStatus, lookup.DNSSECEnabled)
+	}
+}
@@ -1,11 +1,16 @@
 package tools
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 const GoogleResolver = "8.8.8.8:53" // Google DNS Server
@@ -21,16 +26,28 @@ const (
 	MXRecord     DNSRecordType = "MX"
 	SOARecord    DNSRecordType = "SOA"
 	DNSKeyRecord DNSRecordType = "DNSKey"
+	CAARecord    DNSRecordType = "CAA"
+	SRVRecord    DNSRecordType = "SRV"
+	PTRRecord    DNSRecordType = "PTR"
+	DSRecord     DNSRecordType = "DS"
+	RRSIGRecord  DNSRecordType = "RRSIG"
+	NSECRecord   DNSRecordType = "NSEC"
+	NSEC3Record  DNSRecordType = "NSEC3"
+	SVCBRecord   DNSRecordType = "SVCB"
+	HTTPSRecord  DNSRecordType = "HTTPS"
 )
 
 // DNSLookupResult represents the result of a DNS Lookup.
 type DNSLookupResult struct {
-	Domain         string        `json:"domain"`          // The domain name being queried
-	DNSRecords     []DNSRecord   `json:"dns_records"`     // A list of DNS records
-	DNSSECEnabled  bool          `json:"dnssec_enabled"`  // Indicated if DNSSEC is enabled
-	LookupDuration time.Duration `json:"lookup_duration"` // Time taken to perform the lookup
-	CreatedAt      time.Time     `json:"created_at"`      // Timestamp when the lookup was performed
-	Error          string        `json:"error,omitempty"` // String containing encountered errors
+	Domain         string        `json:"domain"`                  // The domain name being queried
+	DNSRecords     []DNSRecord   `json:"dns_records"`             // A list of DNS records
+	DNSSECEnabled  bool          `json:"dnssec_enabled"`          // Indicated if DNSSEC is enabled
+	DNSSECStatus   DNSSECStatus  `json:"dnssec_status,omitempty"` // Detailed DNSSEC status, see GetDNSSECStatus
+	LookupDuration time.Duration `json:"lookup_duration"`         // Time taken to perform the lookup
+	CreatedAt      time.Time     `json:"created_at"`              // Timestamp when the lookup was performed
+	Error          string        `json:"error,omitempty"`         // String containing encountered errors
+
+	ZoneTransfer *ZoneTransferResult `json:"zone_transfer,omitempty"` // Outcome of AXFR attempts, if checked
 }
 
 // DNSRecord represents a DNS (Domain Name Service) record.
@@ -42,6 +59,159 @@ type DNSRecord struct {
 	Priority *int          `json:"priority,omitempty"` // Optional priority for MX records
 }
 
+// dnsRecordValueFactories return a pointer to an empty value of the concrete
+// type held by DNSRecord.Value for each record type.
+var dnsRecordValueFactories = map[DNSRecordType]func() any{
+	ARecord:      func() any { return new(string) },
+	AAAARecord:   func() any { return new(string) },
+	CNAMERecord:  func() any { return new(string) },
+	TXTRecord:    func() any { return new(string) },
+	NSRecord:     func() any { return new(string) },
+	MXRecord:     func() any { return new(MailExchange) },
+	SOARecord:    func() any { return new(StartOfAuthority) },
+	DNSKeyRecord: func() any { return new(DNSKey) },
+	CAARecord:    func() any { return new(CertificationAuthorityAuthorization) },
+	SRVRecord:    func() any { return new(ServiceLocation) },
+	PTRRecord:    func() any { return new(string) },
+	DSRecord:     func() any { return new(DelegationSigner) },
+	RRSIGRecord:  func() any { return new(RecordSignature) },
+	NSECRecord:   func() any { return new(NextSecure) },
+	NSEC3Record:  func() any { return new(NextSecure3) },
+	SVCBRecord:   func() any { return new(ServiceBinding) },
+	HTTPSRecord:  func() any { return new(ServiceBinding) },
+}
+
+// UnmarshalJSON decodes Value into the concrete type matching Type, e.g.,
+// MailExchange for MX records, instead of a map.
+func (r *DNSRecord) UnmarshalJSON(data []byte) error {
+	type Alias DNSRecord
+	aux := &struct {
+		Value json.RawMessage `json:"value"`
+		*Alias
+	}{
+		Alias: (*Alias)(r),
+	}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return fmt.Errorf("failed to unmarshal DNSRecord: %w", err)
+	}
+
+	r.Value = nil
+	if len(aux.Value) == 0 || string(aux.Value) == "null" {
+		return nil
+	}
+	return r.decodeValue(func(v any) error { return json.Unmarshal(aux.Value, v) })
+}
+
+// DecodeMsgpack implements msgpack.CustomDecoder, mirroring UnmarshalJSON.
+func (r *DNSRecord) DecodeMsgpack(dec *msgpack.Decoder) error {
+	// msgpack does not inline embedded pointers, so the fields are listed explicitly
+	var aux struct {
+		Type     DNSRecordType      `json:"type"`
+		Name     string             `json:"name"`
+		TTL      int                `json:"ttl"`
+		Value    msgpack.RawMessage `json:"value"`
+		Priority *int               `json:"priority,omitempty"`
+	}
+	if err := dec.Decode(&aux); err != nil {
+		return fmt.Errorf("failed to unmarshal DNSRecord: %w", err)
+	}
+	r.Type, r.Name, r.TTL, r.Priority, r.Value = aux.Type, aux.Name, aux.TTL, aux.Priority, nil
+
+	if len(aux.Value) == 0 || aux.Value[0] == msgpackNil {
+		return nil
+	}
+	return r.decodeValue(func(v any) error {
+		vd := msgpack.NewDecoder(bytes.NewReader(aux.Value))
+		vd.SetCustomStructTag("json")
+		return vd.Decode(v)
+	})
+}
+
+// decodeValue decodes Value with decode into the concrete type matching
+// Type. Values of unknown record types are decoded generically.
+func (r *DNSRecord) decodeValue(decode func(v any) error) error {
+	newValue, ok := dnsRecordValueFactories[r.Type]
+	if !ok {
+		return decode(&r.Value)
+	}
+
+	v := newValue()
+	if err := decode(v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s record value: %w", r.Type, err)
+	}
+	r.Value = reflect.ValueOf(v).Elem().Interface()
+	return nil
+}
+
+// AsString returns the value of A, AAAA, CNAME, TXT, NS and PTR records.
+func (r DNSRecord) AsString() (string, bool) {
+	return recordValueAs[string](r.Value)
+}
+
+// AsMX returns the value of MX records.
+func (r DNSRecord) AsMX() (MailExchange, bool) {
+	return recordValueAs[MailExchange](r.Value)
+}
+
+// AsSOA returns the value of SOA records.
+func (r DNSRecord) AsSOA() (StartOfAuthority, bool) {
+	return recordValueAs[StartOfAuthority](r.Value)
+}
+
+// AsDNSKey returns the value of DNSKEY records.
+func (r DNSRecord) AsDNSKey() (DNSKey, bool) {
+	return recordValueAs[DNSKey](r.Value)
+}
+
+// AsCAA returns the value of CAA records.
+func (r DNSRecord) AsCAA() (CertificationAuthorityAuthorization, bool) {
+	return recordValueAs[CertificationAuthorityAuthorization](r.Value)
+}
+
+// AsSRV returns the value of SRV records.
+func (r DNSRecord) AsSRV() (ServiceLocation, bool) {
+	return recordValueAs[ServiceLocation](r.Value)
+}
+
+// AsDS returns the value of DS records.
+func (r DNSRecord) AsDS() (DelegationSigner, bool) {
+	return recordValueAs[DelegationSigner](r.Value)
+}
+
+// AsRRSIG returns the value of RRSIG records.
+func (r DNSRecord) AsRRSIG() (RecordSignature, bool) {
+	return recordValueAs[RecordSignature](r.Value)
+}
+
+// AsNSEC returns the value of NSEC records.
+func (r DNSRecord) AsNSEC() (NextSecure, bool) {
+	return recordValueAs[NextSecure](r.Value)
+}
+
+// AsNSEC3 returns the value of NSEC3 records.
+func (r DNSRecord) AsNSEC3() (NextSecure3, bool) {
+	return recordValueAs[NextSecure3](r.Value)
+}
+
+// AsServiceBinding returns the value of SVCB and HTTPS records.
+func (r DNSRecord) AsServiceBinding() (ServiceBinding, bool) {
+	return recordValueAs[ServiceBinding](r.Value)
+}
+
+// recordValueAs returns value as a T, whether it holds a T or a *T.
+func recordValueAs[T any](value any) (T, bool) {
+	switch v := value.(type) {
+	case T:
+		return v, true
+	case *T:
+		if v != nil {
+			return *v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
 // MailExchange represents an MX (Mail Exchange) record.
 type MailExchange struct {
 	Host     string `json:"host"`     // The mail server host
@@ -61,9 +231,53 @@ type StartOfAuthority struct {
 
 // DNSKey represents a DNSKEY record.
 type DNSKey struct {
-	Flags     int `json:"flags"`     // Flags of the key
-	Protocol  int `json:"protocol"`  // Protocol of the key
-	Algorithm int `json:"algorithm"` // Algorithm of the key
+	Flags     int    `json:"flags"`                // Flags of the key
+	Protocol  int    `json:"protocol"`             // Protocol of the key
+	Algorithm int    `json:"algorithm"`            // Algorithm of the key
+	KeyTag    int    `json:"key_tag,omitempty"`    // Tag referencing the key from DS and RRSIG records
+	PublicKey string `json:"public_key,omitempty"` // Base64 encoded public key
+}
+
+// CertificationAuthorityAuthorization represents a CAA record.
+type CertificationAuthorityAuthorization struct {
+	Flag  int    `json:"flag"`  // Flags, 128 marks the property as critical
+	Tag   string `json:"tag"`   // Property tag, e.g., issue, issuewild or iodef
+	Value string `json:"value"` // Property value, e.g., letsencrypt.org
+}
+
+// IsCritical reports whether CAs must understand the property to issue certificates.
+func (c CertificationAuthorityAuthorization) IsCritical() bool {
+	return c.Flag&128 != 0
+}
+
+// ServiceLocation represents an SRV record.
+type ServiceLocation struct {
+	Priority int    `json:"priority"` // Priority of the target, lower first
+	Weight   int    `json:"weight"`   // Weight among targets of the same priority
+	Port     int    `json:"port"`     // Port of the service on the target
+	Target   string `json:"target"`   // Host providing the service
+}
+
+// ServiceBinding represents an SVCB or HTTPS record.
+type ServiceBinding struct {
+	Priority int               `json:"priority"`         // Priority of the record, 0 for alias mode
+	Target   string            `json:"target"`           // Target name, "." for the owner name
+	Params   map[string]string `json:"params,omitempty"` // Service parameters, e.g., alpn or ipv4hint
+}
+
+// IsAlias reports whether the record aliases another name instead of
+// describing the service endpoint.
+func (b ServiceBinding) IsAlias() bool {
+	return b.Priority == 0
+}
+
+// ALPN returns the protocols of the alpn parameter, e.g., ["h2", "h3"].
+func (b ServiceBinding) ALPN() []string {
+	alpn, ok := b.Params["alpn"]
+	if !ok || alpn == "" {
+		return nil
+	}
+	return strings.Split(alpn, ",")
 }
 
 // LogValue creates a standard structured log representation for logging.
@@ -82,6 +296,7 @@ func (r *DNSLookupResult) LogValue() slog.Value {
 	return slog.GroupValue(
 		slog.String("domain", r.Domain),
 		slog.Bool("dnssec_enabled", r.DNSSECEnabled),
+		slog.String("dnssec_status", string(r.DNSSECStatus)),
 		slog.String("lookup_duration", r.LookupDuration.String()),
 		slog.Time("created_at", r.CreatedAt),
 		slog.String("error", r.Error),
@@ -0,0 +1,137 @@
+package tools
+
+import "time"
+
+// DNSSECStatus is the DNSSEC state of a domain, as far as its DNS records tell.
+type DNSSECStatus string
+
+const (
+	// DNSSECStatusUnsigned means the zone publishes no keys.
+	DNSSECStatusUnsigned DNSSECStatus = "unsigned"
+	// DNSSECStatusSigned means the zone is signed but its parent holds no DS
+	// record, so resolvers cannot validate it.
+	DNSSECStatusSigned DNSSECStatus = "signed"
+	// DNSSECStatusDelegated means the zone is signed and its parent holds a
+	// DS record matching one of its keys.
+	DNSSECStatusDelegated DNSSECStatus = "delegated"
+	// DNSSECStatusBroken means the chain of trust is broken, e.g., the DS
+	// records match no key or the signatures expired, so validating resolvers
+	// fail to resolve the domain.
+	DNSSECStatusBroken DNSSECStatus = "broken"
+)
+
+func (s DNSSECStatus) String() string {
+	return string(s)
+}
+
+// IsEnabled reports whether the zone is signed, whether or not it validates.
+func (s DNSSECStatus) IsEnabled() bool {
+	return s == DNSSECStatusSigned || s == DNSSECStatusDelegated || s == DNSSECStatusBroken
+}
+
+// DelegationSigner represents a DS record.
+type DelegationSigner struct {
+	KeyTag     int    `json:"key_tag"`     // Tag of the referenced DNSKEY
+	Algorithm  int    `json:"algorithm"`   // Algorithm of the referenced DNSKEY
+	DigestType int    `json:"digest_type"` // Algorithm of the digest, e.g., 2 for SHA-256
+	Digest     string `json:"digest"`      // Hex encoded digest of the DNSKEY
+}
+
+// RecordSignature represents an RRSIG record.
+type RecordSignature struct {
+	TypeCovered DNSRecordType `json:"type_covered"`        // Type of the signed records
+	Algorithm   int           `json:"algorithm"`           // Algorithm of the signature
+	Labels      int           `json:"labels"`              // Number of labels of the signed name
+	OriginalTTL int           `json:"original_ttl"`        // TTL of the signed records
+	Expiration  time.Time     `json:"expiration"`          // End of the validity period
+	Inception   time.Time     `json:"inception"`           // Start of the validity period
+	KeyTag      int           `json:"key_tag"`             // Tag of the signing DNSKEY
+	SignerName  string        `json:"signer_name"`         // Zone of the signing DNSKEY
+	Signature   string        `json:"signature,omitempty"` // Base64 encoded signature
+}
+
+// IsValidAt reports whether the signature validity period includes the given time.
+func (s RecordSignature) IsValidAt(at time.Time) bool {
+	return !at.Before(s.Inception) && !at.After(s.Expiration)
+}
+
+// NextSecure represents an NSEC record.
+type NextSecure struct {
+	NextDomain string          `json:"next_domain"` // Next name of the zone
+	Types      []DNSRecordType `json:"types"`       // Types of the records of the owner name
+}
+
+// NextSecure3 represents an NSEC3 record.
+type NextSecure3 struct {
+	HashAlgorithm int             `json:"hash_algorithm"`    // Algorithm hashing the names, 1 for SHA-1
+	Flags         int             `json:"flags"`             // Flags, 1 for opt-out
+	Iterations    int             `json:"iterations"`        // Additional hash iterations
+	Salt          string          `json:"salt,omitempty"`    // Hex encoded salt
+	NextHashed    string          `json:"next_hashed_owner"` // Next hashed name of the zone
+	Types         []DNSRecordType `json:"types"`             // Types of the records of the owner name
+}
+
+// GetDNSSECStatus returns the DNSSEC status of a zone from its DNSKEY, DS and
+// RRSIG records. A DS record is expected to match a key by tag and algorithm,
+// or by algorithm only when the key tags are unknown. Signatures are checked
+// against the given time.
+func GetDNSSECStatus(records []DNSRecord, at time.Time) DNSSECStatus {
+	var keys []DNSKey
+	var ds []DelegationSigner
+	var sigs []RecordSignature
+	for _, record := range records {
+		switch record.Type {
+		case DNSKeyRecord:
+			if v, ok := record.AsDNSKey(); ok {
+				keys = append(keys, v)
+			}
+		case DSRecord:
+			if v, ok := record.AsDS(); ok {
+				ds = append(ds, v)
+			}
+		case RRSIGRecord:
+			if v, ok := record.AsRRSIG(); ok {
+				sigs = append(sigs, v)
+			}
+		}
+	}
+
+	switch {
+	case len(keys) == 0 && len(ds) == 0:
+		return DNSSECStatusUnsigned
+	case len(keys) == 0:
+		// The parent points to keys the zone does not publish
+		return DNSSECStatusBroken
+	}
+
+	if len(sigs) > 0 && !hasValidSignature(sigs, at) {
+		return DNSSECStatusBroken
+	}
+	if len(ds) == 0 {
+		return DNSSECStatusSigned
+	}
+	for _, d := range ds {
+		for _, k := range keys {
+			if d.Algorithm == k.Algorithm && (k.KeyTag == 0 || d.KeyTag == k.KeyTag) {
+				return DNSSECStatusDelegated
+			}
+		}
+	}
+	return DNSSECStatusBroken
+}
+
+func hasValidSignature(sigs []RecordSignature, at time.Time) bool {
+	for _, sig := range sigs {
+		if sig.IsValidAt(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateDNSSEC sets DNSSECStatus from the looked up records and, for
+// consumers of the bare flag, DNSSECEnabled.
+func (r *DNSLookupResult) UpdateDNSSEC(at time.Time) {
+	r.DNSSECStatus = GetDNSSECStatus(r.DNSRecords, at)
+	r.DNSSECEnabled = r.DNSSECStatus.IsEnabled()
+}
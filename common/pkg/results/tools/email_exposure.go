@@ -0,0 +1,273 @@
+package tools
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+)
+
+// IDs of the email exposure findings.
+const (
+	FindingEmailsExposed        = "email-personal-exposed"
+	FindingEmailPatternInferred = "email-pattern-inferred"
+)
+
+// EmailKind tells whether an address belongs to a person or a role.
+type EmailKind string
+
+const (
+	EmailKindRole     EmailKind = "role"
+	EmailKindPersonal EmailKind = "personal"
+)
+
+func (k EmailKind) String() string {
+	return string(k)
+}
+
+// EmailPattern is the way an organization builds the local part of the
+// addresses of its members from their names.
+type EmailPattern string
+
+const (
+	EmailPatternUnknown         EmailPattern = ""
+	EmailPatternFirstDotLast    EmailPattern = "first.last"
+	EmailPatternInitialDotLast  EmailPattern = "f.last"
+	EmailPatternFirstDotInitial EmailPattern = "first.l"
+	EmailPatternFirstUnderscore EmailPattern = "first_last"
+	EmailPatternFirstHyphenLast EmailPattern = "first-last"
+)
+
+func (p EmailPattern) String() string {
+	return string(p)
+}
+
+// minPatternSamples is how many addresses must follow a pattern for it to
+// be inferred.
+const minPatternSamples = 2
+
+// roleLocalParts are the local parts of role-based addresses, matched
+// against the whole local part or its first word, e.g., "support" in
+// "support-eu".
+var roleLocalParts = map[string]bool{
+	"abuse": true, "accounting": true, "accounts": true, "admin": true, "administrator": true,
+	"billing": true, "booking": true, "careers": true, "contact": true, "customerservice": true,
+	"dev": true, "enquiries": true, "feedback": true, "hello": true, "help": true, "helpdesk": true,
+	"hostmaster": true, "hr": true, "info": true, "inquiries": true, "it": true, "jobs": true,
+	"legal": true, "mail": true, "marketing": true, "media": true, "newsletter": true,
+	"noreply": true, "office": true, "ops": true, "orders": true, "postmaster": true, "press": true,
+	"privacy": true, "reservations": true, "root": true, "sales": true, "security": true,
+	"service": true, "support": true, "team": true, "webmaster": true,
+}
+
+// EmailExposure summarizes the email addresses of an organization found by
+// OSINT sources.
+type EmailExposure struct {
+	Domain    string         `json:"domain"`
+	Addresses []ExposedEmail `json:"addresses"`
+	// Counts of the addresses, Personal and Role only counting the addresses
+	// of the domain.
+	Total     int `json:"total"`
+	Personal  int `json:"personal"`
+	Role      int `json:"role"`
+	OffDomain int `json:"off_domain"`
+	// Invalid counts the discarded values which are not email addresses.
+	Invalid int `json:"invalid,omitempty"`
+	// Pattern is the address pattern of the personal addresses of the domain,
+	// and PatternConfidence the share of them following it.
+	Pattern           EmailPattern `json:"pattern,omitempty"`
+	PatternConfidence float64      `json:"pattern_confidence,omitempty"`
+	Findings          []Finding    `json:"findings"`
+}
+
+// ExposedEmail is a normalized email address and its classification.
+type ExposedEmail struct {
+	Address string    `json:"address" sensitive:"true"`
+	Kind    EmailKind `json:"kind"`
+	// OffDomain tells whether the address is outside the domain and its
+	// subdomains, e.g., a personal webmail address.
+	OffDomain bool         `json:"off_domain"`
+	Pattern   EmailPattern `json:"pattern,omitempty"`
+	Sources   []string     `json:"sources,omitempty"`
+}
+
+// EmailExposure analyzes the harvested emails of the domain, with their
+// sources when known.
+func (r *HarvesterResult) EmailExposure(domain string) *EmailExposure {
+	sources := make(map[string][]string, len(r.EmailDetails))
+	emails := make([]string, 0, len(r.Emails))
+	for _, e := range r.EmailDetails {
+		sources[e.Address] = e.Sources
+		emails = append(emails, e.Address)
+	}
+	// Older payloads carry no attribution
+	emails = append(emails, r.Emails...)
+
+	exposure := AnalyzeEmails(domain, emails)
+	for i := range exposure.Addresses {
+		exposure.Addresses[i].Sources = sources[exposure.Addresses[i].Address]
+	}
+	return exposure
+}
+
+// AnalyzeEmails normalizes and deduplicates the email addresses, classifies
+// them, and infers the address pattern of the domain.
+func AnalyzeEmails(domain string, emails []string) *EmailExposure {
+	domain = normalizeDNSName(domain)
+	exposure := &EmailExposure{Domain: domain, Addresses: []ExposedEmail{}, Findings: []Finding{}}
+
+	seen := make(map[string]bool, len(emails))
+	patterns := make(map[EmailPattern]int)
+	for _, raw := range emails {
+		address, ok := NormalizeEmail(raw)
+		if !ok {
+			exposure.Invalid++
+			continue
+		}
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+
+		local, host, _ := strings.Cut(address, "@")
+		email := ExposedEmail{
+			Address:   address,
+			Kind:      classifyLocalPart(local),
+			OffDomain: host != domain && !strings.HasSuffix(host, "."+domain),
+		}
+		if email.Kind == EmailKindPersonal {
+			email.Pattern = localPartPattern(local)
+		}
+		exposure.Addresses = append(exposure.Addresses, email)
+
+		switch {
+		case email.OffDomain:
+			exposure.OffDomain++
+		case email.Kind == EmailKindRole:
+			exposure.Role++
+		default:
+			exposure.Personal++
+			patterns[email.Pattern]++
+		}
+	}
+	exposure.Total = len(exposure.Addresses)
+
+	best := 0
+	for pattern, count := range patterns {
+		if pattern == EmailPatternUnknown || count < minPatternSamples {
+			continue
+		}
+		// Ties are broken by name for a stable result
+		if count > best || (count == best && pattern < exposure.Pattern) {
+			exposure.Pattern, best = pattern, count
+		}
+	}
+	if best > 0 {
+		exposure.PatternConfidence = float64(best) / float64(exposure.Personal)
+	}
+
+	exposure.Findings = exposure.findings()
+	return exposure
+}
+
+// NormalizeEmail lowercases the email address and strips the display name,
+// "mailto:" scheme and trailing dot of the domain it may come with. It
+// reports whether the value is an email address.
+func NormalizeEmail(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= len("mailto:") && strings.EqualFold(raw[:len("mailto:")], "mailto:") {
+		raw = raw[len("mailto:"):]
+	}
+	// net/mail rejects fully qualified domains
+	raw = strings.Replace(strings.TrimSuffix(raw, "."), ".>", ">", 1)
+	addr, err := mail.ParseAddress(raw)
+	if err != nil {
+		return "", false
+	}
+
+	local, host, _ := strings.Cut(strings.ToLower(addr.Address), "@")
+	if local == "" || !strings.Contains(host, ".") {
+		return "", false
+	}
+	return local + "@" + host, true
+}
+
+// classifyLocalPart returns whether the local part of an address, without
+// its "+tag", belongs to a role or a person.
+func classifyLocalPart(local string) EmailKind {
+	local, _, _ = strings.Cut(local, "+")
+	words := strings.FieldsFunc(local, isLocalPartSeparator)
+	// e.g., "no-reply"
+	if roleLocalParts[strings.Join(words, "")] || (len(words) > 0 && roleLocalParts[words[0]]) {
+		return EmailKindRole
+	}
+	return EmailKindPersonal
+}
+
+// localPartPattern returns the pattern of a personal local part, ignoring
+// its "+tag" and trailing digits, e.g., "f.last" for "j.doe2".
+func localPartPattern(local string) EmailPattern {
+	local, _, _ = strings.Cut(local, "+")
+	local = strings.TrimRight(local, "0123456789")
+
+	words := strings.FieldsFunc(local, isLocalPartSeparator)
+	if len(words) != 2 || strings.IndexFunc(local, isLocalPartSeparator) != len(words[0]) ||
+		strings.ContainsFunc(local, func(r rune) bool { return r >= '0' && r <= '9' }) {
+		return EmailPatternUnknown
+	}
+	first, last := words[0], words[1]
+
+	switch local[len(first)] {
+	case '.':
+		switch {
+		case len(first) == 1 && len(last) > 1:
+			return EmailPatternInitialDotLast
+		case len(first) > 1 && len(last) == 1:
+			return EmailPatternFirstDotInitial
+		case len(first) > 1:
+			return EmailPatternFirstDotLast
+		}
+	case '_':
+		if len(first) > 1 && len(last) > 1 {
+			return EmailPatternFirstUnderscore
+		}
+	case '-':
+		if len(first) > 1 && len(last) > 1 {
+			return EmailPatternFirstHyphenLast
+		}
+	}
+	return EmailPatternUnknown
+}
+
+func isLocalPartSeparator(r rune) bool {
+	return r == '.' || r == '_' || r == '-'
+}
+
+func (e *EmailExposure) findings() []Finding {
+	findings := []Finding{}
+	if e.Personal > 0 {
+		findings = append(findings, Finding{
+			ID:    FindingEmailsExposed,
+			Title: "Personal email addresses exposed",
+			Description: fmt.Sprintf("%d personal email addresses of %s are publicly available. They can be targeted by phishing and password spraying.",
+				e.Personal, e.Domain),
+			Severity:    enums.SeverityTypeLow,
+			Category:    enums.GetOwaspCategoryForCWE("CWE-200"),
+			Remediation: "Publish role addresses rather than personal ones, and train the staff to recognize phishing.",
+		})
+	}
+	if e.Pattern != EmailPatternUnknown {
+		findings = append(findings, Finding{
+			ID:    FindingEmailPatternInferred,
+			Title: "Email address pattern inferable",
+			Description: fmt.Sprintf("The personal addresses of %s follow the %s pattern, so the address of any member can be guessed from their name.",
+				e.Domain, e.Pattern),
+			Severity:    enums.SeverityTypeLow,
+			Category:    enums.GetOwaspCategoryForCWE("CWE-200"),
+			Remediation: "Protect accounts with multi-factor authentication and monitor password spraying against guessed addresses.",
+			Evidence:    fmt.Sprintf("%s@%s (%.0f%% of %d personal addresses)", e.Pattern, e.Domain, 100*e.PatternConfidence, e.Personal),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NormalizeEmail(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"John.Doe@Example.COM", "john.doe@example.com", true},
+		{"  mailto:info@example.com ", "info@example.com", true},
+		{"John Doe <jdoe@example.com.>", "jdoe@example.com", true},
+		{"user+tag@mail.example.com", "user+tag@mail.example.com", true},
+		{"not an email", "", false},
+		{"root@localhost", "", false},
+		{"@example.com", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeEmail(tt.raw)
+		assert.Equal(t, tt.ok, ok, tt.raw)
+		assert.Equal(t, tt.want, got, tt.raw)
+	}
+}
+
+func Test_ClassifyLocalPart(t *testing.T) {
+	tests := map[string]EmailKind{
+		"admin":        EmailKindRole,
+		"info":         EmailKindRole,
+		"support-eu":   EmailKindRole,
+		"no-reply":     EmailKindRole,
+		"sales+leads":  EmailKindRole,
+		"john.doe":     EmailKindPersonal,
+		"itziar":       EmailKindPersonal,
+		"j.doe":        EmailKindPersonal,
+		"infosec.team": EmailKindPersonal,
+	}
+	for local, want := range tests {
+		assert.Equal(t, want, classifyLocalPart(local), local)
+	}
+}
+
+func Test_LocalPartPattern(t *testing.T) {
+	tests := map[string]EmailPattern{
+		"john.doe":     EmailPatternFirstDotLast,
+		"j.doe":        EmailPatternInitialDotLast,
+		"j.doe2":       EmailPatternInitialDotLast,
+		"john.d":       EmailPatternFirstDotInitial,
+		"john_doe":     EmailPatternFirstUnderscore,
+		"john-doe+web": EmailPatternFirstHyphenLast,
+		"jdoe":         EmailPatternUnknown,
+		"j.d":          EmailPatternUnknown,
+		"john.m.doe":   EmailPatternUnknown,
+		".john.doe":    EmailPatternUnknown,
+		"john2.doe":    EmailPatternUnknown,
+	}
+	for local, want := range tests {
+		assert.Equal(t, want, localPartPattern(local), local)
+	}
+}
+
+func Test_AnalyzeEmails(t *testing.T) {
+	exposure := AnalyzeEmails("Example.com.", []string{
+		"John.Doe@example.com",
+		"john.doe@example.com",
+		"jane.roe@example.com",
+		"a.smith@eu.example.com",
+		"bob@example.com",
+		"info@example.com",
+		"webmaster@example.com",
+		"john.doe@gmail.com",
+		"not an email",
+	})
+
+	assert.Equal(t, "example.com", exposure.Domain)
+	assert.Equal(t, 7, exposure.Total)
+	assert.Equal(t, 4, exposure.Personal)
+	assert.Equal(t, 2, exposure.Role)
+	assert.Equal(t, 1, exposure.OffDomain)
+	assert.Equal(t, 1, exposure.Invalid)
+	assert.Equal(t, EmailPatternFirstDotLast, exposure.Pattern)
+	assert.InDelta(t, 0.5, exposure.PatternConfidence, 1e-9)
+
+	assert.Equal(t, ExposedEmail{Address: "john.doe@example.com", Kind: EmailKindPersonal, Pattern: EmailPatternFirstDotLast}, exposure.Addresses[0])
+	assert.Equal(t, ExposedEmail{Address: "info@example.com", Kind: EmailKindRole}, exposure.Addresses[4])
+	assert.Equal(t, ExposedEmail{Address: "john.doe@gmail.com", Kind: EmailKindPersonal, OffDomain: true, Pattern: EmailPatternFirstDotLast}, exposure.Addresses[6])
+
+	require.Len(t, exposure.Findings, 2)
+	assert.Equal(t, FindingEmailsExposed, exposure.Findings[0].ID)
+	assert.Equal(t, FindingEmailPatternInferred, exposure.Findings[1].ID)
+	assert.Equal(t, "first.last@example.com (50% of 4 personal addresses)", exposure.Findings[1].Evidence)
+}
+
+func Test_AnalyzeEmailsPatternInference(t *testing.T) {
+	tests := []struct {
+		name   string
+		emails []string
+		want   EmailPattern
+	}{
+		{
+			name:   "Single sample",
+			emails: []string{"j.doe@example.com", "bob@example.com"},
+			want:   EmailPatternUnknown,
+		},
+		{
+			name:   "Majority",
+			emails: []string{"j.doe@example.com", "j.roe@example.com", "a.smith@example.com", "john.doe@example.com", "jane.roe@example.com"},
+			want:   EmailPatternInitialDotLast,
+		},
+		{
+			name:   "Tie",
+			emails: []string{"john.doe@example.com", "jane.roe@example.com", "j.doe@example.com", "j.roe@example.com"},
+			want:   EmailPatternInitialDotLast,
+		},
+		{
+			name:   "Off-domain addresses are ignored",
+			emails: []string{"john.doe@gmail.com", "jane.roe@gmail.com", "bob@example.com"},
+			want:   EmailPatternUnknown,
+		},
+		{
+			name:   "Role addresses are ignored",
+			emails: []string{"support.eu@example.com", "support.us@example.com"},
+			want:   EmailPatternUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exposure := AnalyzeEmails("example.com", tt.emails)
+			assert.Equal(t, tt.want, exposure.Pattern)
+			if tt.want == EmailPatternUnknown {
+				assert.Zero(t, exposure.PatternConfidence)
+			}
+		})
+	}
+}
+
+func Test_AnalyzeEmailsEmpty(t *testing.T) {
+	exposure := AnalyzeEmails("example.com", nil)
+	assert.Equal(t, &EmailExposure{Domain: "example.com", Addresses: []ExposedEmail{}, Findings: []Finding{}}, exposure)
+}
+
+func Test_HarvesterResultEmailExposure(t *testing.T) {
+	seenAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	res := &HarvesterResult{}
+	res.AddEmail("john.doe@example.com", seenAt, "bing")
+	res.AddEmail("john.doe@example.com", seenAt, "crtsh")
+	// Older payloads only list emails
+	res.Emails = append(res.Emails, "Info@Example.com")
+
+	exposure := res.EmailExposure("example.com")
+	require.Len(t, exposure.Addresses, 2)
+	assert.Equal(t, []string{"bing", "crtsh"}, exposure.Addresses[0].Sources)
+	assert.Equal(t, "info@example.com", exposure.Addresses[1].Address)
+	assert.Nil(t, exposure.Addresses[1].Sources)
+}
@@ -0,0 +1,613 @@
+package tools
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+)
+
+// SPFMaxLookups is the maximum number of DNS lookups an SPF evaluation may
+// perform before failing with a permanent error (RFC 7208, section 4.6.4).
+const SPFMaxLookups = 10
+
+// CommonDKIMSelectors are selectors worth querying, since DKIM selectors
+// cannot be enumerated through DNS.
+var CommonDKIMSelectors = []string{
+	"default", "dkim", "google", "k1", "k2", "mail", "s1", "s2", "selector1", "selector2",
+}
+
+// IDs of the email security findings.
+const (
+	FindingSPFMissing         = "spf-missing"
+	FindingSPFMultiple        = "spf-multiple-records"
+	FindingSPFInvalid         = "spf-invalid"
+	FindingSPFPassAll         = "spf-pass-all"
+	FindingSPFNeutralAll      = "spf-neutral-all"
+	FindingSPFNoAll           = "spf-no-all"
+	FindingSPFTooManyLookups  = "spf-too-many-lookups"
+	FindingSPFPTR             = "spf-ptr-mechanism"
+	FindingDMARCMissing       = "dmarc-missing"
+	FindingDMARCMultiple      = "dmarc-multiple-records"
+	FindingDMARCInvalid       = "dmarc-invalid"
+	FindingDMARCPolicyNone    = "dmarc-policy-none"
+	FindingDMARCPartial       = "dmarc-partial-enforcement"
+	FindingDMARCSubdomainNone = "dmarc-subdomain-policy-none"
+	FindingDMARCNoReports     = "dmarc-no-aggregate-reports"
+	FindingDKIMInvalid        = "dkim-invalid"
+	FindingDKIMWeakKey        = "dkim-weak-key"
+	FindingDKIMTesting        = "dkim-testing-mode"
+)
+
+var (
+	// ErrNotSPFRecord is returned when parsing a TXT record which is not an SPF record.
+	ErrNotSPFRecord = errors.New("not an SPF record")
+	// ErrNotDMARCRecord is returned when parsing a TXT record which is not a DMARC record.
+	ErrNotDMARCRecord = errors.New("not a DMARC record")
+)
+
+// SPFQualifier is the result of an SPF mechanism when it matches.
+type SPFQualifier string
+
+const (
+	SPFQualifierPass     SPFQualifier = "+"
+	SPFQualifierFail     SPFQualifier = "-"
+	SPFQualifierSoftFail SPFQualifier = "~"
+	SPFQualifierNeutral  SPFQualifier = "?"
+)
+
+func (q SPFQualifier) String() string {
+	return string(q)
+}
+
+// spfLookupMechanisms are the mechanisms and modifiers which count towards SPFMaxLookups.
+var spfLookupMechanisms = []string{"include", "a", "mx", "ptr", "exists", "redirect"}
+
+var spfMechanisms = []string{"all", "include", "a", "mx", "ptr", "ip4", "ip6", "exists"}
+
+// SPFMechanism is a mechanism of an SPF record, e.g., "-ip4:192.0.2.0/24".
+type SPFMechanism struct {
+	Qualifier SPFQualifier `json:"qualifier"`
+	Name      string       `json:"name"`
+	Value     string       `json:"value,omitempty"`
+}
+
+func (m SPFMechanism) String() string {
+	s := string(m.Qualifier) + m.Name
+	if m.Value != "" {
+		s += ":" + m.Value
+	}
+	return s
+}
+
+// SPFRecord is a parsed SPF (Sender Policy Framework) record.
+type SPFRecord struct {
+	Raw         string         `json:"raw"`
+	Mechanisms  []SPFMechanism `json:"mechanisms"`
+	Redirect    string         `json:"redirect,omitempty"`
+	Explanation string         `json:"explanation,omitempty"`
+	// LookupCount is the number of terms of the record that require a DNS
+	// lookup. Lookups done by included records are not counted.
+	LookupCount int `json:"lookup_count"`
+}
+
+// All returns the "all" mechanism of the record.
+func (r *SPFRecord) All() (SPFMechanism, bool) {
+	for _, m := range r.Mechanisms {
+		if m.Name == "all" {
+			return m, true
+		}
+	}
+	return SPFMechanism{}, false
+}
+
+// IsSPFRecord reports whether the TXT record value is an SPF record.
+func IsSPFRecord(txt string) bool {
+	fields := strings.Fields(unquoteTXT(txt))
+	return len(fields) > 0 && strings.EqualFold(fields[0], "v=spf1")
+}
+
+// ParseSPF parses an SPF record, e.g., "v=spf1 mx include:_spf.google.com ~all".
+func ParseSPF(txt string) (*SPFRecord, error) {
+	raw := unquoteTXT(txt)
+	if !IsSPFRecord(raw) {
+		return nil, ErrNotSPFRecord
+	}
+
+	record := &SPFRecord{Raw: raw, Mechanisms: []SPFMechanism{}}
+	for _, term := range strings.Fields(raw)[1:] {
+		// Modifiers are name=value pairs
+		if name, value, ok := strings.Cut(term, "="); ok && !strings.ContainsAny(name, ":/") {
+			switch strings.ToLower(name) {
+			case "redirect":
+				record.Redirect = value
+				record.LookupCount++
+			case "exp":
+				record.Explanation = value
+			}
+			// Unknown modifiers must be ignored
+			continue
+		}
+
+		m := SPFMechanism{Qualifier: SPFQualifierPass}
+		switch q := SPFQualifier(term[:1]); q {
+		case SPFQualifierPass, SPFQualifierFail, SPFQualifierSoftFail, SPFQualifierNeutral:
+			m.Qualifier = q
+			term = term[1:]
+		}
+		name, value, _ := strings.Cut(term, ":")
+		// a and mx accept a CIDR length without a domain, e.g., "a/24"
+		if n, cidr, ok := strings.Cut(name, "/"); ok {
+			name, value = n, "/"+cidr
+		}
+		m.Name, m.Value = strings.ToLower(name), value
+
+		if !slices.Contains(spfMechanisms, m.Name) {
+			return nil, fmt.Errorf("invalid SPF term %q", term)
+		}
+		if slices.Contains(spfLookupMechanisms, m.Name) {
+			record.LookupCount++
+		}
+		record.Mechanisms = append(record.Mechanisms, m)
+	}
+	return record, nil
+}
+
+// DMARCPolicy is the action requested for messages failing DMARC.
+type DMARCPolicy string
+
+const (
+	DMARCPolicyNone       DMARCPolicy = "none"
+	DMARCPolicyQuarantine DMARCPolicy = "quarantine"
+	DMARCPolicyReject     DMARCPolicy = "reject"
+)
+
+func (p DMARCPolicy) String() string {
+	return string(p)
+}
+
+// DMARCAlignment is the identifier alignment mode of DKIM or SPF.
+type DMARCAlignment string
+
+const (
+	DMARCAlignmentRelaxed DMARCAlignment = "r"
+	DMARCAlignmentStrict  DMARCAlignment = "s"
+)
+
+func (a DMARCAlignment) String() string {
+	return string(a)
+}
+
+// DMARCRecord is a parsed DMARC record. Omitted tags are set to their
+// default values.
+type DMARCRecord struct {
+	Raw    string      `json:"raw"`
+	Policy DMARCPolicy `json:"policy"`
+	// SubdomainPolicy defaults to Policy.
+	SubdomainPolicy     DMARCPolicy    `json:"subdomain_policy"`
+	Percentage          int            `json:"percentage"`
+	AggregateReportURIs []string       `json:"aggregate_report_uris,omitempty"`
+	ForensicReportURIs  []string       `json:"forensic_report_uris,omitempty"`
+	DKIMAlignment       DMARCAlignment `json:"dkim_alignment"`
+	SPFAlignment        DMARCAlignment `json:"spf_alignment"`
+	FailureOptions      string         `json:"failure_options,omitempty"`
+}
+
+// IsDMARCRecord reports whether the TXT record value is a DMARC record.
+func IsDMARCRecord(txt string) bool {
+	version, _, _ := strings.Cut(unquoteTXT(txt), ";")
+	return strings.EqualFold(strings.ReplaceAll(version, " ", ""), "v=DMARC1")
+}
+
+// ParseDMARC parses a DMARC record, e.g., "v=DMARC1; p=reject; rua=mailto:dmarc@example.com".
+func ParseDMARC(txt string) (*DMARCRecord, error) {
+	raw := unquoteTXT(txt)
+	if !IsDMARCRecord(raw) {
+		return nil, ErrNotDMARCRecord
+	}
+
+	tags := parseTagList(raw)
+	record := &DMARCRecord{
+		Raw:            raw,
+		Percentage:     100,
+		DKIMAlignment:  DMARCAlignmentRelaxed,
+		SPFAlignment:   DMARCAlignmentRelaxed,
+		FailureOptions: tags["fo"],
+	}
+
+	var err error
+	if record.Policy, err = parseDMARCPolicy(tags["p"]); err != nil {
+		return nil, fmt.Errorf("invalid DMARC policy: %w", err)
+	}
+	record.SubdomainPolicy = record.Policy
+	if sp, ok := tags["sp"]; ok {
+		if record.SubdomainPolicy, err = parseDMARCPolicy(sp); err != nil {
+			return nil, fmt.Errorf("invalid DMARC subdomain policy: %w", err)
+		}
+	}
+	if pct, ok := tags["pct"]; ok {
+		record.Percentage, err = strconv.Atoi(pct)
+		if err != nil || record.Percentage < 0 || record.Percentage > 100 {
+			return nil, fmt.Errorf("invalid DMARC percentage %q", pct)
+		}
+	}
+	if record.DKIMAlignment, err = parseDMARCAlignment(tags, "adkim"); err != nil {
+		return nil, err
+	}
+	if record.SPFAlignment, err = parseDMARCAlignment(tags, "aspf"); err != nil {
+		return nil, err
+	}
+	record.AggregateReportURIs = splitURIs(tags["rua"])
+	record.ForensicReportURIs = splitURIs(tags["ruf"])
+	return record, nil
+}
+
+func parseDMARCPolicy(s string) (DMARCPolicy, error) {
+	p := DMARCPolicy(strings.ToLower(s))
+	switch p {
+	case DMARCPolicyNone, DMARCPolicyQuarantine, DMARCPolicyReject:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown policy %q", s)
+	}
+}
+
+func parseDMARCAlignment(tags map[string]string, tag string) (DMARCAlignment, error) {
+	v, ok := tags[tag]
+	if !ok {
+		return DMARCAlignmentRelaxed, nil
+	}
+	a := DMARCAlignment(strings.ToLower(v))
+	switch a {
+	case DMARCAlignmentRelaxed, DMARCAlignmentStrict:
+		return a, nil
+	default:
+		return "", fmt.Errorf("invalid DMARC %s alignment %q", tag, v)
+	}
+}
+
+func splitURIs(s string) []string {
+	var uris []string
+	for _, uri := range strings.Split(s, ",") {
+		if uri = strings.TrimSpace(uri); uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// DKIMRecord is a parsed DKIM public key record, published at
+// "<selector>._domainkey.<domain>".
+type DKIMRecord struct {
+	Selector string `json:"selector"`
+	Raw      string `json:"raw"`
+	// KeyType defaults to "rsa".
+	KeyType        string   `json:"key_type"`
+	KeyBits        int      `json:"key_bits,omitempty"`
+	PublicKey      string   `json:"public_key,omitempty"`
+	HashAlgorithms []string `json:"hash_algorithms,omitempty"`
+	Flags          []string `json:"flags,omitempty"`
+	// Revoked tells whether the key was revoked by publishing an empty key.
+	Revoked bool `json:"revoked"`
+}
+
+// IsTesting reports whether the domain is testing DKIM, in which case
+// verifiers treat signed and unsigned messages alike.
+func (r *DKIMRecord) IsTesting() bool {
+	return slices.Contains(r.Flags, "y")
+}
+
+// DKIMRecordName returns the name of the DKIM record of the selector.
+func DKIMRecordName(selector, domain string) string {
+	return selector + "._domainkey." + domain
+}
+
+// DMARCRecordName returns the name of the DMARC record of the domain.
+func DMARCRecordName(domain string) string {
+	return "_dmarc." + domain
+}
+
+// ParseDKIM parses the DKIM record of the selector, e.g., "v=DKIM1; k=rsa; p=MIIBIjANBg...".
+func ParseDKIM(selector, txt string) (*DKIMRecord, error) {
+	raw := unquoteTXT(txt)
+	tags := parseTagList(raw)
+	if v, ok := tags["v"]; ok && v != "DKIM1" {
+		return nil, fmt.Errorf("invalid DKIM version %q", v)
+	}
+	key, ok := tags["p"]
+	if !ok {
+		return nil, errors.New("DKIM record has no public key")
+	}
+
+	record := &DKIMRecord{
+		Selector:  selector,
+		Raw:       raw,
+		KeyType:   strings.ToLower(tags["k"]),
+		PublicKey: strings.ReplaceAll(key, " ", ""),
+		Revoked:   key == "",
+	}
+	if record.KeyType == "" {
+		record.KeyType = "rsa"
+	}
+	for _, h := range strings.Split(tags["h"], ":") {
+		if h = strings.TrimSpace(h); h != "" {
+			record.HashAlgorithms = append(record.HashAlgorithms, h)
+		}
+	}
+	for _, f := range strings.Split(tags["t"], ":") {
+		if f = strings.TrimSpace(f); f != "" {
+			record.Flags = append(record.Flags, f)
+		}
+	}
+
+	if !record.Revoked {
+		bits, err := dkimKeyBits(record.KeyType, record.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DKIM public key: %w", err)
+		}
+		record.KeyBits = bits
+	}
+	return record, nil
+}
+
+// dkimKeyBits returns the size of a base64 encoded DKIM public key.
+func dkimKeyBits(keyType, key string) (int, error) {
+	der, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return 0, err
+	}
+
+	switch keyType {
+	case "rsa":
+		pub, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			// Some signers publish the bare PKCS #1 key
+			rsaKey, pkcs1Err := x509.ParsePKCS1PublicKey(der)
+			if pkcs1Err != nil {
+				return 0, err
+			}
+			pub = rsaKey
+		}
+		rsaKey, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return 0, fmt.Errorf("unexpected %T key", pub)
+		}
+		return rsaKey.N.BitLen(), nil
+	case "ed25519":
+		if len(der) != ed25519.PublicKeySize {
+			return 0, fmt.Errorf("invalid ed25519 key length %d", len(der))
+		}
+		return ed25519.PublicKeySize * 8, nil
+	default:
+		return 0, fmt.Errorf("unsupported key type %q", keyType)
+	}
+}
+
+// parseTagList parses the "tag=value; tag=value" lists of DMARC and DKIM records.
+func parseTagList(s string) map[string]string {
+	tags := make(map[string]string)
+	for _, pair := range strings.Split(s, ";") {
+		tag, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		tags[strings.ToLower(strings.TrimSpace(tag))] = strings.TrimSpace(value)
+	}
+	return tags
+}
+
+// unquoteTXT joins the quoted strings of a TXT record, e.g., `"v=spf1 " "-all"`,
+// as some resolvers report them.
+func unquoteTXT(txt string) string {
+	txt = strings.TrimSpace(txt)
+	if !strings.HasPrefix(txt, `"`) || !strings.HasSuffix(txt, `"`) {
+		return txt
+	}
+	var b strings.Builder
+	for _, part := range strings.Split(txt[1:len(txt)-1], `" "`) {
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// EmailSecurityResult is the SPF, DMARC and DKIM posture of a domain.
+type EmailSecurityResult struct {
+	Domain   string        `json:"domain"`
+	SPF      *SPFRecord    `json:"spf,omitempty"`
+	DMARC    *DMARCRecord  `json:"dmarc,omitempty"`
+	DKIM     []*DKIMRecord `json:"dkim,omitempty"`
+	Findings []Finding     `json:"findings"`
+}
+
+// SeverityCounts counts the findings by severity.
+func (r *EmailSecurityResult) SeverityCounts() SeverityCounts {
+	return GetFindingSeverityCounts(r.Findings)
+}
+
+// EmailSecurity analyzes the email security posture of the looked up domain.
+func (r *DNSLookupResult) EmailSecurity() *EmailSecurityResult {
+	return AnalyzeEmailSecurity(r.Domain, r.DNSRecords)
+}
+
+// AnalyzeEmailSecurity analyzes the SPF, DMARC and DKIM records among the TXT
+// records of the domain. DMARC and DKIM records are expected under their
+// own names, e.g., "_dmarc.example.com" and "google._domainkey.example.com".
+// Records without a name are assumed to belong to the domain itself.
+func AnalyzeEmailSecurity(domain string, records []DNSRecord) *EmailSecurityResult {
+	domain = normalizeDNSName(domain)
+	res := &EmailSecurityResult{Domain: domain, Findings: []Finding{}}
+
+	var spf, dmarc []string
+	dkimSuffix := "._domainkey." + domain
+	for _, record := range records {
+		txt, ok := record.AsString()
+		if record.Type != TXTRecord || !ok {
+			continue
+		}
+		name := normalizeDNSName(record.Name)
+		switch {
+		case (name == domain || name == "") && IsSPFRecord(txt):
+			spf = append(spf, txt)
+		case name == DMARCRecordName(domain) && IsDMARCRecord(txt):
+			dmarc = append(dmarc, txt)
+		case strings.HasSuffix(name, dkimSuffix):
+			res.analyzeDKIM(strings.TrimSuffix(name, dkimSuffix), txt)
+		}
+	}
+
+	res.analyzeSPF(spf)
+	res.analyzeDMARC(dmarc)
+	return res
+}
+
+func (r *EmailSecurityResult) addFinding(id, title, description string, severity enums.SeverityType, remediation, evidence string) {
+	r.Findings = append(r.Findings, Finding{
+		ID:          id,
+		Title:       title,
+		Description: description,
+		Severity:    severity,
+		Category:    enums.OwaspCategorySecurityMisconfiguration,
+		Remediation: remediation,
+		Evidence:    evidence,
+	})
+}
+
+func (r *EmailSecurityResult) analyzeSPF(records []string) {
+	switch len(records) {
+	case 0:
+		r.addFinding(FindingSPFMissing, "No SPF record",
+			"The domain publishes no SPF record, so receivers cannot tell which servers may send email on its behalf.",
+			enums.SeverityTypeMedium, "Publish an SPF record listing the domain's mail servers and ending in \"-all\" or \"~all\".", "")
+		return
+	case 1:
+	default:
+		r.addFinding(FindingSPFMultiple, "Multiple SPF records",
+			"The domain publishes more than one SPF record, which makes SPF evaluation fail with a permanent error.",
+			enums.SeverityTypeMedium, "Merge the SPF records into a single record.", strings.Join(records, "\n"))
+		return
+	}
+
+	spf, err := ParseSPF(records[0])
+	if err != nil {
+		r.addFinding(FindingSPFInvalid, "Invalid SPF record",
+			fmt.Sprintf("The SPF record cannot be evaluated: %s.", err),
+			enums.SeverityTypeMedium, "Fix the syntax of the SPF record.", records[0])
+		return
+	}
+	r.SPF = spf
+
+	all, hasAll := spf.All()
+	switch {
+	case hasAll && all.Qualifier == SPFQualifierPass:
+		r.addFinding(FindingSPFPassAll, "SPF allows any sender",
+			"The SPF record ends in \"+all\", which authorizes every server on the Internet to send email for the domain.",
+			enums.SeverityTypeHigh, "Replace \"+all\" with \"-all\" or \"~all\".", spf.Raw)
+	case hasAll && all.Qualifier == SPFQualifierNeutral:
+		r.addFinding(FindingSPFNeutralAll, "SPF neutral policy",
+			"The SPF record ends in \"?all\", so email from unauthorized servers is neither accepted nor rejected.",
+			enums.SeverityTypeMedium, "Replace \"?all\" with \"-all\" or \"~all\".", spf.Raw)
+	case !hasAll && spf.Redirect == "":
+		r.addFinding(FindingSPFNoAll, "SPF record without \"all\"",
+			"The SPF record has no \"all\" mechanism nor redirect, so email from unauthorized servers gets a neutral result.",
+			enums.SeverityTypeLow, "End the SPF record with \"-all\" or \"~all\".", spf.Raw)
+	}
+
+	if spf.LookupCount > SPFMaxLookups {
+		r.addFinding(FindingSPFTooManyLookups, "SPF record exceeds the DNS lookup limit",
+			fmt.Sprintf("The SPF record requires %d DNS lookups, more than the %d allowed, which makes SPF evaluation fail.", spf.LookupCount, SPFMaxLookups),
+			enums.SeverityTypeMedium, "Reduce the number of include, a, mx, ptr and exists mechanisms, e.g., by using ip4 and ip6 mechanisms.", spf.Raw)
+	}
+	for _, m := range spf.Mechanisms {
+		if m.Name == "ptr" {
+			r.addFinding(FindingSPFPTR, "SPF record uses the ptr mechanism",
+				"The ptr mechanism is deprecated, slow and unreliable, and some receivers ignore it.",
+				enums.SeverityTypeLow, "Replace the ptr mechanism with ip4, ip6 or a mechanisms.", spf.Raw)
+			break
+		}
+	}
+}
+
+func (r *EmailSecurityResult) analyzeDMARC(records []string) {
+	switch len(records) {
+	case 0:
+		r.addFinding(FindingDMARCMissing, "No DMARC record",
+			"The domain publishes no DMARC record, so receivers apply no policy to email spoofing the domain.",
+			enums.SeverityTypeMedium, fmt.Sprintf("Publish a DMARC record at %s, starting with \"p=none\" and an aggregate report address.", DMARCRecordName(r.Domain)), "")
+		return
+	case 1:
+	default:
+		r.addFinding(FindingDMARCMultiple, "Multiple DMARC records",
+			"The domain publishes more than one DMARC record, so receivers ignore DMARC for the domain.",
+			enums.SeverityTypeMedium, "Keep a single DMARC record.", strings.Join(records, "\n"))
+		return
+	}
+
+	dmarc, err := ParseDMARC(records[0])
+	if err != nil {
+		r.addFinding(FindingDMARCInvalid, "Invalid DMARC record",
+			fmt.Sprintf("The DMARC record cannot be evaluated: %s.", err),
+			enums.SeverityTypeMedium, "Fix the syntax of the DMARC record.", records[0])
+		return
+	}
+	r.DMARC = dmarc
+
+	if dmarc.Policy == DMARCPolicyNone {
+		r.addFinding(FindingDMARCPolicyNone, "DMARC policy is not enforced",
+			"The DMARC policy is \"none\", so email failing DMARC is delivered as usual.",
+			enums.SeverityTypeMedium, "Move the DMARC policy to \"quarantine\" or \"reject\" once reports show legitimate email passes.", dmarc.Raw)
+	} else {
+		if dmarc.Percentage < 100 {
+			r.addFinding(FindingDMARCPartial, "DMARC policy is partially enforced",
+				fmt.Sprintf("The DMARC policy only applies to %d%% of the email failing DMARC.", dmarc.Percentage),
+				enums.SeverityTypeLow, "Raise the DMARC pct tag to 100.", dmarc.Raw)
+		}
+		if dmarc.SubdomainPolicy == DMARCPolicyNone {
+			r.addFinding(FindingDMARCSubdomainNone, "DMARC policy is not enforced on subdomains",
+				"The DMARC subdomain policy is \"none\", so email spoofing subdomains is delivered as usual.",
+				enums.SeverityTypeLow, "Remove the DMARC sp tag or set it to \"quarantine\" or \"reject\".", dmarc.Raw)
+		}
+	}
+	if len(dmarc.AggregateReportURIs) == 0 {
+		r.addFinding(FindingDMARCNoReports, "DMARC aggregate reports are not requested",
+			"The DMARC record has no rua tag, so the domain owner gets no visibility on email sent on its behalf.",
+			enums.SeverityTypeLow, "Add a rua tag with a mailbox collecting the aggregate reports.", dmarc.Raw)
+	}
+}
+
+func (r *EmailSecurityResult) analyzeDKIM(selector, txt string) {
+	dkim, err := ParseDKIM(selector, txt)
+	if err != nil {
+		r.addFinding(FindingDKIMInvalid, "Invalid DKIM record",
+			fmt.Sprintf("The DKIM record of selector %q cannot be used: %s.", selector, err),
+			enums.SeverityTypeLow, "Fix or remove the DKIM record.", txt)
+		return
+	}
+	r.DKIM = append(r.DKIM, dkim)
+
+	if dkim.KeyType == "rsa" && !dkim.Revoked && dkim.KeyBits < 2048 {
+		severity := enums.SeverityTypeLow
+		if dkim.KeyBits < 1024 {
+			severity = enums.SeverityTypeHigh
+		}
+		r.addFinding(FindingDKIMWeakKey, "Weak DKIM key",
+			fmt.Sprintf("The DKIM key of selector %q is a %d-bit RSA key, which is too short to resist forgery.", selector, dkim.KeyBits),
+			severity, "Rotate the DKIM key to a 2048-bit RSA key.", dkim.Raw)
+	}
+	if dkim.IsTesting() {
+		r.addFinding(FindingDKIMTesting, "DKIM in testing mode",
+			fmt.Sprintf("The DKIM record of selector %q is flagged as testing, so receivers ignore failed signatures.", selector),
+			enums.SeverityTypeLow, "Remove the \"y\" flag from the DKIM record.", dkim.Raw)
+	}
+}
+
+// normalizeDNSName lowercases the name and removes its trailing dot.
+func normalizeDNSName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
@@ -0,0 +1,270 @@
+package tools
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseSPF(t *testing.T) {
+	testCases := []struct {
+		name     string
+		txt      string
+		expected *SPFRecord
+		wantErr  error
+	}{
+		{
+			name: "Qualifiers and modifiers",
+			txt:  "v=spf1 mx a/24 ip4:192.0.2.0/24 -ip6:2001:db8::/32 ?exists:%{i}.spf.example.com include:_spf.google.com exp=explain.example.com ~all",
+			expected: &SPFRecord{
+				Raw: "v=spf1 mx a/24 ip4:192.0.2.0/24 -ip6:2001:db8::/32 ?exists:%{i}.spf.example.com include:_spf.google.com exp=explain.example.com ~all",
+				Mechanisms: []SPFMechanism{
+					{Qualifier: SPFQualifierPass, Name: "mx"},
+					{Qualifier: SPFQualifierPass, Name: "a", Value: "/24"},
+					{Qualifier: SPFQualifierPass, Name: "ip4", Value: "192.0.2.0/24"},
+					{Qualifier: SPFQualifierFail, Name: "ip6", Value: "2001:db8::/32"},
+					{Qualifier: SPFQualifierNeutral, Name: "exists", Value: "%{i}.spf.example.com"},
+					{Qualifier: SPFQualifierPass, Name: "include", Value: "_spf.google.com"},
+					{Qualifier: SPFQualifierSoftFail, Name: "all"},
+				},
+				Explanation: "explain.example.com",
+				LookupCount: 4,
+			},
+		},
+		{
+			name: "Redirect and quoted strings",
+			txt:  `"v=spf1 " "redirect=_spf.example.com"`,
+			expected: &SPFRecord{
+				Raw:         "v=spf1 redirect=_spf.example.com",
+				Mechanisms:  []SPFMechanism{},
+				Redirect:    "_spf.example.com",
+				LookupCount: 1,
+			},
+		},
+		{
+			name:    "Not SPF",
+			txt:     "google-site-verification=abc",
+			wantErr: ErrNotSPFRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spf, err := ParseSPF(tc.txt)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, spf)
+		})
+	}
+
+	_, err := ParseSPF("v=spf1 include:example.com foo:bar -all")
+	assert.ErrorContains(t, err, `invalid SPF term "foo:bar"`)
+
+	spf, err := ParseSPF("v=spf1 -all")
+	require.NoError(t, err)
+	all, ok := spf.All()
+	assert.True(t, ok)
+	assert.Equal(t, "-all", all.String())
+}
+
+func Test_ParseDMARC(t *testing.T) {
+	dmarc, err := ParseDMARC("v=DMARC1; p=quarantine; sp=none; pct=50; rua=mailto:dmarc@example.com, mailto:reports@vendor.example; ruf=mailto:forensic@example.com; adkim=s; fo=1")
+	require.NoError(t, err)
+	assert.Equal(t, &DMARCRecord{
+		Raw:                 "v=DMARC1; p=quarantine; sp=none; pct=50; rua=mailto:dmarc@example.com, mailto:reports@vendor.example; ruf=mailto:forensic@example.com; adkim=s; fo=1",
+		Policy:              DMARCPolicyQuarantine,
+		SubdomainPolicy:     DMARCPolicyNone,
+		Percentage:          50,
+		AggregateReportURIs: []string{"mailto:dmarc@example.com", "mailto:reports@vendor.example"},
+		ForensicReportURIs:  []string{"mailto:forensic@example.com"},
+		DKIMAlignment:       DMARCAlignmentStrict,
+		SPFAlignment:        DMARCAlignmentRelaxed,
+		FailureOptions:      "1",
+	}, dmarc)
+
+	// Defaults
+	dmarc, err = ParseDMARC("v=DMARC1;p=reject")
+	require.NoError(t, err)
+	assert.Equal(t, DMARCPolicyReject, dmarc.SubdomainPolicy)
+	assert.Equal(t, 100, dmarc.Percentage)
+	assert.Equal(t, DMARCAlignmentRelaxed, dmarc.DKIMAlignment)
+
+	for _, txt := range []string{
+		"v=DMARC1; p=block",
+		"v=DMARC1; rua=mailto:dmarc@example.com",
+		"v=DMARC1; p=none; pct=150",
+		"v=DMARC1; p=none; aspf=x",
+	} {
+		_, err := ParseDMARC(txt)
+		assert.Error(t, err, txt)
+	}
+	_, err = ParseDMARC("p=reject; v=DMARC1")
+	assert.ErrorIs(t, err, ErrNotDMARCRecord)
+}
+
+func Test_ParseDKIM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	pub := base64.StdEncoding.EncodeToString(der)
+
+	dkim, err := ParseDKIM("selector1", "v=DKIM1; h=sha256; t=y:s; p="+pub)
+	require.NoError(t, err)
+	assert.Equal(t, "rsa", dkim.KeyType)
+	assert.Equal(t, 1024, dkim.KeyBits)
+	assert.Equal(t, []string{"sha256"}, dkim.HashAlgorithms)
+	assert.True(t, dkim.IsTesting())
+	assert.False(t, dkim.Revoked)
+
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	dkim, err = ParseDKIM("ed", "v=DKIM1; k=ed25519; p="+base64.StdEncoding.EncodeToString(edKey))
+	require.NoError(t, err)
+	assert.Equal(t, 256, dkim.KeyBits)
+	assert.False(t, dkim.IsTesting())
+
+	dkim, err = ParseDKIM("old", "v=DKIM1; p=")
+	require.NoError(t, err)
+	assert.True(t, dkim.Revoked)
+
+	for _, txt := range []string{"v=DKIM1; k=rsa", "v=DKIM2; p=" + pub, "v=DKIM1; p=not-base64!"} {
+		_, err := ParseDKIM("s", txt)
+		assert.Error(t, err, txt)
+	}
+}
+
+func findingIDs(findings []Finding) []string {
+	ids := []string{}
+	for _, f := range findings {
+		ids = append(ids, f.ID)
+	}
+	return ids
+}
+
+func txtRecord(name, value string) DNSRecord {
+	return DNSRecord{Type: TXTRecord, Name: name, TTL: 300, Value: value}
+}
+
+func Test_AnalyzeEmailSecurity(t *testing.T) {
+	// 512-bit keys cannot be generated anymore, only the modulus size matters
+	weakKey := &rsa.PublicKey{N: new(big.Int).Lsh(big.NewInt(1), 511), E: 65537}
+	der, err := x509.MarshalPKIXPublicKey(weakKey)
+	require.NoError(t, err)
+	weakDKIM := "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der)
+
+	tooManyLookups := "v=spf1"
+	for range SPFMaxLookups + 1 {
+		tooManyLookups += " include:example.net"
+	}
+
+	testCases := []struct {
+		name     string
+		records  []DNSRecord
+		expected []string
+	}{
+		{
+			name: "Hardened domain",
+			records: []DNSRecord{
+				txtRecord("example.com.", "v=spf1 include:_spf.google.com -all"),
+				txtRecord("example.com", "google-site-verification=abc"),
+				txtRecord("_dmarc.example.com", "v=DMARC1; p=reject; rua=mailto:dmarc@example.com"),
+				{Type: MXRecord, Name: "example.com", Value: MailExchange{Host: "mx.example.com", Priority: 10}},
+			},
+			expected: []string{},
+		},
+		{
+			name:     "No records",
+			records:  nil,
+			expected: []string{FindingSPFMissing, FindingDMARCMissing},
+		},
+		{
+			name: "Permissive policies",
+			records: []DNSRecord{
+				txtRecord("example.com", "v=spf1 ptr +all"),
+				txtRecord("_dmarc.example.com", "v=DMARC1; p=none"),
+			},
+			expected: []string{FindingSPFPassAll, FindingSPFPTR, FindingDMARCPolicyNone, FindingDMARCNoReports},
+		},
+		{
+			name: "Partial enforcement",
+			records: []DNSRecord{
+				txtRecord("example.com", "v=spf1 mx ?all"),
+				txtRecord("_dmarc.example.com", "v=DMARC1; p=quarantine; sp=none; pct=25; rua=mailto:dmarc@example.com"),
+			},
+			expected: []string{FindingSPFNeutralAll, FindingDMARCPartial, FindingDMARCSubdomainNone},
+		},
+		{
+			name: "Broken records",
+			records: []DNSRecord{
+				txtRecord("", tooManyLookups),
+				txtRecord("_dmarc.example.com", "v=DMARC1; p=reject"),
+				txtRecord("_dmarc.example.com", "v=DMARC1; p=none"),
+			},
+			expected: []string{FindingSPFNoAll, FindingSPFTooManyLookups, FindingDMARCMultiple},
+		},
+		{
+			name: "Invalid and duplicated records",
+			records: []DNSRecord{
+				txtRecord("example.com", "v=spf1 -all"),
+				txtRecord("example.com", "v=spf1 mx -all"),
+				txtRecord("_dmarc.example.com", "v=DMARC1; p=monitor"),
+			},
+			expected: []string{FindingSPFMultiple, FindingDMARCInvalid},
+		},
+		{
+			name: "DKIM selectors",
+			records: []DNSRecord{
+				txtRecord("example.com", "v=spf1 -ptr:example.com -all"),
+				txtRecord("_dmarc.example.com", "v=DMARC1; p=reject; rua=mailto:dmarc@example.com"),
+				txtRecord(DKIMRecordName("weak", "example.com"), weakDKIM+"; t=y"),
+				txtRecord(DKIMRecordName("broken", "example.com"), "v=DKIM1; k=rsa"),
+				txtRecord(DKIMRecordName("old", "example.com"), "v=DKIM1; p="),
+			},
+			expected: []string{FindingDKIMWeakKey, FindingDKIMTesting, FindingDKIMInvalid, FindingSPFPTR},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := AnalyzeEmailSecurity("Example.com", tc.records)
+			assert.Equal(t, "example.com", res.Domain)
+			assert.Equal(t, tc.expected, findingIDs(res.Findings))
+			for _, f := range res.Findings {
+				assert.Equal(t, enums.OwaspCategorySecurityMisconfiguration, f.Category)
+				assert.NotEmpty(t, f.Title)
+				assert.NotEmpty(t, f.Remediation)
+			}
+		})
+	}
+}
+
+func Test_EmailSecurityResult(t *testing.T) {
+	lookup := &DNSLookupResult{
+		Domain: "example.com",
+		DNSRecords: []DNSRecord{
+			txtRecord("example.com", "v=spf1 +all"),
+			txtRecord("_dmarc.example.com", "v=DMARC1; p=none; rua=mailto:dmarc@example.com"),
+		},
+	}
+
+	res := lookup.EmailSecurity()
+	require.NotNil(t, res.SPF)
+	require.NotNil(t, res.DMARC)
+	assert.Equal(t, DMARCPolicyNone, res.DMARC.Policy)
+	assert.Equal(t, SeverityCounts{High: 1, Medium: 1}, res.SeverityCounts())
+
+	counts := GetSeverityCounts([]Vulnerability{{BaseSeverity: enums.SeverityTypeCritical}}).Add(res.SeverityCounts())
+	assert.Equal(t, SeverityCounts{Critical: 1, High: 1, Medium: 1}, counts)
+}
@@ -0,0 +1,57 @@
+package tools
+
+import "github.com/kptm-tools/common/common/pkg/enums"
+
+// Finding is a security weakness derived from tool results rather than
+// reported as a CVE, e.g., a permissive SPF policy or an expiring domain.
+type Finding struct {
+	// ID identifies the kind of finding, e.g., "spf-pass-all". It is stable
+	// across scans so findings can be tracked and deduplicated.
+	ID          string              `json:"id"`
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Severity    enums.SeverityType  `json:"severity"`
+	Category    enums.OwaspCategory `json:"category"`
+	Remediation string              `json:"remediation,omitempty"`
+	// Evidence is the data the finding was derived from, e.g., the offending record.
+	Evidence string `json:"evidence,omitempty"`
+}
+
+// GetFindingSeverityCounts counts the findings by severity.
+func GetFindingSeverityCounts(findings []Finding) SeverityCounts {
+	counts := SeverityCounts{}
+	for _, f := range findings {
+		counts.increment(f.Severity)
+	}
+	return counts
+}
+
+// Add returns the sum of both counts.
+func (c SeverityCounts) Add(other SeverityCounts) SeverityCounts {
+	return SeverityCounts{
+		Critical: c.Critical + other.Critical,
+		High:     c.High + other.High,
+		Medium:   c.Medium + other.Medium,
+		Low:      c.Low + other.Low,
+		None:     c.None + other.None,
+		Unknown:  c.Unknown + other.Unknown,
+	}
+}
+
+// increment counts one more finding of the severity.
+func (c *SeverityCounts) increment(severity enums.SeverityType) {
+	switch severity {
+	case enums.SeverityTypeNone:
+		c.None++
+	case enums.SeverityTypeLow:
+		c.Low++
+	case enums.SeverityTypeMedium:
+		c.Medium++
+	case enums.SeverityTypeHigh:
+		c.High++
+	case enums.SeverityTypeCritical:
+		c.Critical++
+	default:
+		c.Unknown++
+	}
+}
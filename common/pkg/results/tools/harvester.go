@@ -3,20 +3,133 @@ package tools
 import (
 	"encoding/json"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/kptm-tools/common/common/pkg/enums"
 )
 
 type HarvesterResult struct {
-	Emails     []string `json:"emails"`     // A list of harvested emails
-	Subdomains []string `json:"subdomains"` // A list of harvested subdomains
+	Emails     []string `json:"emails" sensitive:"true"` // A list of harvested emails
+	Subdomains []string `json:"subdomains"`              // A list of harvested subdomains
+
+	// EmailDetails and Hosts attribute each email and subdomain to the OSINT
+	// sources that found it. They are absent from older payloads.
+	EmailDetails []HarvestedEmail `json:"email_details,omitempty"`
+	Hosts        []HarvestedHost  `json:"hosts,omitempty"`
+}
+
+// HarvestedEmail is an email found by one or more OSINT sources.
+type HarvestedEmail struct {
+	Address   string    `json:"address" sensitive:"true"`
+	Sources   []string  `json:"sources"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// HarvestedHost is a subdomain found by one or more OSINT sources, along with
+// the IPs it resolved to.
+type HarvestedHost struct {
+	Name      string    `json:"name"`
+	IPs       []string  `json:"ips,omitempty"`
+	Sources   []string  `json:"sources"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// AddEmail records an email found by sources at seenAt. Emails are compared
+// case-insensitively; an email found again gains the sources and keeps the
+// earliest first-seen time.
+func (r *HarvesterResult) AddEmail(address string, seenAt time.Time, sources ...string) {
+	address = normalizeHarvestedEmail(address)
+	if address == "" {
+		return
+	}
+
+	for i := range r.EmailDetails {
+		e := &r.EmailDetails[i]
+		if e.Address == address {
+			e.Sources = addSources(e.Sources, sources)
+			e.FirstSeen = earliest(e.FirstSeen, seenAt)
+			return
+		}
+	}
+
+	r.EmailDetails = append(r.EmailDetails, HarvestedEmail{
+		Address:   address,
+		Sources:   addSources([]string{}, sources),
+		FirstSeen: seenAt,
+	})
+	if !slices.Contains(r.Emails, address) {
+		r.Emails = append(r.Emails, address)
+	}
+}
+
+// AddHost records a subdomain resolving to ips found by sources at seenAt.
+// Hosts are compared case-insensitively; a host found again gains the sources
+// and IPs and keeps the earliest first-seen time.
+func (r *HarvesterResult) AddHost(name string, ips []string, seenAt time.Time, sources ...string) {
+	name = normalizeDNSName(name)
+	if name == "" {
+		return
+	}
+
+	for i := range r.Hosts {
+		h := &r.Hosts[i]
+		if h.Name == name {
+			h.IPs = addIPs(h.IPs, ips)
+			h.Sources = addSources(h.Sources, sources)
+			h.FirstSeen = earliest(h.FirstSeen, seenAt)
+			return
+		}
+	}
+
+	r.Hosts = append(r.Hosts, HarvestedHost{
+		Name:      name,
+		IPs:       addIPs(nil, ips),
+		Sources:   addSources([]string{}, sources),
+		FirstSeen: seenAt,
+	})
+	if !slices.Contains(r.Subdomains, name) {
+		r.Subdomains = append(r.Subdomains, name)
+	}
+}
+
+// Merge adds the emails and hosts of other to r, e.g., to combine theHarvester
+// runs against different sources.
+func (r *HarvesterResult) Merge(other *HarvesterResult) {
+	for _, e := range other.EmailDetails {
+		r.AddEmail(e.Address, e.FirstSeen, e.Sources...)
+	}
+	for _, h := range other.Hosts {
+		r.AddHost(h.Name, h.IPs, h.FirstSeen, h.Sources...)
+	}
+
+	// Older payloads carry no attribution, and may not be normalized
+	r.Emails = mergeNormalized(r.Emails, other.Emails, normalizeHarvestedEmail)
+	r.Subdomains = mergeNormalized(r.Subdomains, other.Subdomains, normalizeDNSName)
+}
+
+// mergeNormalized adds the values to list, normalized as AddEmail and AddHost
+// do, unless already there.
+func mergeNormalized(list, values []string, normalize func(string) string) []string {
+	for _, v := range values {
+		v = normalize(v)
+		if v != "" && !slices.ContainsFunc(list, func(existing string) bool { return normalize(existing) == v }) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func normalizeHarvestedEmail(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
 }
 
 // LogValue creates a standard structured log representation for logging.
 func (r *HarvesterResult) LogValue() slog.Value {
 	return slog.GroupValue(
+		// Emails are sensitive, only their count is logged
 		slog.Int("email_count", len(r.Emails)),
-		slog.Any("emails", r.Emails),
 		slog.Int("subdomain_count", len(r.Subdomains)),
 		slog.Any("subdomains", r.Subdomains),
 	)
@@ -34,3 +147,28 @@ func (r *HarvesterResult) ToJSON() string {
 func (r *HarvesterResult) GetToolName() enums.ToolName {
 	return enums.ToolHarvester
 }
+
+func addSources(sources []string, newSources []string) []string {
+	for _, source := range newSources {
+		if source = strings.ToLower(strings.TrimSpace(source)); source != "" && !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+func addIPs(ips []string, newIPs []string) []string {
+	for _, ip := range newIPs {
+		if ip = strings.TrimSpace(ip); ip != "" && !slices.Contains(ips, ip) {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// theHarvesterOutput mirrors the subset of theHarvester's JSON output (-f) we
+// consume. Hosts are either "name" or "name:ip[, ip...]" when resolved.
+type theHarvesterOutput struct {
+	Emails []string `json:"emails"`
+	Hosts  []string `json:"hosts"`
+}
+
+// ParseHarvesterJSON parses the JSON output of a theHarvester run against
+// source (its -b flag) completed at seenAt. theHarvester does not attribute
+// results when run against several sources at once, so run it once per
+// source and Merge the results to keep the attribution.
+func ParseHarvesterJSON(r io.Reader, source string, seenAt time.Time) (*HarvesterResult, error) {
+	var out theHarvesterOutput
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode theHarvester JSON output: %w", err)
+	}
+
+	res := &HarvesterResult{Emails: []string{}, Subdomains: []string{}}
+	for _, email := range out.Emails {
+		res.AddEmail(email, seenAt, source)
+	}
+	for _, host := range out.Hosts {
+		name, ips := parseHarvesterHost(host)
+		res.AddHost(name, ips, seenAt, source)
+	}
+	return res, nil
+}
+
+func parseHarvesterHost(host string) (string, []string) {
+	name, addrs, found := strings.Cut(host, ":")
+	if !found {
+		return name, nil
+	}
+	return name, strings.Split(addrs, ",")
+}
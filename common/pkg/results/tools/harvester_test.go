@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseHarvesterFixture(t *testing.T, source string, seenAt time.Time) *HarvesterResult {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", "harvester", source+".json"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	res, err := ParseHarvesterJSON(f, source, seenAt)
+	require.NoError(t, err)
+	return res
+}
+
+func Test_ParseHarvesterJSON(t *testing.T) {
+	seenAt := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	res := parseHarvesterFixture(t, "crtsh", seenAt)
+
+	assert.Empty(t, res.Emails)
+	assert.Equal(t, []string{"api.example.com", "dev.example.com", "mail.example.com", "vpn.example.com"}, res.Subdomains)
+	assert.Equal(t, []HarvestedHost{
+		{Name: "api.example.com", IPs: []string{"93.184.216.34"}, Sources: []string{"crtsh"}, FirstSeen: seenAt},
+		{Name: "dev.example.com", Sources: []string{"crtsh"}, FirstSeen: seenAt},
+		{Name: "mail.example.com", IPs: []string{"93.184.216.40", "93.184.216.41"}, Sources: []string{"crtsh"}, FirstSeen: seenAt},
+		{Name: "vpn.example.com", IPs: []string{"10.0.0.5"}, Sources: []string{"crtsh"}, FirstSeen: seenAt},
+	}, res.Hosts)
+
+	_, err := ParseHarvesterJSON(strings.NewReader(`{"emails": "not a list"}`), "bing", seenAt)
+	assert.Error(t, err)
+}
+
+func Test_HarvesterResultMerge(t *testing.T) {
+	first := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	res := parseHarvesterFixture(t, "bing", second)
+	res.Merge(parseHarvesterFixture(t, "crtsh", first))
+	res.Merge(&HarvesterResult{Emails: []string{"legacy@example.com"}, Subdomains: []string{"old.example.com"}})
+	// Legacy lists are normalized like the attributed ones
+	res.Merge(&HarvesterResult{
+		Emails:     []string{"John.Doe@Example.com", " LEGACY@example.com", ""},
+		Subdomains: []string{"WWW.example.com.", "Old.Example.com"},
+	})
+
+	assert.Equal(t, []string{"john.doe@example.com", "info@example.com", "legacy@example.com"}, res.Emails)
+	assert.Equal(t, []string{"api.example.com", "www.example.com", "dev.example.com", "mail.example.com", "vpn.example.com", "old.example.com"}, res.Subdomains)
+	require.Len(t, res.EmailDetails, 2)
+	assert.Equal(t, HarvestedEmail{Address: "john.doe@example.com", Sources: []string{"bing"}, FirstSeen: second}, res.EmailDetails[0])
+
+	// Found by both sources, first seen by crtsh
+	api := res.Hosts[0]
+	assert.Equal(t, "api.example.com", api.Name)
+	assert.Equal(t, []string{"93.184.216.35", "93.184.216.34"}, api.IPs)
+	assert.Equal(t, []string{"bing", "crtsh"}, api.Sources)
+	assert.Equal(t, first, api.FirstSeen)
+}
+
+func Test_HarvesterResultBackwardCompatibility(t *testing.T) {
+	payload := `{"tool_name":"Harvester","result":{"emails":["jane@example.com"],"subdomains":["www.example.com"]},"error":null,"timestamp":"2025-01-06T10:00:00Z"}`
+
+	var tr ToolResult
+	require.NoError(t, json.Unmarshal([]byte(payload), &tr))
+	assert.Equal(t, enums.ToolHarvester, tr.Tool)
+
+	res, ok := tr.Result.(*HarvesterResult)
+	require.True(t, ok)
+	assert.Equal(t, []string{"jane@example.com"}, res.Emails)
+	assert.Equal(t, []string{"www.example.com"}, res.Subdomains)
+	assert.Empty(t, res.EmailDetails)
+	assert.Empty(t, res.Hosts)
+
+	// Results without attribution keep the old shape
+	data, err := json.Marshal(res)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"emails":["jane@example.com"],"subdomains":["www.example.com"]}`, string(data))
+}
+
+func Test_HarvesterResultLogValue(t *testing.T) {
+	res := &HarvesterResult{Emails: []string{"jane.doe@example.com"}, Subdomains: []string{"www.example.com"}}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("harvester", slog.Any("result", res))
+	assert.NotContains(t, buf.String(), "jane.doe@example.com")
+	assert.Contains(t, buf.String(), `"email_count":1`)
+	assert.Contains(t, buf.String(), "www.example.com")
+}
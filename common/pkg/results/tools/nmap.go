@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,10 +20,46 @@ const (
 )
 
 type NmapResult struct {
-	HostName     string     `json:"host_name"`
-	HostAddress  string     `json:"host_address"`
-	ScannedPorts []PortData `json:"scanned_ports"`
-	MostLikelyOS OSData     `json:"most_likely_os"`
+	HostName     string         `json:"host_name"`
+	HostAddress  string         `json:"host_address"`
+	ScannedPorts []PortData     `json:"scanned_ports"`
+	MostLikelyOS OSData         `json:"most_likely_os"`
+	HostScripts  []ScriptResult `json:"host_scripts,omitempty"`
+}
+
+// NmapScanResult is the result of an Nmap run against several hosts, e.g., a
+// network range. Hosts that are down are kept, with no ports.
+type NmapScanResult struct {
+	Args       string        `json:"args"`
+	Version    string        `json:"version"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Elapsed    time.Duration `json:"elapsed"`
+	HostsUp    int           `json:"hosts_up"`
+	HostsDown  int           `json:"hosts_down"`
+	HostsTotal int           `json:"hosts_total"`
+	Hosts      []NmapHost    `json:"hosts"`
+}
+
+// NmapHost is a host of an NmapScanResult.
+type NmapHost struct {
+	HostName    string `json:"host_name"`
+	HostAddress string `json:"host_address"`
+
+	// State is whether the host is up; Reason is how Nmap found it, e.g.,
+	// "echo-reply", "syn-ack" or "no-response".
+	State  enums.HostState `json:"state"`
+	Reason string          `json:"reason"`
+
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	Ports       []PortData     `json:"ports"`
+	HostScripts []ScriptResult `json:"host_scripts,omitempty"`
+
+	// OSMatches are the OS guesses, most accurate first.
+	OSMatches     []OSData `json:"os_matches"`
+	OSFingerprint string   `json:"os_fingerprint,omitempty"`
 }
 
 type OSData struct {
@@ -35,12 +73,13 @@ type OSData struct {
 }
 
 type PortData struct {
-	ID              uint16          `xml:"portid,attr" json:"id"`
-	Protocol        string          `xml:"protocol,attr" json:"protocol"`
-	Service         Service         `xml:"service" json:"service"`
-	Product         string          `xml:"product" json:"product"`
-	State           string          `xml:"state" json:"state"`
-	Vulnerabilities []Vulnerability `xml:"vulnerabilities" json:"vulnerabilities"`
+	ID              uint16             `xml:"portid,attr" json:"id"`
+	Protocol        enums.PortProtocol `xml:"protocol,attr" json:"protocol"`
+	Service         Service            `xml:"service" json:"service"`
+	Product         string             `xml:"product" json:"product"`
+	State           enums.PortState    `xml:"state" json:"state"`
+	Vulnerabilities []Vulnerability    `xml:"vulnerabilities" json:"vulnerabilities"`
+	Scripts         []ScriptResult     `xml:"-" json:"scripts,omitempty"`
 }
 
 type Service struct {
@@ -211,38 +250,124 @@ func (r *NmapResult) GetSeverityPerTypeMap() map[enums.OwaspCategory]int {
 	return severityMap
 }
 
+// GetOpenPorts returns the ports Nmap found open. Use GetPossiblyOpenPorts to
+// include the open|filtered ports UDP scans typically report.
 func (r *NmapResult) GetOpenPorts() []PortData {
-	var openPorts []PortData
+	return r.GetPortsByState(enums.PortStateOpen)
+}
+
+// GetPossiblyOpenPorts returns the open and open|filtered ports.
+func (r *NmapResult) GetPossiblyOpenPorts() []PortData {
+	return r.GetPortsByState(enums.PortStateOpen, enums.PortStateOpenFiltered)
+}
+
+// GetPortsByState returns the ports in any of the given states.
+func (r *NmapResult) GetPortsByState(states ...enums.PortState) []PortData {
+	var ports []PortData
 	for _, port := range r.ScannedPorts {
-		if port.State == "open" {
-			openPorts = append(openPorts, port)
+		if slices.Contains(states, port.State) {
+			ports = append(ports, port)
 		}
 	}
-	return openPorts
+	return ports
+}
+
+// GetPortsByProtocol returns the ports of the given protocol, optionally
+// restricted to the given states.
+func (r *NmapResult) GetPortsByProtocol(protocol enums.PortProtocol, states ...enums.PortState) []PortData {
+	var ports []PortData
+	for _, port := range r.ScannedPorts {
+		if port.Protocol == protocol && (len(states) == 0 || slices.Contains(states, port.State)) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// IsIPv6 reports whether the host was scanned over IPv6.
+func (r *NmapResult) IsIPv6() bool {
+	ip := net.ParseIP(r.HostAddress)
+	return ip != nil && ip.To4() == nil
 }
 
 func (r *NmapResult) GetToolName() enums.ToolName {
 	return enums.ToolNmap
 }
 
+// IsUp reports whether Nmap found the host up.
+func (h *NmapHost) IsUp() bool {
+	return h.State == enums.HostStateUp
+}
+
+// ToNmapResult converts the host into the single-host NmapResult consumed by
+// the existing services. The most accurate OS guess becomes MostLikelyOS.
+func (h *NmapHost) ToNmapResult() *NmapResult {
+	res := &NmapResult{
+		HostName:     h.HostName,
+		HostAddress:  h.HostAddress,
+		ScannedPorts: h.Ports,
+		HostScripts:  h.HostScripts,
+	}
+	if len(h.OSMatches) > 0 {
+		res.MostLikelyOS = h.OSMatches[0]
+		res.MostLikelyOS.FingerPrint = h.OSFingerprint
+	}
+	return res
+}
+
+// UpHosts returns the hosts Nmap found up.
+func (r *NmapScanResult) UpHosts() []NmapHost {
+	var hosts []NmapHost
+	for _, h := range r.Hosts {
+		if h.IsUp() {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+// Split converts the hosts found up into per-host NmapResults, in scan order.
+// Hosts that are down have nothing to report and are left out.
+func (r *NmapScanResult) Split() []*NmapResult {
+	var results []*NmapResult
+	for _, h := range r.UpHosts() {
+		results = append(results, h.ToNmapResult())
+	}
+	return results
+}
+
+// ToJSON returns a visually friendly JSON string which can be displayed with fmt.
+func (r *NmapScanResult) ToJSON() string {
+	data, err := json.MarshalIndent(r, "", " ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// GetToolName returns the multi-host Nmap tool, whose ToolResult payloads are
+// decoded as NmapScanResult. Services consuming per-host results are sent the
+// results of Split under enums.ToolNmap instead.
+func (r *NmapScanResult) GetToolName() enums.ToolName {
+	return enums.ToolNmapScan
+}
+
+// LogValue creates a standard structured log representation for logging.
+func (r *NmapScanResult) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("args", r.Args),
+		slog.Int("hosts_up", r.HostsUp),
+		slog.Int("hosts_down", r.HostsDown),
+		slog.Int("hosts_total", r.HostsTotal),
+		slog.Duration("elapsed", r.Elapsed),
+	)
+}
+
 func GetSeverityCounts(vulns []Vulnerability) SeverityCounts {
 	counts := SeverityCounts{}
 
 	for _, vuln := range vulns {
-		switch vuln.BaseSeverity {
-		case enums.SeverityTypeNone:
-			counts.None++
-		case enums.SeverityTypeLow:
-			counts.Low++
-		case enums.SeverityTypeMedium:
-			counts.Medium++
-		case enums.SeverityTypeHigh:
-			counts.High++
-		case enums.SeverityTypeCritical:
-			counts.Critical++
-		default:
-			counts.Unknown++
-		}
+		counts.increment(vuln.BaseSeverity)
 	}
 
 	return counts
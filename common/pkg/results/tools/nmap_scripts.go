@@ -0,0 +1,277 @@
+package tools
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IDs of the NSE scripts with typed accessors.
+const (
+	ScriptSSLCert        = "ssl-cert"
+	ScriptHTTPTitle      = "http-title"
+	ScriptSSHHostKey     = "ssh-hostkey"
+	ScriptBanner         = "banner"
+	ScriptSMBOSDiscovery = "smb-os-discovery"
+	ScriptVulners        = "vulners"
+)
+
+// subjectAltNameExtName is the name of the subjectAltName extension in ssl-cert output.
+const subjectAltNameExtName = "X509v3 Subject Alternative Name"
+
+// ScriptResult is the output of an NSE script run against a port or a host.
+// Output is the human-readable output; Elems and Tables hold the structured
+// output, when the script provides one.
+type ScriptResult struct {
+	ID     string        `json:"id"`
+	Output string        `json:"output"`
+	Elems  []ScriptElem  `json:"elems,omitempty"`
+	Tables []ScriptTable `json:"tables,omitempty"`
+}
+
+// ScriptElem is a value of an NSE script structured output. Elements of lists
+// have no key.
+type ScriptElem struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+// ScriptTable is a table of an NSE script structured output. Tables of lists
+// have no key.
+type ScriptTable struct {
+	Key    string        `json:"key,omitempty"`
+	Elems  []ScriptElem  `json:"elems,omitempty"`
+	Tables []ScriptTable `json:"tables,omitempty"`
+}
+
+// Get returns the value of the element with the given key.
+func (t ScriptTable) Get(key string) (string, bool) {
+	for _, e := range t.Elems {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+// Table returns the nested table with the given key.
+func (t ScriptTable) Table(key string) (ScriptTable, bool) {
+	for _, nested := range t.Tables {
+		if nested.Key == key {
+			return nested, true
+		}
+	}
+	return ScriptTable{}, false
+}
+
+// Get returns the value of the top-level element with the given key.
+func (s ScriptResult) Get(key string) (string, bool) {
+	return s.root().Get(key)
+}
+
+// Table returns the top-level table with the given key.
+func (s ScriptResult) Table(key string) (ScriptTable, bool) {
+	return s.root().Table(key)
+}
+
+func (s ScriptResult) root() ScriptTable {
+	return ScriptTable{Elems: s.Elems, Tables: s.Tables}
+}
+
+// findScript returns the result of the script with the given ID.
+func findScript(scripts []ScriptResult, id string) (ScriptResult, bool) {
+	for _, s := range scripts {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return ScriptResult{}, false
+}
+
+// Script returns the result of the script with the given ID run against the port.
+func (p *PortData) Script(id string) (ScriptResult, bool) {
+	return findScript(p.Scripts, id)
+}
+
+// HostScript returns the result of the script with the given ID run against the host.
+func (r *NmapResult) HostScript(id string) (ScriptResult, bool) {
+	return findScript(r.HostScripts, id)
+}
+
+// TLSCertificate is the certificate reported by the ssl-cert script.
+type TLSCertificate struct {
+	SubjectCommonName   string    `json:"subject_common_name"`
+	SubjectOrganization string    `json:"subject_organization,omitempty"`
+	IssuerCommonName    string    `json:"issuer_common_name"`
+	IssuerOrganization  string    `json:"issuer_organization,omitempty"`
+	SubjectAltNames     []string  `json:"subject_alt_names,omitempty"`
+	PublicKeyType       string    `json:"public_key_type"`
+	PublicKeyBits       int       `json:"public_key_bits"`
+	SignatureAlgorithm  string    `json:"signature_algorithm"`
+	NotBefore           time.Time `json:"not_before"`
+	NotAfter            time.Time `json:"not_after"`
+	SHA1                string    `json:"sha1,omitempty"`
+	PEM                 string    `json:"pem,omitempty"`
+}
+
+// IsExpired reports whether the certificate is expired at the given time.
+func (c *TLSCertificate) IsExpired(at time.Time) bool {
+	return !c.NotAfter.IsZero() && at.After(c.NotAfter)
+}
+
+// IsSelfSigned reports whether the certificate is issued by its own subject.
+func (c *TLSCertificate) IsSelfSigned() bool {
+	return c.SubjectCommonName == c.IssuerCommonName && c.SubjectOrganization == c.IssuerOrganization
+}
+
+// TLSCertificate returns the certificate reported by the ssl-cert script.
+func (p *PortData) TLSCertificate() (*TLSCertificate, bool) {
+	s, ok := p.Script(ScriptSSLCert)
+	if !ok {
+		return nil, false
+	}
+
+	cert := &TLSCertificate{}
+	if subject, ok := s.Table("subject"); ok {
+		cert.SubjectCommonName, _ = subject.Get("commonName")
+		cert.SubjectOrganization, _ = subject.Get("organizationName")
+	}
+	if issuer, ok := s.Table("issuer"); ok {
+		cert.IssuerCommonName, _ = issuer.Get("commonName")
+		cert.IssuerOrganization, _ = issuer.Get("organizationName")
+	}
+	if pubkey, ok := s.Table("pubkey"); ok {
+		cert.PublicKeyType, _ = pubkey.Get("type")
+		bits, _ := pubkey.Get("bits")
+		cert.PublicKeyBits, _ = strconv.Atoi(bits)
+	}
+	if validity, ok := s.Table("validity"); ok {
+		notBefore, _ := validity.Get("notBefore")
+		cert.NotBefore = parseScriptTime(notBefore)
+		notAfter, _ := validity.Get("notAfter")
+		cert.NotAfter = parseScriptTime(notAfter)
+	}
+	if extensions, ok := s.Table("extensions"); ok {
+		for _, ext := range extensions.Tables {
+			if name, _ := ext.Get("name"); name == subjectAltNameExtName {
+				value, _ := ext.Get("value")
+				cert.SubjectAltNames = parseSubjectAltNames(value)
+			}
+		}
+	}
+	cert.SignatureAlgorithm, _ = s.Get("sig_algo")
+	cert.SHA1, _ = s.Get("sha1")
+	cert.PEM, _ = s.Get("pem")
+	return cert, true
+}
+
+// HTTPTitle returns the page title reported by the http-title script.
+func (p *PortData) HTTPTitle() (string, bool) {
+	s, ok := p.Script(ScriptHTTPTitle)
+	if !ok {
+		return "", false
+	}
+	if title, ok := s.Get("title"); ok {
+		return title, true
+	}
+	// Pages without title have no structured output
+	return strings.TrimSpace(s.Output), true
+}
+
+// Banner returns the service banner reported by the banner script.
+func (p *PortData) Banner() (string, bool) {
+	s, ok := p.Script(ScriptBanner)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(s.Output), true
+}
+
+// SSHHostKey is a host key reported by the ssh-hostkey script.
+type SSHHostKey struct {
+	Type        string `json:"type"`
+	Bits        int    `json:"bits"`
+	Fingerprint string `json:"fingerprint"`
+	Key         string `json:"key"`
+}
+
+// SSHHostKeys returns the host keys reported by the ssh-hostkey script.
+func (p *PortData) SSHHostKeys() []SSHHostKey {
+	s, ok := p.Script(ScriptSSHHostKey)
+	if !ok {
+		return nil
+	}
+
+	keys := make([]SSHHostKey, 0, len(s.Tables))
+	for _, t := range s.Tables {
+		key := SSHHostKey{}
+		key.Type, _ = t.Get("type")
+		bits, _ := t.Get("bits")
+		key.Bits, _ = strconv.Atoi(bits)
+		key.Fingerprint, _ = t.Get("fingerprint")
+		key.Key, _ = t.Get("key")
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// SMBOSInfo is the operating system information reported by the
+// smb-os-discovery script.
+type SMBOSInfo struct {
+	OS           string `json:"os"`
+	LANManager   string `json:"lan_manager,omitempty"`
+	ComputerName string `json:"computer_name"`
+	FQDN         string `json:"fqdn,omitempty"`
+	Domain       string `json:"domain,omitempty"`
+	DomainDNS    string `json:"domain_dns,omitempty"`
+	ForestDNS    string `json:"forest_dns,omitempty"`
+	Workgroup    string `json:"workgroup,omitempty"`
+}
+
+// SMBOSDiscovery returns the operating system information reported by the
+// smb-os-discovery host script.
+func (r *NmapResult) SMBOSDiscovery() (*SMBOSInfo, bool) {
+	s, ok := r.HostScript(ScriptSMBOSDiscovery)
+	if !ok {
+		return nil, false
+	}
+
+	// NetBIOS names are reported with an escaped NUL terminator, e.g., "FILES\x00"
+	get := func(key string) string {
+		v, _ := s.Get(key)
+		return strings.TrimSuffix(v, `\x00`)
+	}
+	return &SMBOSInfo{
+		OS:           get("os"),
+		LANManager:   get("lanmanager"),
+		ComputerName: get("server"),
+		FQDN:         get("fqdn"),
+		Domain:       get("domain"),
+		DomainDNS:    get("domain_dns"),
+		ForestDNS:    get("forest_dns"),
+		Workgroup:    get("workgroup"),
+	}, true
+}
+
+// parseScriptTime parses the timestamps of NSE structured output, which may
+// omit the time zone.
+func parseScriptTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04:05-07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// parseSubjectAltNames parses the DNS names of a subjectAltName extension,
+// e.g., "DNS:example.com, DNS:www.example.com, IP Address:192.0.2.1".
+func parseSubjectAltNames(value string) []string {
+	var names []string
+	for _, entry := range strings.Split(value, ",") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(entry), "DNS:"); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NmapScripts(t *testing.T) {
+	res := parseNmapFixture(t, "scripts.xml")
+	require.Len(t, res.ScannedPorts, 5)
+	ftp, ssh, http, https, smb := &res.ScannedPorts[0], &res.ScannedPorts[1], &res.ScannedPorts[2], &res.ScannedPorts[3], &res.ScannedPorts[4]
+
+	banner, ok := ftp.Banner()
+	assert.True(t, ok)
+	assert.Equal(t, "220-FileZilla Server 1.7.3\n220 Please visit https://filezilla-project.org/", banner)
+
+	assert.Equal(t, []SSHHostKey{
+		{Type: "ssh-rsa", Bits: 3072, Fingerprint: "1c2b4e6f0a9d3e77c1528b21f0aa6d39", Key: "AAAAB3NzaC1yc2EAAAADAQABAAABgQDJ2vHqk4uR8Lz0"},
+		{Type: "ecdsa-sha2-nistp256", Bits: 256, Fingerprint: "7d19423a5ec8610fb2941d33e705c68a", Key: "AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTY"},
+		{Type: "ssh-ed25519", Bits: 256, Fingerprint: "a46ef10b2cd758934fe61a720cb93de4", Key: "AAAAC3NzaC1lZDI1NTE5AAAAIBk8pQx2d4r7Jm0"},
+	}, ssh.SSHHostKeys())
+
+	title, ok := http.HTTPTitle()
+	assert.True(t, ok)
+	assert.Equal(t, "IIS Windows Server", title)
+
+	// Pages without title only have a raw output
+	title, ok = https.HTTPTitle()
+	assert.True(t, ok)
+	assert.Equal(t, "Site doesn't have a title (text/html).", title)
+
+	cert, ok := https.TLSCertificate()
+	require.True(t, ok)
+	assert.Equal(t, "files.corp.example.com", cert.SubjectCommonName)
+	assert.Equal(t, "Example Corp", cert.IssuerOrganization)
+	assert.Equal(t, []string{"files.corp.example.com", "files"}, cert.SubjectAltNames)
+	assert.Equal(t, "rsa", cert.PublicKeyType)
+	assert.Equal(t, 2048, cert.PublicKeyBits)
+	assert.Equal(t, "sha256WithRSAEncryption", cert.SignatureAlgorithm)
+	assert.Equal(t, time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), cert.NotBefore)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), cert.NotAfter)
+	assert.Equal(t, "9f4e2b7c1a0d8e3f6b5a4c2d1e0f9a8b7c6d5e4f", cert.SHA1)
+	assert.Contains(t, cert.PEM, "-----BEGIN CERTIFICATE-----")
+	assert.True(t, cert.IsSelfSigned())
+	assert.True(t, cert.IsExpired(time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, cert.IsExpired(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)))
+
+	// Ports without scripts
+	assert.Empty(t, smb.Scripts)
+	_, ok = smb.TLSCertificate()
+	assert.False(t, ok)
+	_, ok = smb.Banner()
+	assert.False(t, ok)
+	assert.Nil(t, smb.SSHHostKeys())
+
+	info, ok := res.SMBOSDiscovery()
+	require.True(t, ok)
+	assert.Equal(t, &SMBOSInfo{
+		OS:           "Windows Server 2016 Standard 14393",
+		LANManager:   "Windows Server 2016 Standard 6.3",
+		ComputerName: "FILES",
+		FQDN:         "files.corp.example.com",
+		DomainDNS:    "corp.example.com",
+		ForestDNS:    "corp.example.com",
+		Workgroup:    "CORP",
+	}, info)
+
+	// Raw results stay available for scripts without accessors
+	date, ok := res.HostScripts[0].Get("date")
+	assert.True(t, ok)
+	assert.Equal(t, "2025-01-08T14:03:01+01:00", date)
+	_, ok = (&NmapResult{}).SMBOSDiscovery()
+	assert.False(t, ok)
+}
+
+func Test_NmapScriptsJSON(t *testing.T) {
+	res := parseNmapFixture(t, "scripts.xml")
+
+	data, err := json.Marshal(res)
+	require.NoError(t, err)
+
+	var decoded NmapResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, res.HostScripts, decoded.HostScripts)
+	assert.Equal(t, res.ScannedPorts[3].Scripts, decoded.ScannedPorts[3].Scripts)
+
+	cert, ok := decoded.ScannedPorts[3].TLSCertificate()
+	require.True(t, ok)
+	assert.Equal(t, "files.corp.example.com", cert.SubjectCommonName)
+}
+
+func Test_NmapVulnersScriptKept(t *testing.T) {
+	res := parseNmapFixture(t, "scanme.xml")
+
+	s, ok := res.ScannedPorts[0].Script(ScriptVulners)
+	require.True(t, ok)
+	cpe, ok := s.Table("cpe:/a:openbsd:openssh:6.6.1p1")
+	require.True(t, ok)
+	assert.Len(t, cpe.Tables, 6)
+	_, ok = cpe.Table("missing")
+	assert.False(t, ok)
+}
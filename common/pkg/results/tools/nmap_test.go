@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/kptm-tools/common/common/pkg/enums"
@@ -193,3 +194,71 @@ func TestGetSeverityPerTypeMap(t *testing.T) {
 		}
 	}
 }
+
+func Test_PortDataUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		name             string
+		input            string
+		expectedState    enums.PortState
+		expectedProtocol enums.PortProtocol
+		expectError      bool
+	}{
+		{
+			name:             "Current payload",
+			input:            `{"id":123,"protocol":"udp","state":"open|filtered"}`,
+			expectedState:    enums.PortStateOpenFiltered,
+			expectedProtocol: enums.PortProtocolUDP,
+		},
+		{
+			name:             "Mixed case payload",
+			input:            `{"id":22,"protocol":"TCP","state":"Open"}`,
+			expectedState:    enums.PortStateOpen,
+			expectedProtocol: enums.PortProtocolTCP,
+		},
+		{
+			name:             "Spaced combined state",
+			input:            `{"id":161,"protocol":"Udp","state":"Open | Filtered"}`,
+			expectedState:    enums.PortStateOpenFiltered,
+			expectedProtocol: enums.PortProtocolUDP,
+		},
+		{
+			name:             "Unknown values",
+			input:            `{"id":1,"protocol":"icmp","state":"weird"}`,
+			expectedState:    enums.PortStateUnknown,
+			expectedProtocol: enums.PortProtocolUnknown,
+		},
+		{
+			name:             "Missing values",
+			input:            `{"id":1,"protocol":null}`,
+			expectedState:    "",
+			expectedProtocol: enums.PortProtocolUnknown,
+		},
+		{
+			name:        "Non-string state",
+			input:       `{"id":1,"state":1}`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var port PortData
+			err := json.Unmarshal([]byte(tc.input), &port)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if port.State != tc.expectedState {
+				t.Errorf("expected state %q, got %q", tc.expectedState, port.State)
+			}
+			if port.Protocol != tc.expectedProtocol {
+				t.Errorf("expected protocol %q, got %q", tc.expectedProtocol, port.Protocol)
+			}
+		})
+	}
+}
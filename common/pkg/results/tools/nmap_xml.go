@@ -0,0 +1,364 @@
+package tools
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+)
+
+// ErrNoNmapHosts is returned when Nmap XML output contains no scanned host.
+var ErrNoNmapHosts = errors.New("nmap output contains no host")
+
+// nmapRun mirrors the subset of the `nmap -oX` output we consume.
+type nmapRun struct {
+	XMLName  xml.Name     `xml:"nmaprun"`
+	Args     string       `xml:"args,attr"`
+	Version  string       `xml:"version,attr"`
+	Start    int64        `xml:"start,attr"`
+	Hosts    []nmapHost   `xml:"host"`
+	RunStats nmapRunStats `xml:"runstats"`
+}
+
+type nmapRunStats struct {
+	Finished struct {
+		Time    int64   `xml:"time,attr"`
+		Elapsed float64 `xml:"elapsed,attr"`
+		Exit    string  `xml:"exit,attr"`
+	} `xml:"finished"`
+	Hosts struct {
+		Up    int `xml:"up,attr"`
+		Down  int `xml:"down,attr"`
+		Total int `xml:"total,attr"`
+	} `xml:"hosts"`
+}
+
+type nmapHost struct {
+	StartTime   int64          `xml:"starttime,attr"`
+	EndTime     int64          `xml:"endtime,attr"`
+	Status      nmapStatus     `xml:"status"`
+	Addresses   []nmapAddress  `xml:"address"`
+	Hostnames   []nmapHostname `xml:"hostnames>hostname"`
+	Ports       []nmapPort     `xml:"ports>port"`
+	OS          nmapOS         `xml:"os"`
+	HostScripts []nmapScript   `xml:"hostscript>script"`
+}
+
+type nmapStatus struct {
+	State  string `xml:"state,attr"`
+	Reason string `xml:"reason,attr"`
+}
+
+type nmapAddress struct {
+	Addr     string `xml:"addr,attr"`
+	AddrType string `xml:"addrtype,attr"`
+}
+
+type nmapHostname struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type nmapPort struct {
+	Protocol string       `xml:"protocol,attr"`
+	PortID   uint16       `xml:"portid,attr"`
+	State    nmapStatus   `xml:"state"`
+	Service  nmapService  `xml:"service"`
+	Scripts  []nmapScript `xml:"script"`
+}
+
+type nmapService struct {
+	Name      string   `xml:"name,attr"`
+	Product   string   `xml:"product,attr"`
+	Version   string   `xml:"version,attr"`
+	ExtraInfo string   `xml:"extrainfo,attr"`
+	Conf      int      `xml:"conf,attr"`
+	CPEs      []string `xml:"cpe"`
+}
+
+type nmapScript struct {
+	ID     string      `xml:"id,attr"`
+	Output string      `xml:"output,attr"`
+	Tables []nmapTable `xml:"table"`
+	Elems  []nmapElem  `xml:"elem"`
+}
+
+type nmapTable struct {
+	Key    string      `xml:"key,attr"`
+	Tables []nmapTable `xml:"table"`
+	Elems  []nmapElem  `xml:"elem"`
+}
+
+type nmapElem struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type nmapOS struct {
+	Matches      []nmapOSMatch       `xml:"osmatch"`
+	Fingerprints []nmapOSFingerprint `xml:"osfingerprint"`
+}
+
+type nmapOSMatch struct {
+	Name     string        `xml:"name,attr"`
+	Accuracy int           `xml:"accuracy,attr"`
+	Classes  []nmapOSClass `xml:"osclass"`
+}
+
+type nmapOSClass struct {
+	Type     string   `xml:"type,attr"`
+	Vendor   string   `xml:"vendor,attr"`
+	OSFamily string   `xml:"osfamily,attr"`
+	Accuracy int      `xml:"accuracy,attr"`
+	CPEs     []string `xml:"cpe"`
+}
+
+type nmapOSFingerprint struct {
+	Fingerprint string `xml:"fingerprint,attr"`
+}
+
+// ParseNmapXML parses `nmap -oX` output into an NmapResult.
+//
+// The result describes the first host reported up, or the first host when none
+// is up. Use ParseNmapXMLScan for scans of several hosts.
+func ParseNmapXML(r io.Reader) (*NmapResult, error) {
+	scan, err := ParseNmapXMLScan(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(scan.Hosts) == 0 {
+		return nil, ErrNoNmapHosts
+	}
+
+	host := scan.Hosts[0]
+	for _, h := range scan.Hosts {
+		if h.IsUp() {
+			host = h
+			break
+		}
+	}
+	return host.ToNmapResult(), nil
+}
+
+// ParseNmapXMLScan parses `nmap -oX` output into an NmapScanResult holding
+// every scanned host, including the ones that are down. Vulnerabilities
+// reported by the vulners NSE script are attached to the port they were found on.
+func ParseNmapXMLScan(r io.Reader) (*NmapScanResult, error) {
+	var run nmapRun
+	if err := xml.NewDecoder(r).Decode(&run); err != nil {
+		return nil, fmt.Errorf("failed to decode nmap XML: %w", err)
+	}
+
+	scan := &NmapScanResult{
+		Args:       run.Args,
+		Version:    run.Version,
+		StartedAt:  unixTime(run.Start),
+		FinishedAt: unixTime(run.RunStats.Finished.Time),
+		Elapsed:    time.Duration(run.RunStats.Finished.Elapsed * float64(time.Second)),
+		HostsUp:    run.RunStats.Hosts.Up,
+		HostsDown:  run.RunStats.Hosts.Down,
+		HostsTotal: run.RunStats.Hosts.Total,
+		Hosts:      make([]NmapHost, 0, len(run.Hosts)),
+	}
+	for _, h := range run.Hosts {
+		scan.Hosts = append(scan.Hosts, h.toNmapHost())
+	}
+	return scan, nil
+}
+
+func (h nmapHost) toNmapHost() NmapHost {
+	host := NmapHost{
+		HostName:    h.hostName(),
+		HostAddress: h.address(),
+		State:       enums.ParseHostState(h.Status.State, enums.HostStateUnknown),
+		Reason:      h.Status.Reason,
+		StartedAt:   unixTime(h.StartTime),
+		FinishedAt:  unixTime(h.EndTime),
+		Ports:       make([]PortData, 0, len(h.Ports)),
+		OSMatches:   h.OS.matches(),
+	}
+	for _, p := range h.Ports {
+		host.Ports = append(host.Ports, p.toPortData())
+	}
+	for _, s := range h.HostScripts {
+		host.HostScripts = append(host.HostScripts, s.toScriptResult())
+	}
+	if len(h.OS.Fingerprints) > 0 {
+		host.OSFingerprint = h.OS.Fingerprints[0].Fingerprint
+	}
+	return host
+}
+
+// unixTime converts the Unix timestamps of Nmap output, leaving missing ones zero.
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
+
+// address returns the IPv4 or IPv6 address of the host, ignoring MAC addresses.
+func (h nmapHost) address() string {
+	for _, a := range h.Addresses {
+		if a.AddrType == "ipv4" || a.AddrType == "ipv6" {
+			return a.Addr
+		}
+	}
+	return ""
+}
+
+// hostName returns the hostname given on the command line, falling back to
+// the first resolved one.
+func (h nmapHost) hostName() string {
+	for _, hn := range h.Hostnames {
+		if hn.Type == "user" {
+			return hn.Name
+		}
+	}
+	if len(h.Hostnames) > 0 {
+		return h.Hostnames[0].Name
+	}
+	return ""
+}
+
+func (p nmapPort) toPortData() PortData {
+	port := PortData{
+		ID:       p.PortID,
+		Protocol: enums.ParsePortProtocol(p.Protocol, enums.PortProtocolUnknown),
+		Product:  p.Service.Product,
+		State:    enums.ParsePortState(p.State.State, enums.PortStateUnknown),
+		Service: Service{
+			Name:       p.Service.Name,
+			Version:    p.Service.Version,
+			Confidence: p.Service.Conf,
+		},
+	}
+	if len(p.Service.CPEs) > 0 {
+		port.Service.CPE = p.Service.CPEs[0]
+	}
+
+	for _, s := range p.Scripts {
+		port.Scripts = append(port.Scripts, s.toScriptResult())
+		if s.ID == ScriptVulners {
+			port.Vulnerabilities = append(port.Vulnerabilities, s.vulnerabilities()...)
+		}
+	}
+	return port
+}
+
+func (s nmapScript) toScriptResult() ScriptResult {
+	return ScriptResult{
+		ID:     s.ID,
+		Output: s.Output,
+		Elems:  toScriptElems(s.Elems),
+		Tables: toScriptTables(s.Tables),
+	}
+}
+
+func toScriptElems(elems []nmapElem) []ScriptElem {
+	if len(elems) == 0 {
+		return nil
+	}
+	res := make([]ScriptElem, 0, len(elems))
+	for _, e := range elems {
+		res = append(res, ScriptElem{Key: e.Key, Value: e.Value})
+	}
+	return res
+}
+
+func toScriptTables(tables []nmapTable) []ScriptTable {
+	if len(tables) == 0 {
+		return nil
+	}
+	res := make([]ScriptTable, 0, len(tables))
+	for _, t := range tables {
+		res = append(res, ScriptTable{
+			Key:    t.Key,
+			Elems:  toScriptElems(t.Elems),
+			Tables: toScriptTables(t.Tables),
+		})
+	}
+	return res
+}
+
+// matches returns the OS matches, most accurate first.
+func (o nmapOS) matches() []OSData {
+	matches := make([]OSData, 0, len(o.Matches))
+	for _, m := range o.Matches {
+		osData := OSData{
+			Name:     m.Name,
+			Accuracy: m.Accuracy,
+		}
+		if len(m.Classes) > 0 {
+			class := m.Classes[0]
+			osData.Family = class.OSFamily
+			osData.Type = class.Type
+			if len(class.CPEs) > 0 {
+				osData.CPE = class.CPEs[0]
+			}
+		}
+		matches = append(matches, osData)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Accuracy > matches[j].Accuracy
+	})
+	return matches
+}
+
+// vulnerabilities converts the vulners script output into vulnerabilities.
+//
+// The script groups its entries by CPE:
+//
+//	<table key="cpe:/a:openbsd:openssh:6.6.1p1">
+//	  <table>
+//	    <elem key="id">CVE-2015-5600</elem>
+//	    <elem key="type">cve</elem>
+//	    <elem key="cvss">8.5</elem>
+//	    <elem key="is_exploit">false</elem>
+//	  </table>
+//	</table>
+//
+// Only CVE entries are converted; the other entry types (exploitdb,
+// githubexploit, ...) reference exploits rather than vulnerabilities. A CVE
+// listed under several CPEs is returned once.
+func (s nmapScript) vulnerabilities() []Vulnerability {
+	var vulns []Vulnerability
+	seen := make(map[string]bool)
+
+	for _, cpe := range s.Tables {
+		for _, entry := range cpe.Tables {
+			fields := make(map[string]string, len(entry.Elems))
+			for _, e := range entry.Elems {
+				fields[e.Key] = strings.TrimSpace(e.Value)
+			}
+
+			id := fields["id"]
+			if !strings.EqualFold(fields["type"], "cve") || id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			cvss, _ := strconv.ParseFloat(fields["cvss"], 64)
+			vuln := Vulnerability{
+				CveID:         id,
+				Type:          enums.OwaspCategoryVulnerableAndOutdatedComponents,
+				BaseCVSSScore: cvss,
+				BaseSeverity:  MapCVSS(cvss),
+				References:    []string{fmt.Sprintf("https://vulners.com/%s/%s", strings.ToLower(fields["type"]), id)},
+				Exploit:       Exploit{Exploitability: enums.ExploitabilityTypeNotDefined},
+			}
+			if fields["is_exploit"] == "true" {
+				vuln.Exploit.Exploitability = enums.ExploitabilityTypeProofOfConcept
+			}
+			vulns = append(vulns, vuln)
+		}
+	}
+	return vulns
+}
@@ -0,0 +1,294 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func parseNmapFixture(t *testing.T, name string) *NmapResult {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", "nmap", name))
+	require.NoError(t, err)
+	defer f.Close()
+
+	res, err := ParseNmapXML(f)
+	require.NoError(t, err)
+	return res
+}
+
+func Test_ParseNmapXML(t *testing.T) {
+	res := parseNmapFixture(t, "scanme.xml")
+
+	assert.Equal(t, "scanme.nmap.org", res.HostName)
+	assert.Equal(t, "45.33.32.156", res.HostAddress)
+	require.Len(t, res.ScannedPorts, 5)
+
+	ssh := res.ScannedPorts[0]
+	assert.Equal(t, uint16(22), ssh.ID)
+	assert.Equal(t, enums.PortProtocolTCP, ssh.Protocol)
+	assert.Equal(t, enums.PortStateOpen, ssh.State)
+	assert.Equal(t, "OpenSSH", ssh.Product)
+	assert.Equal(t, Service{
+		Name:       "ssh",
+		Version:    "6.6.1p1 Ubuntu 2ubuntu2.13",
+		Confidence: 10,
+		CPE:        "cpe:/a:openbsd:openssh:6.6.1p1",
+	}, ssh.Service)
+
+	assert.Equal(t, enums.PortStateFiltered, res.ScannedPorts[4].State)
+	assert.Len(t, res.GetOpenPorts(), 4)
+
+	assert.Equal(t, OSData{
+		Name:        "Linux 5.0 - 5.4",
+		Accuracy:    95,
+		Family:      "Linux",
+		Type:        "general purpose",
+		CPE:         "cpe:/o:linux:linux_kernel:5",
+		FingerPrint: "OS:SCAN(V=7.94SVN%E=4%D=1/6%OT=22%CT=1%CU=42813%PV=N%DS=11%DC=I%G=Y%TM=677B\nOS:AB0F%P=x86_64-pc-linux-gnu)",
+	}, res.MostLikelyOS)
+}
+
+func Test_ParseNmapXMLVulners(t *testing.T) {
+	res := parseNmapFixture(t, "scanme.xml")
+
+	// Exploit entries are not vulnerabilities
+	sshVulns := res.ScannedPorts[0].Vulnerabilities
+	require.Len(t, sshVulns, 5)
+	for _, v := range sshVulns {
+		assert.True(t, strings.HasPrefix(v.CveID, "CVE-"), v.CveID)
+		assert.Equal(t, enums.OwaspCategoryVulnerableAndOutdatedComponents, v.Type)
+	}
+
+	first := sshVulns[0]
+	assert.Equal(t, "CVE-2015-5600", first.CveID)
+	assert.Equal(t, 8.5, first.BaseCVSSScore)
+	assert.Equal(t, enums.SeverityTypeHigh, first.BaseSeverity)
+	assert.Equal(t, []string{"https://vulners.com/cve/CVE-2015-5600"}, first.References)
+	assert.Equal(t, enums.ExploitabilityTypeNotDefined, first.Exploit.Exploitability)
+	assert.Equal(t, enums.ExploitabilityTypeProofOfConcept, sshVulns[1].Exploit.Exploitability)
+
+	assert.Empty(t, res.ScannedPorts[2].Vulnerabilities)
+	assert.Equal(t, 8, res.TotalVulnerabilities())
+	assert.Equal(t, SeverityCounts{Critical: 3, High: 4, Medium: 1}, GetSeverityCounts(res.GetAllVulnerabilities()))
+}
+
+func Test_ParseNmapXMLHostSelection(t *testing.T) {
+	res := parseNmapFixture(t, "host_down.xml")
+
+	// The down host is skipped, MAC addresses are ignored and PTR names are used
+	assert.Equal(t, "192.0.2.11", res.HostAddress)
+	assert.Equal(t, "db.internal.example", res.HostName)
+	require.Len(t, res.ScannedPorts, 1)
+	assert.Equal(t, "postgresql", res.ScannedPorts[0].Service.Name)
+	assert.Equal(t, OSData{}, res.MostLikelyOS)
+}
+
+func Test_ParseNmapXMLErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "Malformed XML",
+			input: `<nmaprun><host>`,
+		},
+		{
+			name:  "Not nmap output",
+			input: `<scan></scan>`,
+		},
+		{
+			name:  "No hosts",
+			input: `<nmaprun scanner="nmap"><runstats/></nmaprun>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := ParseNmapXML(strings.NewReader(tc.input))
+			assert.Error(t, err)
+			assert.Nil(t, res)
+		})
+	}
+
+	_, err := ParseNmapXML(strings.NewReader(`<nmaprun></nmaprun>`))
+	assert.ErrorIs(t, err, ErrNoNmapHosts)
+}
+
+func Test_ParseNmapXMLScan(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "nmap", "subnet.xml"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	scan, err := ParseNmapXMLScan(f)
+	require.NoError(t, err)
+
+	assert.Equal(t, "nmap -v -sV -O -oX subnet.xml 192.168.56.0/29", scan.Args)
+	assert.Equal(t, "7.94SVN", scan.Version)
+	assert.Equal(t, time.Unix(1736242200, 0).UTC(), scan.StartedAt)
+	assert.Equal(t, time.Unix(1736242241, 0).UTC(), scan.FinishedAt)
+	assert.Equal(t, 41370*time.Millisecond, scan.Elapsed)
+	assert.Equal(t, 2, scan.HostsUp)
+	assert.Equal(t, 6, scan.HostsDown)
+	assert.Equal(t, 8, scan.HostsTotal)
+
+	require.Len(t, scan.Hosts, 4)
+	down := scan.Hosts[0]
+	assert.Equal(t, "192.168.56.0", down.HostAddress)
+	assert.Equal(t, enums.HostStateDown, down.State)
+	assert.False(t, down.IsUp())
+	assert.Equal(t, "no-response", down.Reason)
+	assert.Empty(t, down.Ports)
+	assert.True(t, down.StartedAt.IsZero())
+
+	gateway := scan.Hosts[1]
+	assert.Equal(t, enums.HostStateUp, gateway.State)
+	assert.True(t, gateway.IsUp())
+	assert.Equal(t, "arp-response", gateway.Reason)
+	assert.Equal(t, "gateway.lab", gateway.HostName)
+	assert.Len(t, gateway.Ports, 2)
+	assert.NotEmpty(t, gateway.OSFingerprint)
+
+	// OS guesses are sorted by accuracy
+	windows := scan.Hosts[3]
+	require.Len(t, windows.OSMatches, 2)
+	assert.Equal(t, "Microsoft Windows Server 2019", windows.OSMatches[0].Name)
+	assert.Equal(t, 97, windows.OSMatches[0].Accuracy)
+	assert.Equal(t, "Microsoft Windows 10 1709 - 21H2", windows.OSMatches[1].Name)
+}
+
+func Test_NmapScanResultSplit(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "nmap", "subnet.xml"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	scan, err := ParseNmapXMLScan(f)
+	require.NoError(t, err)
+
+	results := scan.Split()
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "gateway.lab", results[0].HostName)
+	assert.Equal(t, "192.168.56.1", results[0].HostAddress)
+	assert.Len(t, results[0].ScannedPorts, 2)
+	assert.Equal(t, "OpenWrt 21.02 (Linux 5.4)", results[0].MostLikelyOS.Name)
+	assert.Equal(t, scan.Hosts[1].OSFingerprint, results[0].MostLikelyOS.FingerPrint)
+
+	assert.Equal(t, "192.168.56.5", results[1].HostAddress)
+	assert.Equal(t, OSData{
+		Name:     "Microsoft Windows Server 2019",
+		Accuracy: 97,
+		Family:   "Windows",
+		Type:     "general purpose",
+		CPE:      "cpe:/o:microsoft:windows_server_2019",
+	}, results[1].MostLikelyOS)
+
+	assert.Empty(t, (&NmapScanResult{}).Split())
+}
+
+func Test_NmapScanResultJSON(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "nmap", "subnet.xml"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	scan, err := ParseNmapXMLScan(f)
+	require.NoError(t, err)
+	assert.Equal(t, enums.ToolNmapScan, scan.GetToolName())
+
+	var decoded NmapScanResult
+	require.NoError(t, json.Unmarshal([]byte(scan.ToJSON()), &decoded))
+	assert.Equal(t, scan.Hosts[1].State, decoded.Hosts[1].State)
+	assert.Len(t, decoded.UpHosts(), 2)
+
+	// Host states are decoded regardless of case
+	var host NmapHost
+	require.NoError(t, json.Unmarshal([]byte(`{"state":"Up"}`), &host))
+	assert.True(t, host.IsUp())
+	require.NoError(t, json.Unmarshal([]byte(`{"state":"asleep"}`), &host))
+	assert.Equal(t, enums.HostStateUnknown, host.State)
+	assert.Error(t, json.Unmarshal([]byte(`{"state":1}`), &host))
+}
+
+func Test_ParseNmapXMLIPv6UDP(t *testing.T) {
+	res := parseNmapFixture(t, "ipv6_udp.xml")
+
+	assert.Equal(t, "2001:db8:85a3::8a2e:370:7334", res.HostAddress)
+	assert.True(t, res.IsIPv6())
+	assert.False(t, (&NmapResult{HostAddress: "192.0.2.1"}).IsIPv6())
+
+	portIDs := func(ports []PortData) []uint16 {
+		var ids []uint16
+		for _, p := range ports {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []uint16{22, 53}, portIDs(res.GetOpenPorts()))
+	assert.Equal(t, []uint16{22, 53, 123}, portIDs(res.GetPossiblyOpenPorts()))
+	assert.Equal(t, []uint16{53, 123, 161}, portIDs(res.GetPortsByProtocol(enums.PortProtocolUDP)))
+	assert.Equal(t, []uint16{53, 123}, portIDs(res.GetPortsByProtocol(enums.PortProtocolUDP, enums.PortStateOpen, enums.PortStateOpenFiltered)))
+	assert.Equal(t, []uint16{80, 161}, portIDs(res.GetPortsByState(enums.PortStateClosed, enums.PortStateFiltered)))
+	assert.Empty(t, res.GetPortsByProtocol(enums.PortProtocolSCTP))
+}
+
+func Test_NmapScanResultToolResult(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "nmap", "subnet.xml"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	scan, err := ParseNmapXMLScan(f)
+	require.NoError(t, err)
+	published := ToolResult{Tool: scan.GetToolName(), Result: scan}
+
+	codecs := map[string]struct {
+		marshal   func(v any) ([]byte, error)
+		unmarshal func(data []byte, v any) error
+	}{
+		"JSON": {marshal: json.Marshal, unmarshal: json.Unmarshal},
+		"Msgpack": {
+			marshal: func(v any) ([]byte, error) {
+				var buf bytes.Buffer
+				enc := msgpack.NewEncoder(&buf)
+				enc.SetCustomStructTag("json")
+				err := enc.Encode(v)
+				return buf.Bytes(), err
+			},
+			unmarshal: func(data []byte, v any) error {
+				dec := msgpack.NewDecoder(bytes.NewReader(data))
+				dec.SetCustomStructTag("json")
+				return dec.Decode(v)
+			},
+		},
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			data, err := codec.marshal(&published)
+			require.NoError(t, err)
+
+			var received ToolResult
+			require.NoError(t, codec.unmarshal(data, &received))
+			assert.Equal(t, enums.ToolNmapScan, received.Tool)
+
+			// Every host is kept, not only a single-host NmapResult
+			got, ok := received.Result.(*NmapScanResult)
+			require.True(t, ok, "decoded as %T", received.Result)
+			require.Len(t, got.Hosts, len(scan.Hosts))
+			assert.Equal(t, scan.Args, got.Args)
+			assert.Equal(t, scan.Hosts[1].HostAddress, got.Hosts[1].HostAddress)
+			assert.Equal(t, scan.Hosts[1].Ports, got.Hosts[1].Ports)
+			assert.Len(t, got.Split(), 2)
+		})
+	}
+}
@@ -0,0 +1,486 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RDAP object classes, see RFC 9083.
+const (
+	RDAPObjectDomain    = "domain"
+	RDAPObjectIPNetwork = "ip network"
+)
+
+// RDAP entity roles.
+const (
+	RDAPRoleRegistrant     = "registrant"
+	RDAPRoleRegistrar      = "registrar"
+	RDAPRoleAbuse          = "abuse"
+	RDAPRoleAdministrative = "administrative"
+	RDAPRoleTechnical      = "technical"
+	RDAPRoleBilling        = "billing"
+)
+
+// RDAP event actions.
+const (
+	RDAPEventRegistration = "registration"
+	RDAPEventExpiration   = "expiration"
+	RDAPEventLastChanged  = "last changed"
+)
+
+// RDAPResult is an RDAP domain or IP network object.
+type RDAPResult struct {
+	ObjectClass string `json:"object_class"`
+	Handle      string `json:"handle,omitempty"`
+	// Name is the LDH name of a domain or the name of an IP network.
+	Name     string       `json:"name,omitempty"`
+	Status   []string     `json:"status,omitempty"`
+	Events   []RDAPEvent  `json:"events,omitempty"`
+	Entities []RDAPEntity `json:"entities,omitempty"`
+	// NameServers and DelegationSigned are only set for domains.
+	NameServers      []string `json:"name_servers,omitempty"`
+	DelegationSigned bool     `json:"delegation_signed,omitempty"`
+	// Network is only set for IP networks.
+	Network *RDAPNetwork `json:"network,omitempty"`
+	// Redacted are the names of the fields redacted by the server, see RFC 9537.
+	Redacted []string `json:"redacted,omitempty"`
+	Port43   string   `json:"port43,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// RDAPEvent is a dated event in the life of an RDAP object.
+type RDAPEvent struct {
+	Action string    `json:"action"`
+	Date   time.Time `json:"date"`
+	Actor  string    `json:"actor,omitempty"`
+}
+
+// RDAPEntity is a contact of an RDAP object, flattened from its vCard.
+type RDAPEntity struct {
+	Handle string   `json:"handle,omitempty"`
+	Roles  []string `json:"roles"`
+	// Kind is the vCard kind, e.g., "individual" or "org".
+	Kind         string `json:"kind,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Street       string `json:"street,omitempty"`
+	City         string `json:"city,omitempty"`
+	Province     string `json:"province,omitempty"`
+	PostalCode   string `json:"postal_code,omitempty"`
+	Country      string `json:"country,omitempty"`
+	URL          string `json:"url,omitempty"`
+	IANAID       string `json:"iana_id,omitempty"`
+	// Entities are the contacts of the entity, e.g., the abuse contact of a registrar.
+	Entities []RDAPEntity `json:"entities,omitempty"`
+}
+
+// RDAPNetwork is the address range of an RDAP IP network.
+type RDAPNetwork struct {
+	StartAddress string   `json:"start_address"`
+	EndAddress   string   `json:"end_address"`
+	IPVersion    string   `json:"ip_version,omitempty"`
+	Type         string   `json:"type,omitempty"`
+	Country      string   `json:"country,omitempty"`
+	ParentHandle string   `json:"parent_handle,omitempty"`
+	CIDRs        []string `json:"cidrs,omitempty"`
+	// OriginASNs are the autonomous systems announcing the network, only
+	// given by ARIN.
+	OriginASNs []uint32 `json:"origin_asns,omitempty"`
+}
+
+// RDAPError is the error response of an RDAP server.
+type RDAPError struct {
+	Code        int
+	Title       string
+	Description []string
+}
+
+func (e *RDAPError) Error() string {
+	msg := fmt.Sprintf("RDAP error %d", e.Code)
+	if e.Title != "" {
+		msg += ": " + e.Title
+	}
+	if len(e.Description) > 0 {
+		msg += ": " + strings.Join(e.Description, " ")
+	}
+	return msg
+}
+
+// HasRole reports whether the entity has the role.
+func (e *RDAPEntity) HasRole(role string) bool {
+	return slices.Contains(e.Roles, role)
+}
+
+// Entity returns the first entity with the role, searching nested entities
+// depth first, or nil when there is none.
+func (r *RDAPResult) Entity(role string) *RDAPEntity {
+	return findRDAPEntity(r.Entities, role)
+}
+
+func findRDAPEntity(entities []RDAPEntity, role string) *RDAPEntity {
+	for i := range entities {
+		if entities[i].HasRole(role) {
+			return &entities[i]
+		}
+		if e := findRDAPEntity(entities[i].Entities, role); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// EventDate returns the date of the first event with the action, or the
+// zero time when there is none.
+func (r *RDAPResult) EventDate(action string) time.Time {
+	for _, e := range r.Events {
+		if e.Action == action {
+			return e.Date
+		}
+	}
+	return time.Time{}
+}
+
+// SensitiveFields returns the personal data of the entities, to be encrypted
+// before the result leaves the service. Registrar and abuse contacts are not
+// personal data and are left out.
+func (r *RDAPResult) SensitiveFields() []*string {
+	var fields []*string
+	var walk func(entities []RDAPEntity)
+	walk = func(entities []RDAPEntity) {
+		for i := range entities {
+			e := &entities[i]
+			if !e.HasRole(RDAPRoleRegistrar) && !e.HasRole(RDAPRoleAbuse) {
+				fields = append(fields, &e.Handle, &e.Name, &e.Organization, &e.Email, &e.Phone, &e.Street, &e.City, &e.PostalCode)
+			}
+			walk(e.Entities)
+		}
+	}
+	walk(r.Entities)
+	return fields
+}
+
+// Registration returns the normalized registration data of an RDAP domain,
+// or nil for other objects.
+func (r *RDAPResult) Registration() *DomainRegistration {
+	if r.ObjectClass != RDAPObjectDomain {
+		return nil
+	}
+
+	reg := &DomainRegistration{
+		Domain:      normalizeDNSName(r.Name),
+		WhoisServer: r.Port43,
+		DNSSEC:      r.DelegationSigned,
+		CreatedAt:   r.EventDate(RDAPEventRegistration),
+		UpdatedAt:   r.EventDate(RDAPEventLastChanged),
+		ExpiresAt:   r.EventDate(RDAPEventExpiration),
+		Redacted:    len(r.Redacted) > 0,
+		Sources:     []string{RegistrationSourceRDAP},
+	}
+	for _, ns := range r.NameServers {
+		reg.NameServers = append(reg.NameServers, normalizeDNSName(ns))
+	}
+	for _, status := range r.Status {
+		if status = eppStatus(status); status != "" && !reg.HasStatus(status) {
+			reg.Statuses = append(reg.Statuses, status)
+		}
+	}
+	reg.updateLocks()
+
+	if registrar := r.Entity(RDAPRoleRegistrar); registrar != nil {
+		reg.Registrar = registrar.Name
+		if reg.Registrar == "" {
+			reg.Registrar = registrar.Organization
+		}
+		reg.RegistrarIANAID = registrar.IANAID
+		reg.RegistrarURL = registrar.URL
+	}
+	if abuse := r.Entity(RDAPRoleAbuse); abuse != nil {
+		reg.AbuseContact = AbuseContact{Email: abuse.Email, Phone: abuse.Phone}
+	}
+	if registrant := r.Entity(RDAPRoleRegistrant); registrant != nil {
+		redacted, privacyService, exposed := classifyRegistrant(
+			[]string{registrant.Name, registrant.Organization},
+			[]string{registrant.Name, registrant.Street, registrant.City, registrant.PostalCode, registrant.Phone, registrant.Email},
+		)
+		reg.Redacted = reg.Redacted || redacted
+		reg.PrivacyService, reg.RegistrantExposed = privacyService, exposed
+	}
+	return reg
+}
+
+// eppStatus converts an RDAP status to its EPP status code, e.g., "client
+// transfer prohibited" to "clientTransferProhibited", see RFC 8056.
+func eppStatus(status string) string {
+	words := strings.Fields(strings.ToLower(status))
+	if len(words) == 1 && words[0] == "active" {
+		return "ok"
+	}
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return strings.Join(words, "")
+}
+
+// rdapObject mirrors the subset of an RDAP response we consume, see RFC 9083.
+type rdapObject struct {
+	ObjectClassName string       `json:"objectClassName"`
+	Handle          string       `json:"handle"`
+	LDHName         string       `json:"ldhName"`
+	Name            string       `json:"name"`
+	Status          []string     `json:"status"`
+	Events          []rdapEvent  `json:"events"`
+	Entities        []rdapEntity `json:"entities"`
+	Port43          string       `json:"port43"`
+
+	// Domain members
+	NameServers []struct {
+		LDHName string `json:"ldhName"`
+	} `json:"nameservers"`
+	SecureDNS struct {
+		DelegationSigned bool `json:"delegationSigned"`
+	} `json:"secureDNS"`
+	Redacted []struct {
+		Name struct {
+			Type        string `json:"type"`
+			Description string `json:"description"`
+		} `json:"name"`
+	} `json:"redacted"`
+
+	// IP network members
+	StartAddress string `json:"startAddress"`
+	EndAddress   string `json:"endAddress"`
+	IPVersion    string `json:"ipVersion"`
+	Type         string `json:"type"`
+	Country      string `json:"country"`
+	ParentHandle string `json:"parentHandle"`
+	CIDRs        []struct {
+		V4Prefix string `json:"v4prefix"`
+		V6Prefix string `json:"v6prefix"`
+		Length   int    `json:"length"`
+	} `json:"cidr0_cidrs"`
+	OriginASNs []uint32 `json:"arin_originas0_originautnums"`
+
+	// Error response members
+	ErrorCode   int      `json:"errorCode"`
+	Title       string   `json:"title"`
+	Description []string `json:"description"`
+}
+
+type rdapEvent struct {
+	EventAction string    `json:"eventAction"`
+	EventDate   time.Time `json:"eventDate"`
+	EventActor  string    `json:"eventActor"`
+}
+
+type rdapEntity struct {
+	Handle    string       `json:"handle"`
+	Roles     []string     `json:"roles"`
+	VCard     rdapVCard    `json:"vcardArray"`
+	Entities  []rdapEntity `json:"entities"`
+	PublicIDs []struct {
+		Type       string `json:"type"`
+		Identifier string `json:"identifier"`
+	} `json:"publicIds"`
+}
+
+// rdapVCard holds the properties of a jCard, see RFC 7095, by name.
+type rdapVCard map[string]rdapVCardProperty
+
+type rdapVCardProperty struct {
+	Params map[string]any
+	Value  json.RawMessage
+}
+
+func (v *rdapVCard) UnmarshalJSON(data []byte) error {
+	var card []json.RawMessage
+	if err := json.Unmarshal(data, &card); err != nil {
+		return err
+	}
+	// Registries send null or empty jCards for redacted entities
+	if len(card) == 0 {
+		*v = nil
+		return nil
+	}
+	if len(card) != 2 {
+		return fmt.Errorf("invalid jCard of %d members", len(card))
+	}
+
+	var props [][]json.RawMessage
+	if err := json.Unmarshal(card[1], &props); err != nil {
+		return err
+	}
+	*v = rdapVCard{}
+	for _, prop := range props {
+		if len(prop) < 4 {
+			continue
+		}
+		var name string
+		var params map[string]any
+		if err := json.Unmarshal(prop[0], &name); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(prop[1], &params); err != nil {
+			return err
+		}
+		// Keep the first of repeated properties
+		if _, ok := (*v)[name]; !ok {
+			(*v)[name] = rdapVCardProperty{Params: params, Value: prop[3]}
+		}
+	}
+	return nil
+}
+
+// text returns the value of the property, joining structured values.
+func (v rdapVCard) text(name string) string {
+	prop, ok := v[name]
+	if !ok {
+		return ""
+	}
+	return strings.Join(flattenJCardValue(prop.Value), " ")
+}
+
+func flattenJCardValue(raw json.RawMessage) []string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if s = strings.TrimSpace(s); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil
+	}
+	var flat []string
+	for _, value := range values {
+		flat = append(flat, flattenJCardValue(value)...)
+	}
+	return flat
+}
+
+// address returns the components of the structured address, i.e., post
+// office box, extended address, street, locality, region, postal code and
+// country name.
+func (v rdapVCard) address() [7]string {
+	var adr [7]string
+	var values []json.RawMessage
+	if err := json.Unmarshal(v["adr"].Value, &values); err != nil {
+		return adr
+	}
+	for i := 0; i < len(values) && i < len(adr); i++ {
+		adr[i] = strings.Join(flattenJCardValue(values[i]), ", ")
+	}
+	return adr
+}
+
+// param returns the string parameter of the property.
+func (v rdapVCard) param(name, param string) string {
+	s, _ := v[name].Params[param].(string)
+	return s
+}
+
+// ParseRDAP parses an RDAP domain or IP network response. Error responses
+// are returned as an *RDAPError.
+func ParseRDAP(r io.Reader) (*RDAPResult, error) {
+	var obj rdapObject
+	if err := json.NewDecoder(r).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("failed to decode RDAP response: %w", err)
+	}
+	if obj.ErrorCode != 0 {
+		return nil, &RDAPError{Code: obj.ErrorCode, Title: obj.Title, Description: obj.Description}
+	}
+
+	res := &RDAPResult{
+		ObjectClass: obj.ObjectClassName,
+		Handle:      obj.Handle,
+		Status:      obj.Status,
+		Entities:    toRDAPEntities(obj.Entities),
+		Port43:      obj.Port43,
+	}
+	for _, e := range obj.Events {
+		res.Events = append(res.Events, RDAPEvent{Action: e.EventAction, Date: e.EventDate.UTC(), Actor: e.EventActor})
+	}
+
+	switch obj.ObjectClassName {
+	case RDAPObjectDomain:
+		res.Name = obj.LDHName
+		for _, ns := range obj.NameServers {
+			res.NameServers = append(res.NameServers, ns.LDHName)
+		}
+		res.DelegationSigned = obj.SecureDNS.DelegationSigned
+		for _, field := range obj.Redacted {
+			name := field.Name.Type
+			if name == "" {
+				name = field.Name.Description
+			}
+			res.Redacted = append(res.Redacted, name)
+		}
+	case RDAPObjectIPNetwork:
+		res.Name = obj.Name
+		res.Network = &RDAPNetwork{
+			StartAddress: obj.StartAddress,
+			EndAddress:   obj.EndAddress,
+			IPVersion:    obj.IPVersion,
+			Type:         obj.Type,
+			Country:      obj.Country,
+			ParentHandle: obj.ParentHandle,
+			OriginASNs:   obj.OriginASNs,
+		}
+		for _, cidr := range obj.CIDRs {
+			prefix := cidr.V4Prefix
+			if prefix == "" {
+				prefix = cidr.V6Prefix
+			}
+			res.Network.CIDRs = append(res.Network.CIDRs, fmt.Sprintf("%s/%d", prefix, cidr.Length))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported RDAP object class %q", obj.ObjectClassName)
+	}
+	return res, nil
+}
+
+func toRDAPEntities(entities []rdapEntity) []RDAPEntity {
+	var res []RDAPEntity
+	for _, e := range entities {
+		entity := RDAPEntity{
+			Handle:       e.Handle,
+			Roles:        e.Roles,
+			Kind:         e.VCard.text("kind"),
+			Name:         e.VCard.text("fn"),
+			Organization: e.VCard.text("org"),
+			Email:        e.VCard.text("email"),
+			Phone:        strings.TrimPrefix(e.VCard.text("tel"), "tel:"),
+			Country:      e.VCard.param("adr", "cc"),
+			URL:          e.VCard.text("url"),
+			Entities:     toRDAPEntities(e.Entities),
+		}
+		adr := e.VCard.address()
+		entity.Street, entity.City, entity.Province, entity.PostalCode = adr[2], adr[3], adr[4], adr[5]
+		if entity.Country == "" {
+			entity.Country = adr[6]
+		}
+		// Some servers only give the address as a label
+		if label := e.VCard.param("adr", "label"); entity.Street == "" && label != "" {
+			var lines []string
+			for _, line := range strings.Split(label, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			entity.Street = strings.Join(lines, ", ")
+		}
+		for _, id := range e.PublicIDs {
+			if id.Type == "IANA Registrar ID" {
+				entity.IANAID = id.Identifier
+			}
+		}
+		res = append(res, entity)
+	}
+	return res
+}
@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseRDAPFixture(t *testing.T, name string) *RDAPResult {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", "rdap", name))
+	require.NoError(t, err)
+	defer f.Close()
+
+	res, err := ParseRDAP(f)
+	require.NoError(t, err)
+	return res
+}
+
+func Test_ParseRDAPDomain(t *testing.T) {
+	res := parseRDAPFixture(t, "example.com.json")
+
+	assert.Equal(t, RDAPObjectDomain, res.ObjectClass)
+	assert.Equal(t, "2336799_DOMAIN_COM-VRSN", res.Handle)
+	assert.Equal(t, "EXAMPLE.COM", res.Name)
+	assert.Equal(t, []string{"A.IANA-SERVERS.NET", "B.IANA-SERVERS.NET"}, res.NameServers)
+	assert.True(t, res.DelegationSigned)
+	assert.Nil(t, res.Network)
+	assert.Equal(t, []string{"Registrant Name", "Registrant Phone", "Registrant Email"}, res.Redacted)
+	assert.Equal(t, "whois.verisign-grs.com", res.Port43)
+	assert.Equal(t, time.Date(2025, 8, 13, 4, 0, 0, 0, time.UTC), res.EventDate(RDAPEventExpiration))
+	assert.Len(t, res.Events, 4)
+
+	registrar := res.Entity(RDAPRoleRegistrar)
+	require.NotNil(t, registrar)
+	assert.Equal(t, "MarkMonitor Inc.", registrar.Name)
+	assert.Equal(t, "292", registrar.IANAID)
+	assert.Equal(t, "http://www.markmonitor.com", registrar.URL)
+
+	abuse := res.Entity(RDAPRoleAbuse)
+	require.NotNil(t, abuse)
+	assert.Equal(t, "abusecomplaints@markmonitor.com", abuse.Email)
+	assert.Equal(t, "+1.2086851750", abuse.Phone)
+
+	registrant := res.Entity(RDAPRoleRegistrant)
+	require.NotNil(t, registrant)
+	assert.Equal(t, RDAPEntity{
+		Roles:        []string{RDAPRoleRegistrant},
+		Organization: "Internet Assigned Numbers Authority",
+		Province:     "CA",
+		Country:      "US",
+	}, *registrant)
+
+	assert.Nil(t, res.Entity(RDAPRoleTechnical))
+}
+
+func Test_ParseRDAPRedactedEntities(t *testing.T) {
+	res := parseRDAPFixture(t, "redacted.example.json")
+
+	assert.Equal(t, "redacted.org", res.Name)
+	assert.Equal(t, []string{"Registrant Name"}, res.Redacted)
+	require.NotNil(t, res.Entity(RDAPRoleRegistrar))
+	assert.Equal(t, "Example Registrar, LLC", res.Entity(RDAPRoleRegistrar).Name)
+
+	// Null and empty jCards leave the entities without contact data
+	for _, role := range []string{RDAPRoleRegistrant, RDAPRoleAdministrative, RDAPRoleTechnical} {
+		entity := res.Entity(role)
+		require.NotNil(t, entity, role)
+		assert.Equal(t, RDAPEntity{Roles: []string{role}}, *entity, role)
+	}
+
+	reg := res.Registration()
+	assert.True(t, reg.Redacted)
+	assert.False(t, reg.RegistrantExposed)
+}
+
+func Test_ParseRDAPIPNetwork(t *testing.T) {
+	res := parseRDAPFixture(t, "google-dns.json")
+
+	assert.Equal(t, RDAPObjectIPNetwork, res.ObjectClass)
+	assert.Equal(t, "GOGL", res.Name)
+	assert.Equal(t, []string{"active"}, res.Status)
+	assert.Equal(t, &RDAPNetwork{
+		StartAddress: "8.8.8.0",
+		EndAddress:   "8.8.8.255",
+		IPVersion:    "v4",
+		Type:         "DIRECT ALLOCATION",
+		ParentHandle: "NET-8-0-0-0-0",
+		CIDRs:        []string{"8.8.8.0/24"},
+		OriginASNs:   []uint32{15169},
+	}, res.Network)
+	assert.Equal(t, time.Date(2023, 12, 28, 22, 24, 33, 0, time.UTC), res.EventDate(RDAPEventRegistration))
+
+	org := res.Entity(RDAPRoleRegistrant)
+	require.NotNil(t, org)
+	assert.Equal(t, "Google LLC", org.Name)
+	assert.Equal(t, "org", org.Kind)
+	assert.Equal(t, "1600 Amphitheatre Parkway, Mountain View, CA, 94043, United States", org.Street)
+
+	abuse := res.Entity(RDAPRoleAbuse)
+	require.NotNil(t, abuse)
+	assert.Equal(t, "network-abuse@google.com", abuse.Email)
+	assert.Equal(t, "+1-650-253-0000", abuse.Phone)
+
+	assert.Nil(t, res.Registration())
+}
+
+func Test_ParseRDAPErrors(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "rdap", "not-found.json"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = ParseRDAP(f)
+	var rdapErr *RDAPError
+	require.True(t, errors.As(err, &rdapErr))
+	assert.Equal(t, 404, rdapErr.Code)
+	assert.Equal(t, "RDAP error 404: Not Found: The requested domain was not found in the registry.", err.Error())
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Malformed", `{"objectClassName": `},
+		{"Unsupported object class", `{"objectClassName": "autnum", "handle": "AS15169"}`},
+		{"Malformed jCard", `{"objectClassName": "domain", "entities": [{"vcardArray": ["vcard"]}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRDAP(strings.NewReader(tt.body))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_EPPStatus(t *testing.T) {
+	tests := map[string]string{
+		"client transfer prohibited": "clientTransferProhibited",
+		"Server Hold":                "serverHold",
+		"active":                     "ok",
+		"inactive":                   "inactive",
+		"":                           "",
+	}
+	for status, want := range tests {
+		assert.Equal(t, want, eppStatus(status), status)
+	}
+}
+
+func Test_RDAPResultRegistration(t *testing.T) {
+	reg := parseRDAPFixture(t, "example.com.json").Registration()
+	require.NotNil(t, reg)
+
+	assert.Equal(t, DomainRegistration{
+		Domain:          "example.com",
+		Registrar:       "MarkMonitor Inc.",
+		RegistrarIANAID: "292",
+		RegistrarURL:    "http://www.markmonitor.com",
+		WhoisServer:     "whois.verisign-grs.com",
+		AbuseContact:    AbuseContact{Email: "abusecomplaints@markmonitor.com", Phone: "+1.2086851750"},
+		Statuses: []string{
+			"clientDeleteProhibited", "clientTransferProhibited", "clientUpdateProhibited", "serverDeleteProhibited",
+		},
+		Locks:       RegistrationLocks{Transfer: true, Update: true, Delete: true},
+		NameServers: []string{"a.iana-servers.net", "b.iana-servers.net"},
+		DNSSEC:      true,
+		CreatedAt:   time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2024, 8, 14, 7, 1, 34, 0, time.UTC),
+		ExpiresAt:   time.Date(2025, 8, 13, 4, 0, 0, 0, time.UTC),
+		Redacted:    true,
+		Sources:     []string{RegistrationSourceRDAP},
+	}, *reg)
+}
+
+func Test_WhoIsResultRegistrationWithRDAP(t *testing.T) {
+	whois := parseWhoIsFixture(t, "example.com.txt")
+	whois.RDAP = parseRDAPFixture(t, "example.com.json")
+	// Registries omit what the registrar knows
+	whois.RDAP.Entities = whois.RDAP.Entities[1:]
+	whois.RDAP.Events = whois.RDAP.Events[:1]
+
+	reg := whois.Registration()
+	require.NotNil(t, reg)
+	assert.Equal(t, []string{RegistrationSourceRDAP, RegistrationSourceWhoIs}, reg.Sources)
+	assert.Equal(t, "whois.verisign-grs.com", reg.WhoisServer)
+	assert.Equal(t, "MarkMonitor, Inc.", reg.Registrar)
+	assert.Equal(t, AbuseContact{Email: "abusecomplaints@markmonitor.com", Phone: "+1.2086851750"}, reg.AbuseContact)
+	assert.Equal(t, time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC), reg.CreatedAt)
+	assert.Equal(t, time.Date(2025, 8, 13, 4, 0, 0, 0, time.UTC), reg.ExpiresAt)
+	assert.Len(t, reg.Statuses, 4)
+	assert.Equal(t, []string{"a.iana-servers.net", "b.iana-servers.net"}, reg.NameServers)
+	assert.True(t, reg.Redacted)
+	assert.False(t, reg.RegistrantExposed)
+
+	rdapOnly := &WhoIsResult{RDAP: parseRDAPFixture(t, "example.com.json")}
+	require.NotNil(t, rdapOnly.Registration())
+	assert.Equal(t, []string{RegistrationSourceRDAP}, rdapOnly.Registration().Sources)
+}
+
+func Test_DomainRegistrationMerge(t *testing.T) {
+	reg := &DomainRegistration{
+		Domain:    "example.com",
+		Registrar: "Registry view",
+		Statuses:  []string{"ok"},
+		Sources:   []string{RegistrationSourceRDAP},
+	}
+	reg.Merge(&DomainRegistration{
+		Domain:            "example.com",
+		Registrar:         "Registrar view",
+		RegistrarURL:      "https://registrar.example",
+		Statuses:          []string{"OK", StatusClientTransferProhibited},
+		NameServers:       []string{"ns1.example.com"},
+		ExpiresAt:         time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		RegistrantExposed: true,
+		Sources:           []string{RegistrationSourceWhoIs},
+	})
+
+	assert.Equal(t, "Registry view", reg.Registrar)
+	assert.Equal(t, "https://registrar.example", reg.RegistrarURL)
+	assert.Equal(t, []string{"ok", StatusClientTransferProhibited}, reg.Statuses)
+	assert.True(t, reg.Locks.Transfer)
+	assert.Equal(t, []string{"ns1.example.com"}, reg.NameServers)
+	assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), reg.ExpiresAt)
+	assert.True(t, reg.RegistrantExposed)
+	assert.Equal(t, []string{RegistrationSourceRDAP, RegistrationSourceWhoIs}, reg.Sources)
+
+	reg.Merge(&DomainRegistration{PrivacyService: "Domains By Proxy, LLC"})
+	assert.False(t, reg.RegistrantExposed)
+}
+
+func Test_WhoIsResultSensitiveFieldsWithRDAP(t *testing.T) {
+	res := &WhoIsResult{RDAP: parseRDAPFixture(t, "example.com.json")}
+
+	fields := res.SensitiveFields()
+	// The registrar and its abuse contact are left out
+	require.Len(t, fields, 8)
+	assert.Equal(t, "Internet Assigned Numbers Authority", *fields[2])
+}
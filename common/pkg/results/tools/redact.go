@@ -0,0 +1,13 @@
+package tools
+
+// redactedValue replaces sensitive values in logs.
+const redactedValue = "[REDACTED]"
+
+// redacted returns the value to log in place of a sensitive value. Empty
+// values are kept, so that missing data can still be told apart.
+func redacted(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redactedValue
+}
@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+)
+
+// ErrInvalidToolDefinition is returned when registering an incomplete or
+// duplicate tool definition.
+var ErrInvalidToolDefinition = errors.New("invalid tool definition")
+
+// ToolDefinition describes everything common needs to know about a tool:
+// how to decode its results, where they are published and what it can scan.
+type ToolDefinition struct {
+	Name enums.ToolName
+
+	// Subject is the subject the tool results are published on.
+	Subject enums.EventSubjectName
+
+	// NewResult returns an empty result of the concrete type produced by the
+	// tool, into which ToolResult payloads are decoded.
+	NewResult func() IToolResult
+
+	// TargetTypes are the host types the tool can run against.
+	TargetTypes []enums.TargetType
+
+	// BaseDomain tells whether the tool runs against the registrable domain of
+	// the host, e.g., example.com for www.example.com, rather than the host
+	// itself. IP addresses are left as is.
+	BaseDomain bool
+}
+
+// CanRun reports whether the tool can run against hosts of the given type.
+func (d ToolDefinition) CanRun(t enums.TargetType) bool {
+	return slices.Contains(d.TargetTypes, t)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[enums.ToolName]ToolDefinition)
+)
+
+func init() {
+	for _, def := range []ToolDefinition{
+		{
+			Name:        enums.ToolWhoIs,
+			Subject:     enums.WhoIsEventSubject,
+			NewResult:   func() IToolResult { return &WhoIsResult{} },
+			TargetTypes: []enums.TargetType{enums.IP, enums.Domain, enums.Subdomain},
+			BaseDomain:  true,
+		},
+		{
+			Name:        enums.ToolHarvester,
+			Subject:     enums.HarvesterEventSubject,
+			NewResult:   func() IToolResult { return &HarvesterResult{} },
+			TargetTypes: []enums.TargetType{enums.Domain, enums.Subdomain},
+			BaseDomain:  true,
+		},
+		{
+			Name:        enums.ToolDNSLookup,
+			Subject:     enums.DNSLookupEventSubject,
+			NewResult:   func() IToolResult { return &DNSLookupResult{} },
+			TargetTypes: []enums.TargetType{enums.Domain, enums.Subdomain},
+			BaseDomain:  true,
+		},
+		{
+			Name:        enums.ToolNmap,
+			Subject:     enums.NmapEventSubject,
+			NewResult:   func() IToolResult { return &NmapResult{} },
+			TargetTypes: []enums.TargetType{enums.IP, enums.Domain, enums.Subdomain},
+		},
+		{
+			// Multi-host scans target network ranges, which are not
+			// dispatched by host type
+			Name:      enums.ToolNmapScan,
+			Subject:   enums.NmapScanEventSubject,
+			NewResult: func() IToolResult { return &NmapScanResult{} },
+		},
+		{
+			// Web scans target URLs, which are not dispatched by host type
+			Name:      enums.ToolWebScan,
+			Subject:   enums.WebScanEventSubject,
+			NewResult: func() IToolResult { return &WebScanResult{} },
+		},
+	} {
+		MustRegisterTool(def)
+	}
+}
+
+// RegisterTool registers a tool so that its results can be decoded by
+// ToolResult and its subject resolved by enums.GetToolSubjectName. Tools are
+// meant to be registered from init functions, including in other modules.
+func RegisterTool(def ToolDefinition) error {
+	if def.Name == "" || def.Subject == "" || def.NewResult == nil {
+		return fmt.Errorf("%w: name, subject and result factory are required", ErrInvalidToolDefinition)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[def.Name]; exists {
+		return fmt.Errorf("%w: tool %s is already registered", ErrInvalidToolDefinition, def.Name)
+	}
+	def.TargetTypes = slices.Clone(def.TargetTypes)
+	registry[def.Name] = def
+	enums.RegisterToolSubject(def.Name, def.Subject)
+	return nil
+}
+
+// MustRegisterTool is like RegisterTool but panics on error.
+func MustRegisterTool(def ToolDefinition) {
+	if err := RegisterTool(def); err != nil {
+		panic(err)
+	}
+}
+
+// UnregisterTool removes a registered tool and its subject. It is meant for
+// tests registering tools in the process-wide registry, which must restore it
+// afterwards, e.g., with t.Cleanup. It reports whether the tool was registered.
+func UnregisterTool(name enums.ToolName) bool {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[name]; !exists {
+		return false
+	}
+	delete(registry, name)
+	enums.UnregisterToolSubject(name)
+	return true
+}
+
+// LookupTool returns the definition of a registered tool.
+func LookupTool(name enums.ToolName) (ToolDefinition, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	def, ok := registry[name]
+	return def, ok
+}
+
+// RegisteredTools returns the definitions of all registered tools, sorted by name.
+func RegisteredTools() []ToolDefinition {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	defs := make([]ToolDefinition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// newToolResult returns an empty result of the concrete type produced by the given tool.
+func newToolResult(tool enums.ToolName) (IToolResult, error) {
+	def, ok := LookupTool(tool)
+	if !ok {
+		return nil, fmt.Errorf("unsupported tool name: %s", tool)
+	}
+	return def.NewResult(), nil
+}
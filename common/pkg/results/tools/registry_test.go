@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sslScanResult struct {
+	Protocols []string `json:"protocols"`
+}
+
+func (r *sslScanResult) GetToolName() enums.ToolName {
+	return "SSLScan"
+}
+
+func Test_RegisterTool(t *testing.T) {
+	def := ToolDefinition{
+		Name:        "SSLScan",
+		Subject:     "event.sslscan",
+		NewResult:   func() IToolResult { return &sslScanResult{} },
+		TargetTypes: []enums.TargetType{enums.Domain, enums.Subdomain},
+	}
+	require.NoError(t, RegisterTool(def))
+	t.Cleanup(func() { UnregisterTool(def.Name) })
+
+	registered, ok := LookupTool("SSLScan")
+	require.True(t, ok)
+	assert.True(t, registered.CanRun(enums.Subdomain))
+	assert.False(t, registered.CanRun(enums.IP))
+
+	subject, err := enums.GetToolSubjectName("SSLScan")
+	require.NoError(t, err)
+	assert.Equal(t, "event.sslscan", subject)
+
+	// Snapshots include registered tools, and cannot alter the registry
+	subjects := enums.ToolSubjects()
+	assert.Equal(t, enums.EventSubjectName("event.sslscan"), subjects["SSLScan"])
+	delete(subjects, "SSLScan")
+	_, err = enums.GetToolSubjectName("SSLScan")
+	require.NoError(t, err)
+
+	// Results of registered tools are decoded into their concrete type
+	var tr ToolResult
+	require.NoError(t, json.Unmarshal([]byte(`{"tool_name":"SSLScan","result":{"protocols":["TLSv1.2","TLSv1.3"]}}`), &tr))
+	assert.Equal(t, &sslScanResult{Protocols: []string{"TLSv1.2", "TLSv1.3"}}, tr.Result)
+
+	assert.ErrorIs(t, RegisterTool(def), ErrInvalidToolDefinition)
+	assert.Panics(t, func() { MustRegisterTool(def) })
+}
+
+func Test_UnregisterTool(t *testing.T) {
+	def := ToolDefinition{
+		Name:      "Ephemeral",
+		Subject:   "event.ephemeral",
+		NewResult: func() IToolResult { return &sslScanResult{} },
+	}
+	require.NoError(t, RegisterTool(def))
+
+	assert.True(t, UnregisterTool(def.Name))
+	_, ok := LookupTool(def.Name)
+	assert.False(t, ok)
+	_, err := enums.GetToolSubjectName(def.Name)
+	assert.Error(t, err)
+	assert.False(t, UnregisterTool(def.Name))
+
+	// The tool can be registered again
+	require.NoError(t, RegisterTool(def))
+	assert.True(t, UnregisterTool(def.Name))
+}
+
+func Test_RegisterToolInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		def  ToolDefinition
+	}{
+		{
+			name: "Missing name",
+			def:  ToolDefinition{Subject: "event.invalid", NewResult: func() IToolResult { return &NmapResult{} }},
+		},
+		{
+			name: "Missing subject",
+			def:  ToolDefinition{Name: "Invalid", NewResult: func() IToolResult { return &NmapResult{} }},
+		},
+		{
+			name: "Missing result factory",
+			def:  ToolDefinition{Name: "Invalid", Subject: "event.invalid"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, RegisterTool(tc.def), ErrInvalidToolDefinition)
+			_, ok := LookupTool("Invalid")
+			assert.False(t, ok)
+		})
+	}
+}
+
+func Test_BuiltinTools(t *testing.T) {
+	subjects := enums.ToolSubjects()
+	for _, name := range []enums.ToolName{enums.ToolWhoIs, enums.ToolHarvester, enums.ToolDNSLookup, enums.ToolNmap, enums.ToolNmapScan, enums.ToolWebScan} {
+		def, ok := LookupTool(name)
+		require.True(t, ok, name)
+		assert.Equal(t, name, def.NewResult().GetToolName())
+		assert.Equal(t, subjects[name], def.Subject)
+	}
+
+	var tr ToolResult
+	err := json.Unmarshal([]byte(`{"tool_name":"Unknown","result":{}}`), &tr)
+	assert.ErrorContains(t, err, "unsupported tool name: Unknown")
+}
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+)
+
+// IDs of the subdomain findings.
+const (
+	FindingInternalSubdomains = "subdomain-internal-exposed"
+	FindingPrivateAddresses   = "subdomain-private-address"
+)
+
+// SubdomainStatus is the outcome of resolving a subdomain.
+type SubdomainStatus string
+
+const (
+	// SubdomainLive resolves to addresses other than the wildcard ones.
+	SubdomainLive SubdomainStatus = "live"
+	// SubdomainDead does not resolve.
+	SubdomainDead SubdomainStatus = "dead"
+	// SubdomainWildcard only resolves to the wildcard addresses of the
+	// domain, so it may not exist.
+	SubdomainWildcard SubdomainStatus = "wildcard"
+	// SubdomainUnresolved could not be resolved, e.g., on timeouts.
+	SubdomainUnresolved SubdomainStatus = "unresolved"
+)
+
+func (s SubdomainStatus) String() string {
+	return string(s)
+}
+
+// internalKeywords are the labels, or words of labels, of subdomains which
+// look meant for internal use, e.g., "vpn" in vpn.example.com or "staging"
+// in app-staging.example.com.
+var internalKeywords = []string{
+	"admin", "backup", "ci", "citrix", "confluence", "corp", "db", "dev", "develop", "development",
+	"git", "gitlab", "grafana", "internal", "intranet", "jenkins", "jira", "kibana", "ldap", "mysql",
+	"preprod", "qa", "rdp", "remote", "sandbox", "sql", "stage", "staging", "stg", "test", "testing",
+	"uat", "vault", "vpn",
+}
+
+// SubdomainEnrichment is the outcome of resolving the subdomains of a domain.
+type SubdomainEnrichment struct {
+	Domain     string              `json:"domain"`
+	Subdomains []EnrichedSubdomain `json:"subdomains"`
+	// WildcardIPs are the addresses any name of the domain resolves to, when
+	// it has wildcard DNS records.
+	WildcardIPs []string `json:"wildcard_ips,omitempty"`
+	// WildcardError is why wildcard DNS records could not be detected, e.g.,
+	// on timeouts. Wildcard names may then be reported as live.
+	WildcardError string `json:"wildcard_error,omitempty"`
+	// IPGroups are the live subdomains grouped by address.
+	IPGroups   []SubdomainIPGroup `json:"ip_groups"`
+	Live       int                `json:"live"`
+	Dead       int                `json:"dead"`
+	Wildcard   int                `json:"wildcard"`
+	Unresolved int                `json:"unresolved"`
+	Findings   []Finding          `json:"findings"`
+}
+
+// EnrichedSubdomain is a resolved subdomain.
+type EnrichedSubdomain struct {
+	Name   string          `json:"name"`
+	IPs    []string        `json:"ips,omitempty"`
+	Status SubdomainStatus `json:"status"`
+	// InternalKeyword is the keyword making the name look internal, if any.
+	InternalKeyword string `json:"internal_keyword,omitempty"`
+	// PrivateIPs are the private or loopback addresses among IPs.
+	PrivateIPs []string `json:"private_ips,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// IsInternal reports whether the name looks meant for internal use.
+func (s *EnrichedSubdomain) IsInternal() bool {
+	return s.InternalKeyword != ""
+}
+
+// SubdomainIPGroup is an address shared by live subdomains.
+type SubdomainIPGroup struct {
+	IP         string   `json:"ip"`
+	Subdomains []string `json:"subdomains"`
+}
+
+// NewSubdomainEnrichment returns the enrichment of the resolved subdomains,
+// whose status is SubdomainLive, SubdomainDead or SubdomainUnresolved. Live
+// subdomains only resolving to the wildcard addresses are downgraded to
+// SubdomainWildcard.
+func NewSubdomainEnrichment(domain string, wildcardIPs []string, subdomains []EnrichedSubdomain) *SubdomainEnrichment {
+	domain = normalizeDNSName(domain)
+	res := &SubdomainEnrichment{
+		Domain:      domain,
+		Subdomains:  []EnrichedSubdomain{},
+		WildcardIPs: wildcardIPs,
+		IPGroups:    []SubdomainIPGroup{},
+	}
+
+	groups := make(map[string][]string)
+	for _, s := range subdomains {
+		s.Name = normalizeDNSName(s.Name)
+		s.InternalKeyword = internalKeyword(s.Name, domain)
+		s.PrivateIPs = nil
+		for _, ip := range s.IPs {
+			if addr, err := netip.ParseAddr(ip); err == nil && (addr.IsPrivate() || addr.IsLoopback()) {
+				s.PrivateIPs = append(s.PrivateIPs, ip)
+			}
+		}
+		if s.Status == SubdomainLive && len(wildcardIPs) > 0 && !slices.ContainsFunc(s.IPs, func(ip string) bool {
+			return !slices.Contains(wildcardIPs, ip)
+		}) {
+			s.Status = SubdomainWildcard
+		}
+
+		switch s.Status {
+		case SubdomainLive:
+			res.Live++
+			for _, ip := range s.IPs {
+				groups[ip] = append(groups[ip], s.Name)
+			}
+		case SubdomainDead:
+			res.Dead++
+		case SubdomainWildcard:
+			res.Wildcard++
+		default:
+			res.Unresolved++
+		}
+		res.Subdomains = append(res.Subdomains, s)
+	}
+
+	for ip, names := range groups {
+		res.IPGroups = append(res.IPGroups, SubdomainIPGroup{IP: ip, Subdomains: names})
+	}
+	slices.SortFunc(res.IPGroups, func(a, b SubdomainIPGroup) int {
+		addrA, errA := netip.ParseAddr(a.IP)
+		addrB, errB := netip.ParseAddr(b.IP)
+		if errA != nil || errB != nil {
+			return strings.Compare(a.IP, b.IP)
+		}
+		return addrA.Compare(addrB)
+	})
+
+	res.Findings = res.findings()
+	return res
+}
+
+// LiveSubdomains returns the names of the live subdomains.
+func (e *SubdomainEnrichment) LiveSubdomains() []string {
+	var names []string
+	for _, s := range e.Subdomains {
+		if s.Status == SubdomainLive {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// internalKeyword returns the internal keyword found in the labels of the
+// name below the domain.
+func internalKeyword(name, domain string) string {
+	prefix, ok := strings.CutSuffix(name, "."+domain)
+	if !ok {
+		return ""
+	}
+	for _, label := range strings.Split(prefix, ".") {
+		for _, word := range strings.FieldsFunc(label, func(r rune) bool { return r == '-' || r == '_' }) {
+			// e.g., "dev2"
+			word = strings.TrimRight(word, "0123456789")
+			if slices.Contains(internalKeywords, word) {
+				return word
+			}
+		}
+	}
+	return ""
+}
+
+func (e *SubdomainEnrichment) findings() []Finding {
+	var internal, private []string
+	privateHosts := 0
+	for _, s := range e.Subdomains {
+		if s.Status != SubdomainLive {
+			continue
+		}
+		if s.IsInternal() {
+			internal = append(internal, s.Name)
+		}
+		if len(s.PrivateIPs) > 0 {
+			privateHosts++
+		}
+		for _, ip := range s.PrivateIPs {
+			private = append(private, s.Name+" "+ip)
+		}
+	}
+
+	findings := []Finding{}
+	if len(internal) > 0 {
+		findings = append(findings, Finding{
+			ID:    FindingInternalSubdomains,
+			Title: "Internal hosts resolvable from the Internet",
+			Description: fmt.Sprintf("%d live subdomains of %s look meant for internal use, e.g., development, staging or remote access hosts, which are often less protected.",
+				len(internal), e.Domain),
+			Severity:    enums.SeverityTypeLow,
+			Category:    enums.OwaspCategorySecurityMisconfiguration,
+			Remediation: "Move internal hosts to a private DNS zone, or restrict their access to the internal network or a VPN.",
+			Evidence:    strings.Join(internal, ", "),
+		})
+	}
+	if len(private) > 0 {
+		findings = append(findings, Finding{
+			ID:    FindingPrivateAddresses,
+			Title: "Private addresses published in public DNS",
+			Description: fmt.Sprintf("%d subdomains of %s resolve to private addresses, disclosing the internal addressing plan.",
+				privateHosts, e.Domain),
+			Severity:    enums.SeverityTypeLow,
+			Category:    enums.GetOwaspCategoryForCWE("CWE-200"),
+			Remediation: "Publish the records of internal hosts in a private DNS zone only.",
+			Evidence:    strings.Join(private, ", "),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_InternalKeyword(t *testing.T) {
+	tests := map[string]string{
+		"vpn.example.com":              "vpn",
+		"app-staging.example.com":      "staging",
+		"dev2.eu.example.com":          "dev",
+		"jenkins_ci.example.com":       "jenkins",
+		"www.example.com":              "",
+		"devices.example.com":          "",
+		"example.com":                  "",
+		"vpn.example.org":              "",
+		"api.test.example.com":         "test",
+		"contest.example.com":          "",
+		"mysql-backup-01.example.com":  "mysql",
+		"intranet.corp.example.com":    "intranet",
+		"staging.example.com.evil.com": "",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, internalKeyword(name, "example.com"), name)
+	}
+}
+
+func Test_NewSubdomainEnrichment(t *testing.T) {
+	res := NewSubdomainEnrichment("Example.com.", []string{"192.0.2.1"}, []EnrichedSubdomain{
+		{Name: "WWW.example.com.", IPs: []string{"93.184.216.34"}, Status: SubdomainLive},
+		{Name: "api.example.com", IPs: []string{"93.184.216.34", "192.0.2.1"}, Status: SubdomainLive},
+		{Name: "vpn.example.com", IPs: []string{"10.0.0.1"}, Status: SubdomainLive},
+		{Name: "staging.example.com", IPs: []string{"192.0.2.1"}, Status: SubdomainLive},
+		{Name: "old.example.com", Status: SubdomainDead},
+		{Name: "slow.example.com", Status: SubdomainUnresolved, Error: "i/o timeout"},
+	})
+
+	assert.Equal(t, "example.com", res.Domain)
+	assert.Equal(t, 3, res.Live)
+	assert.Equal(t, 1, res.Dead)
+	assert.Equal(t, 1, res.Wildcard)
+	assert.Equal(t, 1, res.Unresolved)
+	assert.Equal(t, []string{"www.example.com", "api.example.com", "vpn.example.com"}, res.LiveSubdomains())
+
+	require.Len(t, res.Subdomains, 6)
+	assert.Equal(t, EnrichedSubdomain{
+		Name: "vpn.example.com", IPs: []string{"10.0.0.1"}, Status: SubdomainLive, InternalKeyword: "vpn", PrivateIPs: []string{"10.0.0.1"},
+	}, res.Subdomains[2])
+	// Only resolving to the wildcard address
+	assert.Equal(t, SubdomainWildcard, res.Subdomains[3].Status)
+	assert.True(t, res.Subdomains[3].IsInternal())
+
+	assert.Equal(t, []SubdomainIPGroup{
+		{IP: "10.0.0.1", Subdomains: []string{"vpn.example.com"}},
+		{IP: "93.184.216.34", Subdomains: []string{"www.example.com", "api.example.com"}},
+		{IP: "192.0.2.1", Subdomains: []string{"api.example.com"}},
+	}, res.IPGroups)
+
+	// Wildcard and dead internal names are not reported
+	require.Len(t, res.Findings, 2)
+	assert.Equal(t, FindingInternalSubdomains, res.Findings[0].ID)
+	assert.Equal(t, "vpn.example.com", res.Findings[0].Evidence)
+	assert.Equal(t, FindingPrivateAddresses, res.Findings[1].ID)
+	assert.Equal(t, "vpn.example.com 10.0.0.1", res.Findings[1].Evidence)
+	assert.Equal(t, enums.SeverityTypeLow, res.Findings[1].Severity)
+}
+
+func Test_NewSubdomainEnrichmentWithoutWildcard(t *testing.T) {
+	res := NewSubdomainEnrichment("example.com", nil, []EnrichedSubdomain{
+		{Name: "www.example.com", IPs: []string{"192.0.2.1"}, Status: SubdomainLive},
+	})
+	assert.Equal(t, 1, res.Live)
+	assert.Empty(t, res.Findings)
+
+	empty := NewSubdomainEnrichment("example.com", nil, nil)
+	assert.Equal(t, &SubdomainEnrichment{
+		Domain:     "example.com",
+		Subdomains: []EnrichedSubdomain{},
+		IPGroups:   []SubdomainIPGroup{},
+		Findings:   []Finding{},
+	}, empty)
+	assert.Nil(t, empty.LiveSubdomains())
+}
@@ -1,12 +1,14 @@
 package tools
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 type IToolResult interface {
@@ -54,45 +56,55 @@ func (r *ToolResult) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("failed to unmarshal ToolResult: %w", err)
 	}
 
-	// Determine the concrete type for Result based on the Tool field
-	switch r.Tool {
-	case enums.ToolWhoIs:
-		var whoisResult WhoIsResult
-		if err := json.Unmarshal(aux.Result, &whoisResult); err != nil {
-			return fmt.Errorf("failed to unmarshal WhoIsResult: %w", err)
-		}
-		r.Result = &whoisResult
-	case enums.ToolHarvester:
-		var harvesterResult HarvesterResult
-		if err := json.Unmarshal(aux.Result, &harvesterResult); err != nil {
-			return fmt.Errorf("failed to unmarshal HarvesterResult: %w", err)
-		}
-		r.Result = &harvesterResult
-	case enums.ToolDNSLookup:
-		var dnsLookupResult DNSLookupResult
-		if err := json.Unmarshal(aux.Result, &dnsLookupResult); err != nil {
-			return fmt.Errorf("failed to unmarshal DNSLookupResult: %w", err)
-		}
-		r.Result = &dnsLookupResult
-	case enums.ToolNmap:
-		var nmapResult NmapResult
-		if err := json.Unmarshal(aux.Result, &nmapResult); err != nil {
-			return fmt.Errorf("failed to unmarshal NmapResult: %w", err)
+	result, err := newToolResult(r.Tool)
+	if err != nil {
+		return err
+	}
+	if len(aux.Result) > 0 && string(aux.Result) != "null" {
+		if err := json.Unmarshal(aux.Result, result); err != nil {
+			return fmt.Errorf("failed to unmarshal %T: %w", result, err)
 		}
-		r.Result = &nmapResult
-	case enums.ToolWebScan:
-		var webScanResult WebScanResult
-		if err := json.Unmarshal(aux.Result, &webScanResult); err != nil {
-			return fmt.Errorf("failed to unmarshal WebScanResult: %w", err)
+		r.Result = result
+	}
+
+	return nil
+}
+
+// DecodeMsgpack implements msgpack.CustomDecoder so that Result is decoded
+// into the concrete type matching the Tool field, mirroring UnmarshalJSON.
+func (r *ToolResult) DecodeMsgpack(dec *msgpack.Decoder) error {
+	// msgpack does not inline embedded pointers, so the fields are listed explicitly
+	var aux struct {
+		Tool      enums.ToolName     `json:"tool_name"`
+		Result    msgpack.RawMessage `json:"result,omitempty"`
+		Err       *ToolError         `json:"error,omitempty"`
+		Timestamp time.Time          `json:"timestamp"`
+	}
+
+	if err := dec.Decode(&aux); err != nil {
+		return fmt.Errorf("failed to unmarshal ToolResult: %w", err)
+	}
+	r.Tool, r.Err, r.Timestamp = aux.Tool, aux.Err, aux.Timestamp
+
+	result, err := newToolResult(r.Tool)
+	if err != nil {
+		return err
+	}
+	if len(aux.Result) > 0 && aux.Result[0] != msgpackNil {
+		rd := msgpack.NewDecoder(bytes.NewReader(aux.Result))
+		rd.SetCustomStructTag("json")
+		if err := rd.Decode(result); err != nil {
+			return fmt.Errorf("failed to unmarshal %T: %w", result, err)
 		}
-		r.Result = &webScanResult
-	default:
-		return fmt.Errorf("unsupported tool name: %s", r.Tool)
+		r.Result = result
 	}
 
 	return nil
 }
 
+// msgpackNil is the MessagePack encoding of a nil value.
+const msgpackNil = 0xc0
+
 // LogValue creates a standard structured log representation for logging.
 func (r *ToolResult) LogValue() slog.Value {
 	return slog.GroupValue(
@@ -13,14 +13,15 @@ type InstanceAlert struct {
 }
 
 type WebVulnerability struct {
-	Name       string                      `json:"name"`
-	Risk       enums.RiskCodeType          `json:"risk"`
-	Instances  []InstanceAlert             `json:"instances"`
-	Confidence enums.ConfidenceWebScanType `json:"confidence"`
-	Solution   string                      `json:"solution"`
-	Reference  string                      `json:"reference"`
-	CweID      string                      `json:"cwe_id"`
-	WascID     string                      `json:"wasc_id"`
+	Name        string                      `json:"name"`
+	Risk        enums.RiskCodeType          `json:"risk"`
+	Instances   []InstanceAlert             `json:"instances"`
+	Confidence  enums.ConfidenceWebScanType `json:"confidence"`
+	Description string                      `json:"description,omitempty"`
+	Solution    string                      `json:"solution"`
+	Reference   string                      `json:"reference"`
+	CweID       string                      `json:"cwe_id"`
+	WascID      string                      `json:"wasc_id"`
 }
 
 type WebScanResult struct {
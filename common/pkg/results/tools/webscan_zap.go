@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+)
+
+// zapJSONReport mirrors ZAP's traditional JSON report.
+type zapJSONReport struct {
+	Sites []struct {
+		Name   string     `json:"@name"`
+		Alerts []zapAlert `json:"alerts"`
+	} `json:"site"`
+}
+
+// zapXMLReport mirrors ZAP's traditional XML report.
+type zapXMLReport struct {
+	XMLName xml.Name `xml:"OWASPZAPReport"`
+	Sites   []struct {
+		Name   string     `xml:"name,attr"`
+		Alerts []zapAlert `xml:"alerts>alertitem"`
+	} `xml:"site"`
+}
+
+// zapAlert is an alert of a ZAP report. The JSON and XML reports use the same
+// field names.
+type zapAlert struct {
+	Name       string        `json:"name" xml:"name"`
+	Alert      string        `json:"alert" xml:"alert"`
+	RiskCode   zapString     `json:"riskcode" xml:"riskcode"`
+	Confidence zapString     `json:"confidence" xml:"confidence"`
+	Desc       string        `json:"desc" xml:"desc"`
+	Solution   string        `json:"solution" xml:"solution"`
+	OtherInfo  string        `json:"otherinfo" xml:"otherinfo"`
+	Reference  string        `json:"reference" xml:"reference"`
+	CWEID      zapString     `json:"cweid" xml:"cweid"`
+	WASCID     zapString     `json:"wascid" xml:"wascid"`
+	Instances  []zapInstance `json:"instances" xml:"instances>instance"`
+}
+
+type zapInstance struct {
+	ID        zapString `json:"id" xml:"id"`
+	URI       string    `json:"uri" xml:"uri"`
+	Method    string    `json:"method" xml:"method"`
+	Param     string    `json:"param" xml:"param"`
+	Attack    string    `json:"attack" xml:"attack"`
+	Evidence  string    `json:"evidence" xml:"evidence"`
+	OtherInfo string    `json:"otherinfo" xml:"otherinfo"`
+}
+
+// zapString is a string that ZAP versions encode either as a JSON string or
+// as a JSON number, e.g., riskcode.
+type zapString string
+
+func (s *zapString) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = zapString(str)
+		return nil
+	}
+
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return fmt.Errorf("expected string or number, got %s", data)
+	}
+	*s = zapString(num.String())
+	return nil
+}
+
+// ParseZAPJSON imports a ZAP traditional JSON report. The alerts of all the
+// sites in the report are returned in order.
+func ParseZAPJSON(r io.Reader) (*WebScanResult, error) {
+	var report zapJSONReport
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode ZAP JSON report: %w", err)
+	}
+
+	res := &WebScanResult{WebVulnerabilities: []WebVulnerability{}}
+	for _, site := range report.Sites {
+		for _, a := range site.Alerts {
+			res.WebVulnerabilities = append(res.WebVulnerabilities, a.toWebVulnerability())
+		}
+	}
+	return res, nil
+}
+
+// ParseZAPXML imports a ZAP traditional XML report. The alerts of all the
+// sites in the report are returned in order.
+func ParseZAPXML(r io.Reader) (*WebScanResult, error) {
+	var report zapXMLReport
+	if err := xml.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode ZAP XML report: %w", err)
+	}
+
+	res := &WebScanResult{WebVulnerabilities: []WebVulnerability{}}
+	for _, site := range report.Sites {
+		for _, a := range site.Alerts {
+			res.WebVulnerabilities = append(res.WebVulnerabilities, a.toWebVulnerability())
+		}
+	}
+	return res, nil
+}
+
+func (a zapAlert) toWebVulnerability() WebVulnerability {
+	name := a.Name
+	if name == "" {
+		name = a.Alert
+	}
+
+	otherInfo := stripHTML(a.OtherInfo)
+	instances := make([]InstanceAlert, 0, len(a.Instances))
+	for _, i := range a.Instances {
+		instance := InstanceAlert{
+			ID:        strings.TrimSpace(string(i.ID)),
+			URI:       i.URI,
+			Method:    enums.MethodType(strings.ToUpper(i.Method)),
+			Param:     i.Param,
+			Attack:    i.Attack,
+			Evidence:  i.Evidence,
+			OtherInfo: stripHTML(i.OtherInfo),
+		}
+		// Older ZAP versions report other info per alert only
+		if instance.OtherInfo == "" {
+			instance.OtherInfo = otherInfo
+		}
+		instances = append(instances, instance)
+	}
+
+	return WebVulnerability{
+		Name:        strings.TrimSpace(name),
+		Risk:        enums.ParseRiskCode(string(a.RiskCode), enums.RiskCodeInformational),
+		Confidence:  enums.ParseConfidenceWebScan(string(a.Confidence), enums.ConfidenceMedium),
+		Instances:   instances,
+		Description: stripHTML(a.Desc),
+		Solution:    stripHTML(a.Solution),
+		Reference:   stripHTML(a.Reference),
+		CweID:       zapID(a.CWEID),
+		WascID:      zapID(a.WASCID),
+	}
+}
+
+// zapID returns the CWE or WASC ID of an alert, or "" when ZAP reports none
+// (-1 or 0).
+func zapID(id zapString) string {
+	s := strings.TrimSpace(string(id))
+	if s == "" || s == "0" || strings.HasPrefix(s, "-") {
+		return ""
+	}
+	return s
+}
+
+// stripHTML converts the HTML snippets of ZAP reports into plain text. Block
+// elements such as paragraphs and list items become lines, and entities are
+// unescaped.
+func stripHTML(s string) string {
+	var text, tag strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<' && !inTag:
+			inTag = true
+			tag.Reset()
+		case r == '>' && inTag:
+			inTag = false
+			if isBlockTag(tag.String()) {
+				text.WriteByte('\n')
+			}
+		case inTag:
+			tag.WriteRune(r)
+		default:
+			text.WriteRune(r)
+		}
+	}
+	if inTag {
+		// Not a tag after all
+		text.WriteString("<" + tag.String())
+	}
+
+	var lines []string
+	for _, line := range strings.Split(html.UnescapeString(text.String()), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isBlockTag(tag string) bool {
+	fields := strings.Fields(strings.Trim(tag, "/ "))
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToLower(fields[0]) {
+	case "p", "br", "div", "li", "ul", "ol", "tr", "pre", "h1", "h2", "h3", "h4", "h5", "h6":
+		return true
+	default:
+		return false
+	}
+}
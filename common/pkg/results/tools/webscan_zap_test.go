@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseZAPFixture(t *testing.T, name string, parse func(r io.Reader) (*WebScanResult, error)) *WebScanResult {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", "zap", name))
+	require.NoError(t, err)
+	defer f.Close()
+
+	res, err := parse(f)
+	require.NoError(t, err)
+	return res
+}
+
+func Test_ParseZAPReport(t *testing.T) {
+	jsonRes := parseZAPFixture(t, "report.json", ParseZAPJSON)
+	xmlRes := parseZAPFixture(t, "report.xml", ParseZAPXML)
+
+	for name, res := range map[string]*WebScanResult{"JSON": jsonRes, "XML": xmlRes} {
+		t.Run(name, func(t *testing.T) {
+			require.Len(t, res.WebVulnerabilities, 4)
+
+			xss := res.WebVulnerabilities[0]
+			assert.Equal(t, "Cross Site Scripting (Reflected)", xss.Name)
+			assert.Equal(t, enums.RiskCodeHigh, xss.Risk)
+			assert.Equal(t, enums.ConfidenceMedium, xss.Confidence)
+			assert.Equal(t, "79", xss.CweID)
+			assert.Equal(t, "8", xss.WascID)
+			assert.Equal(t, "Cross-site Scripting (XSS) is an attack technique that involves echoing attacker-supplied code into a user's browser instance.\n"+
+				"When an attacker gets a user's browser to execute his/her code, the code will run within the security context (or zone) of the hosting web site.", xss.Description)
+			assert.Equal(t, "Phase: Architecture and Design\n"+
+				"Use a vetted library or framework that does not allow this weakness to occur & that provides constructs that make this weakness easier to avoid.", xss.Solution)
+			assert.Equal(t, "https://owasp.org/www-community/attacks/xss/\nhttps://cwe.mitre.org/data/definitions/79.html", xss.Reference)
+
+			// Attacks and evidence are kept verbatim
+			require.Len(t, xss.Instances, 1)
+			assert.Equal(t, enums.MethodGet, xss.Instances[0].Method)
+			assert.Equal(t, "q", xss.Instances[0].Param)
+			assert.Equal(t, "</p><scrIpt>alert(1);</scRipt><p>", xss.Instances[0].Attack)
+
+			csp := res.WebVulnerabilities[1]
+			assert.Equal(t, enums.RiskCodeMedium, csp.Risk)
+			assert.Equal(t, enums.ConfidenceHigh, csp.Confidence)
+			require.Len(t, csp.Instances, 2)
+			assert.Equal(t, enums.MethodPost, csp.Instances[1].Method)
+
+			comments := res.WebVulnerabilities[2]
+			assert.Equal(t, enums.RiskCodeInformational, comments.Risk)
+			assert.Equal(t, enums.ConfidenceLow, comments.Confidence)
+			assert.Empty(t, comments.Reference)
+			assert.Equal(t, `The following pattern was used: \bTODO\b and was detected in the element starting with: "// TODO: remove debug endpoint"`,
+				comments.Instances[0].OtherInfo)
+
+			// ZAP reports -1 when an alert has no CWE or WASC ID
+			modern := res.WebVulnerabilities[3]
+			assert.Empty(t, modern.CweID)
+			assert.Empty(t, modern.WascID)
+			assert.Equal(t, `<script src="/static/app.js"></script>`, modern.Instances[0].Evidence)
+		})
+	}
+
+	assert.Equal(t, "12", jsonRes.WebVulnerabilities[0].Instances[0].ID)
+	assert.Empty(t, xmlRes.WebVulnerabilities[0].Instances[0].ID)
+}
+
+func Test_ParseZAPJSONNumericCodes(t *testing.T) {
+	input := `{"site":[{"@name":"http://localhost","alerts":[{"alert":"Old ZAP alert","riskcode":1,"confidence":4,"cweid":-1,"wascid":0,"otherinfo":"<p>Shared</p>","instances":[{"uri":"http://localhost","method":"get"}]}]}]}`
+
+	res, err := ParseZAPJSON(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, res.WebVulnerabilities, 1)
+
+	vuln := res.WebVulnerabilities[0]
+	assert.Equal(t, "Old ZAP alert", vuln.Name)
+	assert.Equal(t, enums.RiskCodeLow, vuln.Risk)
+	assert.Equal(t, enums.ConfidenceHigh, vuln.Confidence)
+	assert.Empty(t, vuln.CweID)
+	assert.Empty(t, vuln.WascID)
+	assert.Equal(t, enums.MethodGet, vuln.Instances[0].Method)
+	assert.Equal(t, "Shared", vuln.Instances[0].OtherInfo)
+}
+
+func Test_ParseZAPErrors(t *testing.T) {
+	_, err := ParseZAPJSON(strings.NewReader(`{"site":[{"alerts":[{"riskcode":{}}]}]}`))
+	assert.Error(t, err)
+
+	_, err = ParseZAPXML(strings.NewReader(`<report></report>`))
+	assert.Error(t, err)
+
+	res, err := ParseZAPJSON(strings.NewReader(`{"site":[]}`))
+	require.NoError(t, err)
+	assert.Empty(t, res.WebVulnerabilities)
+}
+
+func Test_StripHTML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Plain text",
+			input:    "  no   markup  ",
+			expected: "no markup",
+		},
+		{
+			name:     "Paragraphs and line breaks",
+			input:    "<p>First</p><p>Second<br/>Third</p>",
+			expected: "First\nSecond\nThird",
+		},
+		{
+			name:     "Inline tags and entities",
+			input:    "<p>Use <code>X-Frame-Options</code> &amp; <b>CSP</b> &lt;3</p>",
+			expected: "Use X-Frame-Options & CSP <3",
+		},
+		{
+			name:     "Lists",
+			input:    "<ul><li>One</li><li>Two</li></ul>",
+			expected: "One\nTwo",
+		},
+		{
+			name:     "Unterminated tag",
+			input:    "a < b",
+			expected: "a < b",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, stripHTML(tc.input))
+		})
+	}
+}
@@ -10,11 +10,60 @@ import (
 
 type WhoIsResult struct {
 	RawData *whoisparser.WhoisInfo `json:"raw_data"`
-	Error   string                 `json:"error,omitempty"`
+	// RDAP is the RDAP response for the same domain, when the registry has
+	// an RDAP service.
+	RDAP *RDAPResult `json:"rdap,omitempty"`
+	// Network is the IP WHOIS data of IP address targets, for which RawData
+	// is nil.
+	Network *IPRegistration `json:"network,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// SensitiveFields returns the personal data of the registrant, administrative,
+// technical and billing contacts, to be encrypted before the result leaves the
+// service. The registrar contact is not personal data and is left out.
+func (r *WhoIsResult) SensitiveFields() []*string {
+	var fields []*string
+	if r.RDAP != nil {
+		fields = r.RDAP.SensitiveFields()
+	}
+	if r.RawData == nil {
+		return fields
+	}
+
+	for _, c := range []*whoisparser.Contact{
+		r.RawData.Registrant,
+		r.RawData.Administrative,
+		r.RawData.Technical,
+		r.RawData.Billing,
+	} {
+		if c == nil {
+			continue
+		}
+		fields = append(fields,
+			&c.ID, &c.Name, &c.Organization, &c.Street, &c.City, &c.Province,
+			&c.PostalCode, &c.Phone, &c.PhoneExt, &c.Fax, &c.FaxExt, &c.Email,
+		)
+	}
+	return fields
 }
 
 // LogValue creates a standard structured log representation for logging.
 func (r *WhoIsResult) LogValue() slog.Value {
+	if r.Network != nil {
+		return slog.GroupValue(
+			slog.Group("network",
+				slog.String("start_address", r.Network.StartAddress),
+				slog.String("end_address", r.Network.EndAddress),
+				slog.String("net_name", r.Network.NetName),
+				slog.Any("asns", r.Network.ASNs),
+				slog.String("organization", r.Network.Organization),
+				slog.String("country", r.Network.Country),
+				slog.String("abuse_email", r.Network.AbuseContact.Email),
+			),
+			slog.String("error", r.Error),
+		)
+	}
 	if r.RawData == nil {
 		return slog.GroupValue(
 			slog.String("error", r.Error),
@@ -40,6 +89,7 @@ func (r *WhoIsResult) LogValue() slog.Value {
 			slog.String("updated_date", domain.UpdatedDate),
 			slog.String("expiration_date", domain.ExpirationDate),
 		),
+		// The registrar contact is not personal data, unlike the registrant's
 		slog.Group("registrar",
 			slog.String("name", registrar.Name),
 			slog.String("organization", registrar.Organization),
@@ -47,11 +97,11 @@ func (r *WhoIsResult) LogValue() slog.Value {
 			slog.String("phone", registrar.Phone),
 		),
 		slog.Group("registrant",
-			slog.String("name", registrant.Name),
-			slog.String("organization", registrant.Organization),
-			slog.String("city", registrant.City),
+			slog.String("name", redacted(registrant.Name)),
+			slog.String("organization", redacted(registrant.Organization)),
+			slog.String("city", redacted(registrant.City)),
 			slog.String("country", registrant.Country),
-			slog.String("email", registrant.Email),
+			slog.String("email", redacted(registrant.Email)),
 		),
 		slog.String("error", r.Error),
 	)
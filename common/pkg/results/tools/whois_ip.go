@@ -0,0 +1,317 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoNetwork is returned when an IP WHOIS response holds no network.
+var ErrNoNetwork = errors.New("no network found")
+
+// IPRegistration is the registration data of the network an IP address
+// belongs to, whether it comes from WHOIS or RDAP.
+type IPRegistration struct {
+	StartAddress string   `json:"start_address"`
+	EndAddress   string   `json:"end_address"`
+	CIDRs        []string `json:"cidrs,omitempty"`
+	NetName      string   `json:"net_name,omitempty"`
+	Handle       string   `json:"handle,omitempty"`
+	// ASNs are the autonomous systems announcing the network.
+	ASNs         []uint32     `json:"asns,omitempty"`
+	Organization string       `json:"organization,omitempty"`
+	Country      string       `json:"country,omitempty"`
+	AbuseContact AbuseContact `json:"abuse_contact"`
+	// Registry is the regional Internet registry of the network, e.g., "RIPE".
+	Registry  string    `json:"registry,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Sources are the sources merged into the registration, e.g., "whois".
+	Sources []string `json:"sources"`
+}
+
+// Contains reports whether the network contains the IP address.
+func (n *IPRegistration) Contains(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	start, err1 := netip.ParseAddr(n.StartAddress)
+	end, err2 := netip.ParseAddr(n.EndAddress)
+	return err1 == nil && err2 == nil && start.Compare(addr) <= 0 && addr.Compare(end) <= 0
+}
+
+// Merge completes the registration with the fields only known by other,
+// e.g., RDAP data with WHOIS data. ASNs and sources are merged, and the
+// registration takes precedence otherwise.
+func (n *IPRegistration) Merge(other *IPRegistration) {
+	fill := func(v *string, o string) {
+		if *v == "" {
+			*v = o
+		}
+	}
+	if n.StartAddress == "" {
+		n.StartAddress, n.EndAddress, n.CIDRs = other.StartAddress, other.EndAddress, slices.Clone(other.CIDRs)
+	}
+	fill(&n.NetName, other.NetName)
+	fill(&n.Handle, other.Handle)
+	fill(&n.Organization, other.Organization)
+	fill(&n.Country, other.Country)
+	fill(&n.AbuseContact.Email, other.AbuseContact.Email)
+	fill(&n.AbuseContact.Phone, other.AbuseContact.Phone)
+	fill(&n.Registry, other.Registry)
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = other.CreatedAt
+	}
+	if n.UpdatedAt.IsZero() {
+		n.UpdatedAt = other.UpdatedAt
+	}
+	for _, asn := range other.ASNs {
+		if !slices.Contains(n.ASNs, asn) {
+			n.ASNs = append(n.ASNs, asn)
+		}
+	}
+	for _, source := range other.Sources {
+		if !slices.Contains(n.Sources, source) {
+			n.Sources = append(n.Sources, source)
+		}
+	}
+}
+
+// NetworkRegistration returns the normalized registration data of the
+// network of an IP address from the RDAP and WHOIS responses, or nil when
+// there is none. RDAP data takes precedence and is completed with WHOIS data.
+func (r *WhoIsResult) NetworkRegistration() *IPRegistration {
+	var reg *IPRegistration
+	if r.RDAP != nil {
+		reg = r.RDAP.IPRegistration()
+	}
+	switch {
+	case reg == nil && r.Network != nil:
+		network := *r.Network
+		return &network
+	case reg != nil && r.Network != nil:
+		reg.Merge(r.Network)
+	}
+	return reg
+}
+
+// IPRegistration returns the normalized registration data of an RDAP IP
+// network, or nil for other objects.
+func (r *RDAPResult) IPRegistration() *IPRegistration {
+	if r.ObjectClass != RDAPObjectIPNetwork || r.Network == nil {
+		return nil
+	}
+
+	reg := &IPRegistration{
+		StartAddress: r.Network.StartAddress,
+		EndAddress:   r.Network.EndAddress,
+		CIDRs:        slices.Clone(r.Network.CIDRs),
+		NetName:      r.Name,
+		Handle:       r.Handle,
+		ASNs:         slices.Clone(r.Network.OriginASNs),
+		Country:      r.Network.Country,
+		CreatedAt:    r.EventDate(RDAPEventRegistration),
+		UpdatedAt:    r.EventDate(RDAPEventLastChanged),
+		Sources:      []string{RegistrationSourceRDAP},
+	}
+	if len(reg.CIDRs) == 0 {
+		reg.CIDRs = rangeToCIDRs(reg.StartAddress, reg.EndAddress)
+	}
+	if org := r.Entity(RDAPRoleRegistrant); org != nil {
+		reg.Organization = org.Organization
+		if reg.Organization == "" {
+			reg.Organization = org.Name
+		}
+		if reg.Country == "" {
+			reg.Country = org.Country
+		}
+	}
+	if abuse := r.Entity(RDAPRoleAbuse); abuse != nil {
+		reg.AbuseContact = AbuseContact{Email: abuse.Email, Phone: abuse.Phone}
+	}
+	return reg
+}
+
+// ripeAbuseComment is the comment holding the abuse contact in RIPE responses.
+var ripeAbuseComment = regexp.MustCompile(`^% Abuse contact for '.*' is '(.+)'`)
+
+// ParseIPWhoIs parses the WHOIS response of a regional Internet registry for
+// an IP address, in the ARIN or RPSL (RIPE, APNIC, AFRINIC, LACNIC) format.
+// Responses listing several networks, e.g., ARIN parent and child networks,
+// are reduced to the last, most specific, one.
+func ParseIPWhoIs(raw string) (*IPRegistration, error) {
+	reg := &IPRegistration{Sources: []string{RegistrationSourceWhoIs}}
+
+	var network, org map[string][]string
+	for _, block := range whoIsBlocks(raw, func(comment string) {
+		if m := ripeAbuseComment.FindStringSubmatch(comment); m != nil {
+			reg.AbuseContact.Email = m[1]
+		}
+	}) {
+		first := block["_first"][0]
+		switch first {
+		case "netrange", "inetnum", "inet6num":
+			network = block
+		case "orgname", "organisation":
+			org = block
+		case "route", "route6", "aut-num":
+			reg.addASNs(block["origin"]...)
+			reg.addASNs(block["aut-num"]...)
+		}
+		if email := blockValue(block, "orgabuseemail", "abuse-mailbox"); email != "" && reg.AbuseContact.Email == "" {
+			reg.AbuseContact.Email = email
+		}
+		if phone := blockValue(block, "orgabusephone"); phone != "" && reg.AbuseContact.Phone == "" {
+			reg.AbuseContact.Phone = phone
+		}
+	}
+	if network == nil {
+		return nil, ErrNoNetwork
+	}
+
+	var cidrs []string
+	switch rng := blockValue(network, "netrange", "inetnum", "inet6num"); {
+	case strings.Contains(rng, "-"):
+		start, end, _ := strings.Cut(rng, "-")
+		reg.StartAddress, reg.EndAddress = strings.TrimSpace(start), strings.TrimSpace(end)
+	default:
+		prefix, err := netip.ParsePrefix(rng)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network range %q: %w", rng, err)
+		}
+		reg.StartAddress, reg.EndAddress = prefix.Masked().Addr().String(), lastAddr(prefix).String()
+		cidrs = []string{prefix.Masked().String()}
+	}
+	for _, v := range network["cidr"] {
+		for _, cidr := range strings.Split(v, ",") {
+			cidrs = append(cidrs, strings.TrimSpace(cidr))
+		}
+	}
+	if len(cidrs) == 0 {
+		cidrs = rangeToCIDRs(reg.StartAddress, reg.EndAddress)
+	}
+	reg.CIDRs = cidrs
+
+	reg.NetName = blockValue(network, "netname")
+	reg.Handle = blockValue(network, "nethandle")
+	for _, v := range network["originas"] {
+		reg.addASNs(strings.Split(v, ",")...)
+	}
+	reg.Country = blockValue(network, "country")
+	reg.Registry = blockValue(network, "source")
+	if reg.Registry == "" && network["_first"][0] == "netrange" {
+		reg.Registry = "ARIN"
+	}
+	reg.CreatedAt = parseWhoIsDate(blockValue(network, "regdate", "created"), nil)
+	reg.UpdatedAt = parseWhoIsDate(blockValue(network, "updated", "last-modified", "changed"), nil)
+
+	// ARIN names the organization as "Name (HANDLE)" in the network
+	reg.Organization = blockValue(org, "orgname", "org-name")
+	if reg.Organization == "" {
+		name := blockValue(network, "organization", "owner", "descr")
+		if i := strings.LastIndex(name, " ("); i > 0 && strings.HasSuffix(name, ")") {
+			name = name[:i]
+		}
+		reg.Organization = name
+	}
+	if reg.Country == "" {
+		reg.Country = blockValue(org, "country")
+	}
+	return reg, nil
+}
+
+// addASNs adds the AS numbers written as "AS15169" or "15169".
+func (n *IPRegistration) addASNs(values ...string) {
+	for _, v := range values {
+		v = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(v)), "AS")
+		if asn, err := strconv.ParseUint(v, 10, 32); err == nil && !slices.Contains(n.ASNs, uint32(asn)) {
+			n.ASNs = append(n.ASNs, uint32(asn))
+		}
+	}
+}
+
+// whoIsBlocks splits a WHOIS response into blocks of "key: value" lines,
+// separated by blank or comment lines, and passes comments to onComment.
+// Keys are lowercased, and the key of the first line is stored as "_first".
+func whoIsBlocks(raw string, onComment func(string)) []map[string][]string {
+	var blocks []map[string][]string
+	var block map[string][]string
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") {
+			if line != "" {
+				onComment(line)
+			}
+			block = nil
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if block == nil {
+			block = map[string][]string{"_first": {key}}
+			blocks = append(blocks, block)
+		}
+		if value != "" {
+			block[key] = append(block[key], value)
+		}
+	}
+	return blocks
+}
+
+// blockValue returns the first value of the first of the keys in the block.
+func blockValue(block map[string][]string, keys ...string) string {
+	for _, key := range keys {
+		if values := block[key]; len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// lastAddr returns the last address of the prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	addr := prefix.Masked().Addr()
+	b := addr.AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	last, _ := netip.AddrFromSlice(b)
+	return last
+}
+
+// rangeToCIDRs returns the smallest list of prefixes covering the range of
+// addresses, or nil when the range is invalid.
+func rangeToCIDRs(start, end string) []string {
+	first, err1 := netip.ParseAddr(start)
+	last, err2 := netip.ParseAddr(end)
+	if err1 != nil || err2 != nil || first.Is4() != last.Is4() || last.Less(first) {
+		return nil
+	}
+
+	var cidrs []string
+	for first.IsValid() && first.Compare(last) <= 0 {
+		// The largest prefix starting at first which ends within the range
+		bits := first.BitLen()
+		for bits > 0 {
+			p := netip.PrefixFrom(first, bits-1)
+			if p.Masked().Addr() != first || lastAddr(p).Compare(last) > 0 {
+				break
+			}
+			bits--
+		}
+		p := netip.PrefixFrom(first, bits)
+		cidrs = append(cidrs, p.String())
+		first = lastAddr(p).Next()
+	}
+	return cidrs
+}
@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseIPWhoIs(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    IPRegistration
+	}{
+		{
+			name:    "ARIN with parent network",
+			fixture: "8.8.8.8.txt",
+			want: IPRegistration{
+				StartAddress: "8.8.8.0",
+				EndAddress:   "8.8.8.255",
+				CIDRs:        []string{"8.8.8.0/24"},
+				NetName:      "GOGL",
+				Handle:       "NET-8-8-8-0-2",
+				ASNs:         []uint32{15169},
+				Organization: "Google LLC",
+				Country:      "US",
+				AbuseContact: AbuseContact{Email: "network-abuse@google.com", Phone: "+1-650-253-0000"},
+				Registry:     "ARIN",
+				CreatedAt:    time.Date(2023, 12, 28, 0, 0, 0, 0, time.UTC),
+				UpdatedAt:    time.Date(2023, 12, 28, 0, 0, 0, 0, time.UTC),
+				Sources:      []string{RegistrationSourceWhoIs},
+			},
+		},
+		{
+			name:    "RIPE with route object",
+			fixture: "193.0.6.139.txt",
+			want: IPRegistration{
+				StartAddress: "193.0.0.0",
+				EndAddress:   "193.0.7.255",
+				CIDRs:        []string{"193.0.0.0/21"},
+				NetName:      "RIPE-NCC",
+				ASNs:         []uint32{3333},
+				Organization: "Reseaux IP Europeens Network Coordination Centre (RIPE NCC)",
+				Country:      "NL",
+				AbuseContact: AbuseContact{Email: "abuse@ripe.net"},
+				Registry:     "RIPE",
+				CreatedAt:    time.Date(2003, 3, 17, 12, 15, 57, 0, time.UTC),
+				UpdatedAt:    time.Date(2017, 12, 4, 14, 42, 31, 0, time.UTC),
+				Sources:      []string{RegistrationSourceWhoIs},
+			},
+		},
+		{
+			name:    "APNIC IPv6 network",
+			fixture: "2001-db8--1.txt",
+			want: IPRegistration{
+				StartAddress: "2001:db8::",
+				EndAddress:   "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff",
+				CIDRs:        []string{"2001:db8::/32"},
+				NetName:      "EXAMPLE-DOC-V6",
+				Organization: "Documentation prefix",
+				Country:      "AU",
+				AbuseContact: AbuseContact{Email: "abuse@example.net"},
+				Registry:     "APNIC",
+				UpdatedAt:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				Sources:      []string{RegistrationSourceWhoIs},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := os.ReadFile(filepath.Join("testdata", "whois", tt.fixture))
+			require.NoError(t, err)
+
+			got, err := ParseIPWhoIs(string(raw))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func Test_ParseIPWhoIsErrors(t *testing.T) {
+	_, err := ParseIPWhoIs("% No entries found for the selected source(s).\n")
+	assert.True(t, errors.Is(err, ErrNoNetwork))
+
+	_, err = ParseIPWhoIs("inet6num: 2001:db8::\n")
+	assert.Error(t, err)
+}
+
+func Test_RangeToCIDRs(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       []string
+	}{
+		{"10.0.0.0", "10.0.0.255", []string{"10.0.0.0/24"}},
+		{"10.0.0.0", "10.0.2.255", []string{"10.0.0.0/23", "10.0.2.0/24"}},
+		{"10.0.0.1", "10.0.0.6", []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32"}},
+		{"0.0.0.0", "255.255.255.255", []string{"0.0.0.0/0"}},
+		{"2001:db8::", "2001:db8::ffff", []string{"2001:db8::/112"}},
+		{"10.0.0.2", "10.0.0.1", nil},
+		{"10.0.0.0", "2001:db8::", nil},
+		{"invalid", "10.0.0.1", nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, rangeToCIDRs(tt.start, tt.end), "%s - %s", tt.start, tt.end)
+	}
+}
+
+func Test_IPRegistrationContains(t *testing.T) {
+	n := &IPRegistration{StartAddress: "8.8.8.0", EndAddress: "8.8.8.255"}
+	assert.True(t, n.Contains("8.8.8.8"))
+	assert.False(t, n.Contains("8.8.9.0"))
+	assert.False(t, n.Contains("2001:db8::1"))
+	assert.False(t, n.Contains("invalid"))
+}
+
+func Test_WhoIsResultNetworkRegistration(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "whois", "8.8.8.8.txt"))
+	require.NoError(t, err)
+	network, err := ParseIPWhoIs(string(raw))
+	require.NoError(t, err)
+
+	assert.Nil(t, (&WhoIsResult{}).NetworkRegistration())
+	assert.Equal(t, network, (&WhoIsResult{Network: network}).NetworkRegistration())
+
+	res := &WhoIsResult{RDAP: parseRDAPFixture(t, "google-dns.json"), Network: network}
+	reg := res.NetworkRegistration()
+	require.NotNil(t, reg)
+	assert.Equal(t, []string{RegistrationSourceRDAP, RegistrationSourceWhoIs}, reg.Sources)
+	assert.Equal(t, "GOGL", reg.NetName)
+	assert.Equal(t, "Google LLC", reg.Organization)
+	assert.Equal(t, []uint32{15169}, reg.ASNs)
+	assert.Equal(t, []string{"8.8.8.0/24"}, reg.CIDRs)
+	assert.Equal(t, AbuseContact{Email: "network-abuse@google.com", Phone: "+1-650-253-0000"}, reg.AbuseContact)
+	// Only known from WHOIS
+	assert.Equal(t, "US", reg.Country)
+	assert.Equal(t, "ARIN", reg.Registry)
+	assert.Equal(t, time.Date(2023, 12, 28, 22, 24, 33, 0, time.UTC), reg.CreatedAt)
+	assert.True(t, reg.Contains("8.8.8.8"))
+
+	assert.Nil(t, (&WhoIsResult{RDAP: parseRDAPFixture(t, "example.com.json")}).NetworkRegistration())
+}
@@ -0,0 +1,328 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+)
+
+// DomainExpiryWarning is how long before expiration a domain is reported as
+// expiring.
+const DomainExpiryWarning = 30 * 24 * time.Hour
+
+// IDs of the domain registration findings.
+const (
+	FindingDomainExpired           = "whois-domain-expired"
+	FindingDomainExpiring          = "whois-domain-expiring"
+	FindingDomainNoTransferLock    = "whois-no-transfer-lock"
+	FindingDomainRegistrantExposed = "whois-registrant-exposed"
+)
+
+// Sources of a DomainRegistration.
+const (
+	RegistrationSourceWhoIs = "whois"
+	RegistrationSourceRDAP  = "rdap"
+)
+
+// EPP status codes locking a domain, see https://icann.org/epp.
+const (
+	StatusClientTransferProhibited = "clientTransferProhibited"
+	StatusServerTransferProhibited = "serverTransferProhibited"
+	StatusClientUpdateProhibited   = "clientUpdateProhibited"
+	StatusServerUpdateProhibited   = "serverUpdateProhibited"
+	StatusClientDeleteProhibited   = "clientDeleteProhibited"
+	StatusServerDeleteProhibited   = "serverDeleteProhibited"
+)
+
+// redactionMarkers are found in contact fields redacted by registries and
+// registrars, e.g., "REDACTED FOR PRIVACY".
+var redactionMarkers = []string{
+	"redacted", "not disclosed", "non-public data", "data protected", "gdpr masked",
+	"statutory masking", "request email form", "contact form",
+}
+
+// privacyServiceMarkers are found in the registrant of domains registered
+// through a privacy or proxy service, e.g., "Domains By Proxy, LLC".
+var privacyServiceMarkers = []string{
+	"privacy", "proxy", "whoisguard", "whois guard", "protected", "identity protect",
+}
+
+// DomainRegistration is a normalized view of the registration data of a
+// domain, whether it comes from WHOIS or RDAP.
+type DomainRegistration struct {
+	Domain          string       `json:"domain"`
+	Registrar       string       `json:"registrar,omitempty"`
+	RegistrarIANAID string       `json:"registrar_iana_id,omitempty"`
+	RegistrarURL    string       `json:"registrar_url,omitempty"`
+	WhoisServer     string       `json:"whois_server,omitempty"`
+	AbuseContact    AbuseContact `json:"abuse_contact"`
+	// Statuses are the EPP status codes of the domain, e.g., "clientTransferProhibited".
+	Statuses    []string          `json:"statuses,omitempty"`
+	Locks       RegistrationLocks `json:"locks"`
+	NameServers []string          `json:"name_servers,omitempty"`
+	DNSSEC      bool              `json:"dnssec"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+	// RegistrantExposed tells whether the registrant publishes personal data,
+	// i.e., neither redacted nor hidden behind a privacy service.
+	RegistrantExposed bool   `json:"registrant_exposed"`
+	Redacted          bool   `json:"redacted"`
+	PrivacyService    string `json:"privacy_service,omitempty"`
+	// Sources are the sources merged into the registration, e.g., "whois".
+	Sources []string `json:"sources"`
+}
+
+// AbuseContact is the contact of the registrar to report abuse of a domain.
+type AbuseContact struct {
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// RegistrationLocks tell which changes of the domain are prohibited, either
+// by the registrar (client) or the registry (server).
+type RegistrationLocks struct {
+	Transfer bool `json:"transfer"`
+	Update   bool `json:"update"`
+	Delete   bool `json:"delete"`
+}
+
+// HasStatus reports whether the domain has the EPP status, compared case-insensitively.
+func (d *DomainRegistration) HasStatus(status string) bool {
+	return slices.ContainsFunc(d.Statuses, func(s string) bool { return strings.EqualFold(s, status) })
+}
+
+// updateLocks sets Locks from Statuses.
+func (d *DomainRegistration) updateLocks() {
+	d.Locks = RegistrationLocks{
+		Transfer: d.HasStatus(StatusClientTransferProhibited) || d.HasStatus(StatusServerTransferProhibited),
+		Update:   d.HasStatus(StatusClientUpdateProhibited) || d.HasStatus(StatusServerUpdateProhibited),
+		Delete:   d.HasStatus(StatusClientDeleteProhibited) || d.HasStatus(StatusServerDeleteProhibited),
+	}
+}
+
+// Registration returns the normalized registration data of the RDAP and
+// WHOIS responses, or nil when there is none. RDAP data takes precedence and
+// is completed with WHOIS data.
+func (r *WhoIsResult) Registration() *DomainRegistration {
+	var reg *DomainRegistration
+	if r.RDAP != nil {
+		reg = r.RDAP.Registration()
+	}
+	whois := r.whoIsRegistration()
+	switch {
+	case reg == nil:
+		return whois
+	case whois != nil:
+		reg.Merge(whois)
+	}
+	return reg
+}
+
+func (r *WhoIsResult) whoIsRegistration() *DomainRegistration {
+	if r.RawData == nil || r.RawData.Domain == nil {
+		return nil
+	}
+	domain := r.RawData.Domain
+
+	reg := &DomainRegistration{
+		Domain:      strings.ToLower(domain.Domain),
+		WhoisServer: domain.WhoisServer,
+		NameServers: slices.Clone(domain.NameServers),
+		DNSSEC:      domain.DNSSec,
+		CreatedAt:   parseWhoIsDate(domain.CreatedDate, domain.CreatedDateInTime),
+		UpdatedAt:   parseWhoIsDate(domain.UpdatedDate, domain.UpdatedDateInTime),
+		ExpiresAt:   parseWhoIsDate(domain.ExpirationDate, domain.ExpirationDateInTime),
+		Sources:     []string{RegistrationSourceWhoIs},
+	}
+	if reg.Domain == "" {
+		reg.Domain = strings.ToLower(strings.Trim(domain.Name+"."+domain.Extension, "."))
+	}
+	for _, status := range domain.Status {
+		if status = strings.TrimSpace(status); status != "" && !reg.HasStatus(status) {
+			reg.Statuses = append(reg.Statuses, status)
+		}
+	}
+	reg.updateLocks()
+
+	// gTLD registrars publish their abuse contact as the registrar contact
+	if registrar := r.RawData.Registrar; registrar != nil {
+		reg.Registrar = registrar.Name
+		if reg.Registrar == "" {
+			reg.Registrar = registrar.Organization
+		}
+		reg.RegistrarIANAID = registrar.ID
+		reg.RegistrarURL = registrar.ReferralURL
+		reg.AbuseContact = AbuseContact{Email: registrar.Email, Phone: registrar.Phone}
+	}
+
+	if registrant := r.RawData.Registrant; registrant != nil {
+		reg.Redacted, reg.PrivacyService, reg.RegistrantExposed = classifyRegistrant(
+			[]string{registrant.Name, registrant.Organization},
+			[]string{registrant.Name, registrant.Street, registrant.City, registrant.PostalCode, registrant.Phone, registrant.Email},
+		)
+	}
+	return reg
+}
+
+// Merge completes the registration with the fields only known by other,
+// e.g., RDAP data with WHOIS data. Statuses, name servers and sources are
+// merged, and the registration takes precedence otherwise.
+func (d *DomainRegistration) Merge(other *DomainRegistration) {
+	fill := func(v *string, o string) {
+		if *v == "" {
+			*v = o
+		}
+	}
+	fillTime := func(v *time.Time, o time.Time) {
+		if v.IsZero() {
+			*v = o
+		}
+	}
+	fill(&d.Domain, other.Domain)
+	fill(&d.Registrar, other.Registrar)
+	fill(&d.RegistrarIANAID, other.RegistrarIANAID)
+	fill(&d.RegistrarURL, other.RegistrarURL)
+	fill(&d.WhoisServer, other.WhoisServer)
+	fill(&d.AbuseContact.Email, other.AbuseContact.Email)
+	fill(&d.AbuseContact.Phone, other.AbuseContact.Phone)
+	fill(&d.PrivacyService, other.PrivacyService)
+	fillTime(&d.CreatedAt, other.CreatedAt)
+	fillTime(&d.UpdatedAt, other.UpdatedAt)
+	fillTime(&d.ExpiresAt, other.ExpiresAt)
+
+	for _, status := range other.Statuses {
+		if !d.HasStatus(status) {
+			d.Statuses = append(d.Statuses, status)
+		}
+	}
+	d.updateLocks()
+	for _, ns := range other.NameServers {
+		if !slices.Contains(d.NameServers, ns) {
+			d.NameServers = append(d.NameServers, ns)
+		}
+	}
+	for _, source := range other.Sources {
+		if !slices.Contains(d.Sources, source) {
+			d.Sources = append(d.Sources, source)
+		}
+	}
+
+	d.DNSSEC = d.DNSSEC || other.DNSSEC
+	d.Redacted = d.Redacted || other.Redacted
+	d.RegistrantExposed = (d.RegistrantExposed || other.RegistrantExposed) && d.PrivacyService == ""
+}
+
+// classifyRegistrant tells whether the personal fields of the registrant
+// are redacted, and whether the registrant, as named by names, is a privacy
+// service. The registrant is exposed otherwise.
+func classifyRegistrant(names, personal []string) (redacted bool, privacyService string, exposed bool) {
+	published := false
+	for _, v := range personal {
+		switch {
+		case v == "":
+		case containsAny(v, redactionMarkers):
+			redacted = true
+		default:
+			published = true
+		}
+	}
+	for _, name := range names {
+		if name != "" && !containsAny(name, redactionMarkers) && containsAny(name, privacyServiceMarkers) {
+			privacyService = name
+			break
+		}
+	}
+	return redacted, privacyService, published && privacyService == ""
+}
+
+func containsAny(s string, markers []string) bool {
+	s = strings.ToLower(s)
+	return slices.ContainsFunc(markers, func(m string) bool { return strings.Contains(s, m) })
+}
+
+// whoIsDateLayouts are the date layouts not understood by whoisparser.
+var whoIsDateLayouts = []string{
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05.999999999-0700",
+	"2006-01-02T15:04:05.999999999Z",
+	"2006-01-02 15:04:05Z",
+	"2006-01-02T15:04:05",
+	time.DateOnly,
+	"02-Jan-2006 15:04:05 MST",
+	"2006-01-02 15:04:05 MST",
+	"2.1.2006",
+	"2006.01.02 15:04:05",
+	"2006.01.02",
+	"02-Jan-2006",
+	"20060102",
+}
+
+// parseWhoIsDate returns the date parsed by whoisparser, or parses the raw
+// date itself. Unparsable dates are zero.
+func parseWhoIsDate(raw string, parsed *time.Time) time.Time {
+	if parsed != nil {
+		return parsed.UTC()
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}
+	}
+	for _, layout := range whoIsDateLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// Findings returns the weaknesses of the registration at the given time.
+func (d *DomainRegistration) Findings(at time.Time) []Finding {
+	findings := []Finding{}
+	add := func(id, title, description string, severity enums.SeverityType, category enums.OwaspCategory, remediation, evidence string) {
+		findings = append(findings, Finding{
+			ID:          id,
+			Title:       title,
+			Description: description,
+			Severity:    severity,
+			Category:    category,
+			Remediation: remediation,
+			Evidence:    evidence,
+		})
+	}
+
+	if !d.ExpiresAt.IsZero() {
+		expiry := d.ExpiresAt.Format(time.DateOnly)
+		switch left := d.ExpiresAt.Sub(at); {
+		case left <= 0:
+			add(FindingDomainExpired, "Domain registration expired",
+				fmt.Sprintf("The registration of %s expired on %s. The domain may stop resolving or be registered by someone else.", d.Domain, expiry),
+				enums.SeverityTypeHigh, enums.OwaspCategorySecurityMisconfiguration,
+				"Renew the domain registration and enable automatic renewal.", "Expiration date: "+expiry)
+		case left < DomainExpiryWarning:
+			add(FindingDomainExpiring, "Domain registration expiring soon",
+				fmt.Sprintf("The registration of %s expires on %s, in %d days.", d.Domain, expiry, int(left.Hours()/24)),
+				enums.SeverityTypeMedium, enums.OwaspCategorySecurityMisconfiguration,
+				"Renew the domain registration and enable automatic renewal.", "Expiration date: "+expiry)
+		}
+	}
+
+	// Without statuses the locks are unknown
+	if len(d.Statuses) > 0 && !d.Locks.Transfer {
+		add(FindingDomainNoTransferLock, "Domain not locked against transfers",
+			fmt.Sprintf("%s has no transfer lock, so it can be transferred to another registrar with the authorization code alone.", d.Domain),
+			enums.SeverityTypeLow, enums.OwaspCategorySecurityMisconfiguration,
+			"Ask the registrar to set the clientTransferProhibited status.", "Statuses: "+strings.Join(d.Statuses, ", "))
+	}
+
+	if d.RegistrantExposed {
+		add(FindingDomainRegistrantExposed, "Registrant personal data published",
+			fmt.Sprintf("The registration data of %s publishes the personal data of the registrant, which can be used for phishing and social engineering.", d.Domain),
+			enums.SeverityTypeLow, enums.GetOwaspCategoryForCWE("CWE-359"),
+			"Enable the registrar privacy protection or register the domain on behalf of an organization.", "")
+	}
+	return findings
+}
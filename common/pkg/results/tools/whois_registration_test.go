@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+	whoisparser "github.com/likexian/whois-parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+)
+
+func parseWhoIsFixture(t *testing.T, name string) *WhoIsResult {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("testdata", "whois", name))
+	require.NoError(t, err)
+
+	info, err := whoisparser.Parse(string(raw))
+	require.NoError(t, err)
+	return &WhoIsResult{RawData: &info}
+}
+
+func Test_WhoIsResultRegistration(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    DomainRegistration
+	}{
+		{
+			name:    "Redacted registrant with locks",
+			fixture: "example.com.txt",
+			want: DomainRegistration{
+				Domain:          "example.com",
+				Registrar:       "MarkMonitor, Inc.",
+				RegistrarIANAID: "292",
+				RegistrarURL:    "http://www.markmonitor.com",
+				WhoisServer:     "whois.markmonitor.com",
+				AbuseContact:    AbuseContact{Email: "abusecomplaints@markmonitor.com", Phone: "+1.2086851750"},
+				Statuses: []string{
+					"clientDeleteProhibited", "clientTransferProhibited", "clientUpdateProhibited", "serverDeleteProhibited",
+				},
+				Locks:       RegistrationLocks{Transfer: true, Update: true, Delete: true},
+				NameServers: []string{"a.iana-servers.net", "b.iana-servers.net"},
+				DNSSEC:      true,
+				CreatedAt:   time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC),
+				UpdatedAt:   time.Date(2024, 8, 14, 7, 1, 34, 0, time.UTC),
+				ExpiresAt:   time.Date(2025, 8, 13, 4, 0, 0, 0, time.UTC),
+				Redacted:    true,
+				Sources:     []string{RegistrationSourceWhoIs},
+			},
+		},
+		{
+			name:    "Privacy service without locks",
+			fixture: "shop-example.net.txt",
+			want: DomainRegistration{
+				Domain:          "shop-example.net",
+				Registrar:       "NameCheap, Inc.",
+				RegistrarIANAID: "1068",
+				RegistrarURL:    "http://www.namecheap.com",
+				WhoisServer:     "whois.namecheap.com",
+				AbuseContact:    AbuseContact{Email: "abuse@namecheap.com", Phone: "+1.6613102107"},
+				Statuses:        []string{"ok"},
+				NameServers:     []string{"dns1.registrar-servers.com", "dns2.registrar-servers.com"},
+				CreatedAt:       time.Date(2019, 2, 3, 0, 0, 0, 0, time.UTC),
+				UpdatedAt:       time.Date(2024, 2, 3, 10, 11, 12, 0, time.UTC),
+				ExpiresAt:       time.Date(2025, 2, 3, 10, 11, 12, 0, time.UTC),
+				Redacted:        true,
+				PrivacyService:  "Privacy service provided by Withheld for Privacy ehf",
+				Sources:         []string{RegistrationSourceWhoIs},
+			},
+		},
+		{
+			name:    "Exposed registrant",
+			fixture: "personal.org.txt",
+			want: DomainRegistration{
+				Domain:            "personal.org",
+				Registrar:         "Example Registrar, LLC",
+				RegistrarIANAID:   "9999",
+				WhoisServer:       "whois.example-registrar.com",
+				Statuses:          []string{"clientTransferProhibited"},
+				Locks:             RegistrationLocks{Transfer: true},
+				NameServers:       []string{"ns1.personal.org"},
+				CreatedAt:         time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC),
+				UpdatedAt:         time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+				ExpiresAt:         time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+				RegistrantExposed: true,
+				Sources:           []string{RegistrationSourceWhoIs},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseWhoIsFixture(t, tt.fixture).Registration()
+			require.NotNil(t, got)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func Test_WhoIsResultRegistrationWithoutData(t *testing.T) {
+	assert.Nil(t, (&WhoIsResult{Error: "no whois server"}).Registration())
+	assert.Nil(t, (&WhoIsResult{RawData: &whoisparser.WhoisInfo{}}).Registration())
+}
+
+func Test_ParseWhoIsDate(t *testing.T) {
+	parsed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name   string
+		raw    string
+		parsed *time.Time
+		want   time.Time
+	}{
+		{"Parsed by whoisparser", "whatever", &parsed, time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC)},
+		{"Numeric zone", "2024-01-02T03:04:05+0100", nil, time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC)},
+		{"Day month year", "2.1.2024", nil, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"Compact", "20240102", nil, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"Empty", "", nil, time.Time{}},
+		{"Unparsable", "before the flood", nil, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseWhoIsDate(tt.raw, tt.parsed))
+		})
+	}
+}
+
+func Test_DomainRegistrationFindings(t *testing.T) {
+	at := time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		registration DomainRegistration
+		want         map[string]enums.SeverityType
+	}{
+		{
+			name: "Healthy registration",
+			registration: DomainRegistration{
+				Domain:    "example.com",
+				Statuses:  []string{StatusServerTransferProhibited},
+				Locks:     RegistrationLocks{Transfer: true},
+				ExpiresAt: at.AddDate(1, 0, 0),
+			},
+			want: map[string]enums.SeverityType{},
+		},
+		{
+			name: "Expired",
+			registration: DomainRegistration{
+				Domain:    "example.com",
+				ExpiresAt: at.AddDate(0, 0, -1),
+			},
+			want: map[string]enums.SeverityType{FindingDomainExpired: enums.SeverityTypeHigh},
+		},
+		{
+			name: "Expiring without transfer lock",
+			registration: DomainRegistration{
+				Domain:    "example.com",
+				Statuses:  []string{"ok"},
+				ExpiresAt: at.AddDate(0, 0, 10),
+			},
+			want: map[string]enums.SeverityType{
+				FindingDomainExpiring:       enums.SeverityTypeMedium,
+				FindingDomainNoTransferLock: enums.SeverityTypeLow,
+			},
+		},
+		{
+			name: "Unknown expiry and statuses",
+			registration: DomainRegistration{
+				Domain:            "example.com",
+				RegistrantExposed: true,
+			},
+			want: map[string]enums.SeverityType{FindingDomainRegistrantExposed: enums.SeverityTypeLow},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]enums.SeverityType{}
+			for _, f := range tt.registration.Findings(at) {
+				got[f.ID] = f.Severity
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_DomainRegistrationFindingsFromWhoIs(t *testing.T) {
+	reg := parseWhoIsFixture(t, "personal.org.txt").Registration()
+	require.NotNil(t, reg)
+
+	findings := reg.Findings(time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC))
+	require.Len(t, findings, 2)
+	assert.Equal(t, FindingDomainExpiring, findings[0].ID)
+	assert.Equal(t, "The registration of personal.org expires on 2024-12-31, in 11 days.", findings[0].Description)
+	assert.Equal(t, FindingDomainRegistrantExposed, findings[1].ID)
+	assert.Equal(t, enums.GetOwaspCategoryForCWE("CWE-359"), findings[1].Category)
+}
+
+func Test_WhoIsResultLogValue(t *testing.T) {
+	res := &WhoIsResult{RawData: &whoisparser.WhoisInfo{
+		Domain:     &whoisparser.Domain{Domain: "example.com"},
+		Registrar:  &whoisparser.Contact{Name: "Example Registrar, Inc.", Email: "abuse@registrar.example"},
+		Registrant: &whoisparser.Contact{Name: "Jane Doe", Email: "jane.doe@example.com", City: "Springfield", Country: "US"},
+	}}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("whois", slog.Any("result", res))
+	out := buf.String()
+	for _, v := range []string{"Jane Doe", "jane.doe@example.com", "Springfield"} {
+		assert.NotContains(t, out, v)
+	}
+	assert.Contains(t, out, `"name":"Example Registrar, Inc."`)
+	assert.Contains(t, out, `"email":"abuse@registrar.example"`)
+	assert.Contains(t, out, `"email":"[REDACTED]"`)
+	assert.Contains(t, out, `"country":"US"`)
+	assert.Contains(t, out, `"domain":"example.com"`)
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/kptm-tools/common/common/pkg/enums"
+)
+
+// FindingZoneTransfer is the ID of the findings of name servers allowing zone transfers.
+const FindingZoneTransfer = "dns-zone-transfer"
+
+// ZoneTransferResult is the outcome of AXFR attempts against the name servers
+// of a domain.
+type ZoneTransferResult struct {
+	Domain   string                `json:"domain"`
+	Attempts []ZoneTransferAttempt `json:"attempts"`
+	Findings []Finding             `json:"findings"`
+}
+
+// ZoneTransferAttempt is an AXFR attempt against a name server.
+type ZoneTransferAttempt struct {
+	NameServer string `json:"name_server"`
+	// Address is the address the transfer was attempted against, or allowed
+	// by, when the name server has several.
+	Address string `json:"address,omitempty"`
+	Allowed bool   `json:"allowed"`
+	// Records are the records leaked by the transfer.
+	Records []DNSRecord `json:"records,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// NewZoneTransferResult returns the result of the attempts, with a finding
+// for each name server which allowed the transfer.
+func NewZoneTransferResult(domain string, attempts []ZoneTransferAttempt) *ZoneTransferResult {
+	res := &ZoneTransferResult{Domain: domain, Attempts: attempts, Findings: []Finding{}}
+	for _, a := range attempts {
+		if !a.Allowed {
+			continue
+		}
+		evidence := fmt.Sprintf("AXFR %s", domain)
+		if a.Address != "" {
+			evidence += " @" + a.Address
+		}
+		res.Findings = append(res.Findings, Finding{
+			ID:    FindingZoneTransfer,
+			Title: "DNS zone transfer allowed",
+			Description: fmt.Sprintf("Name server %s allows anyone to transfer the %s zone, disclosing its %d records, including hosts which are not meant to be found.",
+				a.NameServer, domain, len(a.Records)),
+			Severity:    enums.SeverityTypeMedium,
+			Category:    enums.OwaspCategorySecurityMisconfiguration,
+			Remediation: "Restrict zone transfers (AXFR) to the secondary name servers, e.g., by IP address or TSIG key.",
+			Evidence:    evidence,
+		})
+	}
+	return res
+}
+
+// AllowedNameServers returns the name servers which allowed the transfer.
+func (r *ZoneTransferResult) AllowedNameServers() []string {
+	var servers []string
+	for _, a := range r.Attempts {
+		if a.Allowed {
+			servers = append(servers, a.NameServer)
+		}
+	}
+	return servers
+}
+
+// NameServers returns the names of the NS records of the domain.
+func (r *DNSLookupResult) NameServers() []string {
+	var servers []string
+	for _, record := range r.DNSRecords {
+		if record.Type != NSRecord || normalizeDNSName(record.Name) != normalizeDNSName(r.Domain) {
+			continue
+		}
+		if ns, ok := record.AsString(); ok {
+			servers = append(servers, ns)
+		}
+	}
+	return servers
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewZoneTransferResult(t *testing.T) {
+	records := []DNSRecord{{Type: "A", Name: "internal.example.com", Value: "10.0.0.1"}}
+
+	testCases := []struct {
+		name         string
+		attempts     []ZoneTransferAttempt
+		wantEvidence []string
+	}{
+		{
+			name: "Refused transfer",
+			attempts: []ZoneTransferAttempt{
+				{NameServer: "ns1.example.com", Address: "192.0.2.1"},
+			},
+			wantEvidence: nil,
+		},
+		{
+			name: "Allowed transfer",
+			attempts: []ZoneTransferAttempt{
+				{NameServer: "ns1.example.com", Address: "192.0.2.1"},
+				{NameServer: "ns2.example.com", Address: "192.0.2.2", Allowed: true, Records: records},
+			},
+			wantEvidence: []string{"AXFR example.com @192.0.2.2"},
+		},
+		{
+			name: "Allowed transfer without address",
+			attempts: []ZoneTransferAttempt{
+				{NameServer: "ns1.example.com", Allowed: true, Records: records},
+			},
+			wantEvidence: []string{"AXFR example.com"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := NewZoneTransferResult("example.com", tc.attempts)
+
+			require.Len(t, res.Findings, len(tc.wantEvidence))
+			for i, want := range tc.wantEvidence {
+				assert.Equal(t, FindingZoneTransfer, res.Findings[i].ID)
+				assert.Equal(t, want, res.Findings[i].Evidence)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kptm-tools/common/common/pkg/results"
+	"github.com/robfig/cron/v3"
+)
+
+// MissedRunPolicy defines what happens to runs that were due while no
+// scheduler was running, e.g., during a deployment.
+type MissedRunPolicy string
+
+const (
+	// MissedRunSkip drops the missed runs and waits for the next due time.
+	MissedRunSkip MissedRunPolicy = "Skip"
+
+	// MissedRunCatchUp fires a single run for all the missed ones, then
+	// resumes at the next due time.
+	MissedRunCatchUp MissedRunPolicy = "CatchUp"
+)
+
+func (p MissedRunPolicy) String() string {
+	return string(p)
+}
+
+// maxNextRunAttempts bounds the search for a due time outside of the
+// maintenance windows, so that a schedule fully covered by them cannot loop forever.
+const maxNextRunAttempts = 1000
+
+// ErrNoNextRun is returned when a schedule has no due time left, e.g., when
+// every run falls within a maintenance window.
+var ErrNoNextRun = errors.New("schedule has no next run")
+
+// Schedule represents a recurring scan of a target.
+// Exactly one of Cron or Interval must be set.
+type Schedule struct {
+	// ID is the unique identifier of the schedule
+	ID uuid.UUID `json:"id"`
+
+	// Target is the target to scan
+	Target results.Target `json:"target"`
+
+	// Cron is a standard 5-field cron expression, e.g., "0 3 * * 1-5"
+	Cron string `json:"cron,omitempty"`
+
+	// Interval is the fixed time between two runs
+	Interval time.Duration `json:"interval,omitempty"`
+
+	// TimeZone is the IANA time zone the Cron expression and maintenance
+	// windows are evaluated in. Defaults to UTC.
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// MaintenanceWindows are periods during which no scan is started.
+	// Runs falling within them are skipped.
+	MaintenanceWindows []Window `json:"maintenance_windows,omitempty"`
+
+	// Jitter is the maximum random delay added to each run, to spread the
+	// load of schedules sharing the same due time.
+	Jitter time.Duration `json:"jitter,omitempty"`
+
+	// MissedRunPolicy defines how runs missed while no scheduler was running
+	// are handled. Defaults to MissedRunSkip.
+	MissedRunPolicy MissedRunPolicy `json:"missed_run_policy,omitempty"`
+
+	// Paused stops the schedule from firing until it is resumed
+	Paused bool `json:"paused"`
+
+	// NextRun is the UTC time of the next run, before jitter
+	NextRun time.Time `json:"next_run"`
+
+	// LastRun is the UTC time the schedule last fired
+	LastRun time.Time `json:"last_run,omitempty"`
+}
+
+// Window is a maintenance window, either a single period between Start and
+// End, or a recurring period of Duration starting at every Cron activation.
+type Window struct {
+	Start time.Time `json:"start,omitempty"`
+	End   time.Time `json:"end,omitempty"`
+
+	Cron     string        `json:"cron,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// Validate checks the schedule definition.
+func (s *Schedule) Validate() error {
+	if s.Target.Value == "" {
+		return errors.New("schedule target is empty")
+	}
+	if (s.Cron == "") == (s.Interval == 0) {
+		return errors.New("schedule must define exactly one of cron or interval")
+	}
+	if s.Interval < 0 || s.Jitter < 0 {
+		return errors.New("schedule interval and jitter must be positive")
+	}
+	if s.Cron != "" {
+		if _, err := cron.ParseStandard(s.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression `%s`: %w", s.Cron, err)
+		}
+	}
+	if _, err := s.location(); err != nil {
+		return err
+	}
+	switch s.MissedRunPolicy {
+	case "", MissedRunSkip, MissedRunCatchUp:
+	default:
+		return fmt.Errorf("invalid missed run policy: %s", s.MissedRunPolicy)
+	}
+	for i, w := range s.MaintenanceWindows {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("invalid maintenance window %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the window definition.
+func (w Window) Validate() error {
+	switch {
+	case w.Cron != "":
+		if w.Duration <= 0 {
+			return errors.New("recurring window must have a positive duration")
+		}
+		if _, err := cron.ParseStandard(w.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression `%s`: %w", w.Cron, err)
+		}
+		return nil
+	case !w.Start.IsZero() && w.End.After(w.Start):
+		return nil
+	default:
+		return errors.New("window must define a cron and duration, or a start before its end")
+	}
+}
+
+// Contains reports whether t falls within the window, evaluating recurring
+// windows in the given location.
+func (w Window) Contains(t time.Time, loc *time.Location) bool {
+	if w.Cron == "" {
+		return !t.Before(w.Start) && t.Before(w.End)
+	}
+
+	sched, err := cron.ParseStandard(w.Cron)
+	if err != nil {
+		return false
+	}
+	// The window containing t, if any, is the first one starting after t-Duration
+	start := sched.Next(t.In(loc).Add(-w.Duration))
+	return !start.After(t)
+}
+
+// InMaintenance reports whether t falls within one of the maintenance windows.
+func (s *Schedule) InMaintenance(t time.Time) bool {
+	loc, err := s.location()
+	if err != nil {
+		return false
+	}
+	for _, w := range s.MaintenanceWindows {
+		if w.Contains(t, loc) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextRunAfter returns the first due time strictly after t that does not fall
+// within a maintenance window.
+func (s *Schedule) NextRunAfter(t time.Time) (time.Time, error) {
+	loc, err := s.location()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := t
+	for i := 0; i < maxNextRunAttempts; i++ {
+		if s.Cron != "" {
+			sched, err := cron.ParseStandard(s.Cron)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid cron expression `%s`: %w", s.Cron, err)
+			}
+			next = sched.Next(next.In(loc))
+		} else {
+			next = next.Add(s.Interval)
+		}
+
+		if next.IsZero() {
+			break
+		}
+		if !s.InMaintenance(next) {
+			return next.UTC(), nil
+		}
+	}
+
+	return time.Time{}, ErrNoNextRun
+}
+
+// FireAt returns the time the run due at NextRun fires, jitter included.
+// The jitter is derived from the schedule ID and due time, so every replica
+// computes the same value.
+func (s *Schedule) FireAt() time.Time {
+	if s.Jitter <= 0 {
+		return s.NextRun
+	}
+
+	h := fnv.New64a()
+	h.Write(s.ID[:])
+	h.Write([]byte(s.NextRun.UTC().Format(time.RFC3339Nano)))
+	return s.NextRun.Add(time.Duration(h.Sum64() % uint64(s.Jitter)))
+}
+
+func (s *Schedule) location() (*time.Location, error) {
+	if s.TimeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone `%s`: %w", s.TimeZone, err)
+	}
+	return loc, nil
+}
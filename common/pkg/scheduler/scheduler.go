@@ -0,0 +1,207 @@
+// Package scheduler fires recurring scans by publishing a ScanStartedEvent
+// for each due Schedule.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/events"
+)
+
+const (
+	defaultPollInterval     = 10 * time.Second
+	defaultMisfireThreshold = time.Minute
+)
+
+// Publisher publishes events, e.g., a *events.NatsEventBus.
+type Publisher interface {
+	PublishEvent(subject string, event any) error
+}
+
+// Scheduler fires due schedules. Several replicas can run against the same
+// Store: each run is fired by the replica that advances the schedule first.
+//
+// Runs are fired at most once: a schedule is advanced before its event is
+// published, so a run whose event fails to publish is not retried.
+type Scheduler struct {
+	store            Store
+	publisher        Publisher
+	pollInterval     time.Duration
+	misfireThreshold time.Duration
+	now              func() time.Time
+	Logger           *slog.Logger
+}
+
+// Option configures optional behaviour of a Scheduler.
+type Option func(*Scheduler)
+
+// WithPollInterval sets how often the scheduler looks for due schedules.
+func WithPollInterval(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.pollInterval = d
+	}
+}
+
+// WithMisfireThreshold sets how late a run can be fired before it is
+// considered missed and handled according to the schedule MissedRunPolicy.
+func WithMisfireThreshold(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.misfireThreshold = d
+	}
+}
+
+// WithClock sets the function used to get the current time.
+func WithClock(now func() time.Time) Option {
+	return func(s *Scheduler) {
+		s.now = now
+	}
+}
+
+// NewScheduler creates a Scheduler firing the schedules of store through publisher.
+func NewScheduler(store Store, publisher Publisher, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		store:            store,
+		publisher:        publisher,
+		pollInterval:     defaultPollInterval,
+		misfireThreshold: defaultMisfireThreshold,
+		now:              time.Now,
+		Logger:           slog.New(slog.Default().Handler()),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add validates and stores a schedule, computing its first run.
+// A new ID is assigned if the schedule has none.
+func (s *Scheduler) Add(ctx context.Context, sched Schedule) (Schedule, error) {
+	if err := sched.Validate(); err != nil {
+		return Schedule{}, err
+	}
+	if sched.ID == uuid.Nil {
+		sched.ID = uuid.New()
+	}
+	if sched.MissedRunPolicy == "" {
+		sched.MissedRunPolicy = MissedRunSkip
+	}
+
+	next, err := sched.NextRunAfter(s.now())
+	if err != nil {
+		return Schedule{}, err
+	}
+	sched.NextRun = next
+
+	if err := s.store.Put(ctx, sched); err != nil {
+		return Schedule{}, err
+	}
+	return sched, nil
+}
+
+// Remove deletes a schedule.
+func (s *Scheduler) Remove(ctx context.Context, id uuid.UUID) error {
+	return s.store.Delete(ctx, id)
+}
+
+// Run fires due schedules every poll interval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil {
+			s.Logger.Error("Failed to fire schedules", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick fires every schedule due at the current time.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	schedules, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	var errs []error
+	for _, sched := range schedules {
+		if err := s.fire(ctx, sched, now); err != nil {
+			errs = append(errs, fmt.Errorf("schedule `%s`: %w", sched.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fire advances sched past now and, unless the run is skipped, publishes its
+// ScanStartedEvent.
+func (s *Scheduler) fire(ctx context.Context, sched Schedule, now time.Time) error {
+	if sched.Paused || sched.NextRun.IsZero() {
+		return nil
+	}
+
+	fireAt := sched.FireAt()
+	if now.Before(fireAt) {
+		return nil
+	}
+
+	next := sched.NextRun
+	for !next.After(now) {
+		var err error
+		if next, err = sched.NextRunAfter(next); err != nil {
+			return err
+		}
+	}
+
+	missed := now.Sub(fireAt) > s.misfireThreshold
+	skip := (missed && sched.MissedRunPolicy != MissedRunCatchUp) || sched.InMaintenance(now)
+
+	lastRun := now
+	if skip {
+		lastRun = sched.LastRun
+	}
+
+	advanced, err := s.store.Advance(ctx, sched.ID, sched.NextRun, next, lastRun)
+	if err != nil || !advanced {
+		// Not advanced: another replica handled this run
+		return err
+	}
+
+	logger := s.Logger.With(
+		slog.String("schedule_id", sched.ID.String()),
+		slog.String("target", sched.Target.Value),
+		slog.Time("due", sched.NextRun),
+		slog.Time("next_run", next),
+	)
+
+	if skip {
+		logger.Info("Skipped scheduled scan", slog.Bool("missed", missed))
+		return nil
+	}
+
+	subject := string(enums.ScanStartedEventSubject)
+	if sched.Target.TenantID != "" {
+		if subject, err = events.TenantSubject(sched.Target.TenantID, enums.ScanStartedEventSubject); err != nil {
+			return err
+		}
+	}
+
+	evt := events.NewScanStartedEvent(uuid.New(), sched.Target)
+	if err := s.publisher.PublishEvent(subject, evt); err != nil {
+		return fmt.Errorf("failed to publish scan started event: %w", err)
+	}
+
+	logger.Info("Started scheduled scan", slog.String("scan_id", evt.ScanID.String()), slog.Bool("missed", missed))
+	return nil
+}
@@ -0,0 +1,225 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/events"
+	"github.com/kptm-tools/common/common/pkg/results"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type publishedEvent struct {
+	subject string
+	event   events.ScanStartedEvent
+}
+
+type fakePublisher struct {
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+func (p *fakePublisher) PublishEvent(subject string, event any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, publishedEvent{subject: subject, event: event.(events.ScanStartedEvent)})
+	return nil
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestScheduler(store Store, publisher Publisher, clock *fakeClock) *Scheduler {
+	s := NewScheduler(store, publisher, WithClock(clock.Now), WithMisfireThreshold(time.Minute))
+	s.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return s
+}
+
+var testTarget = results.Target{Alias: "Example", Value: "example.com", Type: enums.Domain}
+
+func Test_NextRunAfter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule Schedule
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Interval",
+			schedule: Schedule{Interval: 6 * time.Hour},
+			after:    time.Date(2025, 1, 6, 1, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 6, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Cron in time zone",
+			schedule: Schedule{Cron: "0 3 * * *", TimeZone: "America/Mexico_City"},
+			after:    time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Skips one-off maintenance window",
+			schedule: Schedule{
+				Cron: "0 * * * *",
+				MaintenanceWindows: []Window{
+					{Start: time.Date(2025, 1, 6, 1, 30, 0, 0, time.UTC), End: time.Date(2025, 1, 6, 3, 30, 0, 0, time.UTC)},
+				},
+			},
+			after:    time.Date(2025, 1, 6, 1, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 6, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Skips recurring weekend window",
+			schedule: Schedule{
+				Cron:               "0 3 * * *",
+				MaintenanceWindows: []Window{{Cron: "0 0 * * 6", Duration: 48 * time.Hour}},
+			},
+			// Friday 2025-01-10
+			after:    time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 13, 3, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next, err := tc.schedule.NextRunAfter(tc.after)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, next)
+		})
+	}
+
+	always := Schedule{Interval: time.Hour, MaintenanceWindows: []Window{{Cron: "0 * * * *", Duration: time.Hour}}}
+	_, err := always.NextRunAfter(time.Now())
+	assert.ErrorIs(t, err, ErrNoNextRun)
+}
+
+func Test_ScheduleValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		schedule    Schedule
+		expectError bool
+	}{
+		{name: "Cron", schedule: Schedule{Target: testTarget, Cron: "*/15 * * * *"}},
+		{name: "Interval", schedule: Schedule{Target: testTarget, Interval: time.Hour, MissedRunPolicy: MissedRunCatchUp}},
+		{name: "Both cron and interval", schedule: Schedule{Target: testTarget, Cron: "* * * * *", Interval: time.Hour}, expectError: true},
+		{name: "Neither cron nor interval", schedule: Schedule{Target: testTarget}, expectError: true},
+		{name: "Invalid cron", schedule: Schedule{Target: testTarget, Cron: "every day"}, expectError: true},
+		{name: "Invalid time zone", schedule: Schedule{Target: testTarget, Interval: time.Hour, TimeZone: "Mars/Olympus"}, expectError: true},
+		{name: "Missing target", schedule: Schedule{Interval: time.Hour}, expectError: true},
+		{name: "Invalid window", schedule: Schedule{Target: testTarget, Interval: time.Hour, MaintenanceWindows: []Window{{Cron: "0 0 * * *"}}}, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.schedule.Validate()
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_FireAt(t *testing.T) {
+	s := Schedule{ID: uuid.New(), Jitter: 5 * time.Minute, NextRun: time.Date(2025, 1, 6, 3, 0, 0, 0, time.UTC)}
+
+	fireAt := s.FireAt()
+	assert.False(t, fireAt.Before(s.NextRun))
+	assert.True(t, fireAt.Before(s.NextRun.Add(s.Jitter)))
+	assert.Equal(t, fireAt, s.FireAt(), "jitter must be deterministic across replicas")
+}
+
+func Test_SchedulerTick(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 6, 0, 30, 0, 0, time.UTC)
+
+	t.Run("Fires due schedule once across replicas", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		store := NewMemoryStore()
+		publisher := &fakePublisher{}
+		replicas := []*Scheduler{
+			newTestScheduler(store, publisher, clock),
+			newTestScheduler(store, publisher, clock),
+		}
+
+		target := testTarget
+		target.TenantID = "acme"
+		sched, err := replicas[0].Add(ctx, Schedule{Target: target, Cron: "0 * * * *"})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 1, 6, 1, 0, 0, 0, time.UTC), sched.NextRun)
+
+		require.NoError(t, replicas[1].Tick(ctx))
+		assert.Empty(t, publisher.events, "schedule is not due yet")
+
+		clock.now = sched.NextRun.Add(5 * time.Second)
+		var wg sync.WaitGroup
+		for _, r := range replicas {
+			wg.Add(1)
+			go func(r *Scheduler) {
+				defer wg.Done()
+				assert.NoError(t, r.Tick(ctx))
+			}(r)
+		}
+		wg.Wait()
+
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, "tenant.acme.event.scanstarted", publisher.events[0].subject)
+		assert.Equal(t, target, publisher.events[0].event.Target)
+		assert.Equal(t, "acme", publisher.events[0].event.TenantID)
+
+		stored, err := store.Get(ctx, sched.ID)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 1, 6, 2, 0, 0, 0, time.UTC), stored.NextRun)
+		assert.Equal(t, clock.now, stored.LastRun)
+	})
+
+	t.Run("Missed runs", func(t *testing.T) {
+		for _, policy := range []MissedRunPolicy{MissedRunSkip, MissedRunCatchUp} {
+			clock := &fakeClock{now: start}
+			store := NewMemoryStore()
+			publisher := &fakePublisher{}
+			s := newTestScheduler(store, publisher, clock)
+
+			sched, err := s.Add(ctx, Schedule{Target: testTarget, Interval: time.Hour, MissedRunPolicy: policy})
+			require.NoError(t, err)
+
+			// Scheduler down for five runs
+			clock.now = start.Add(5*time.Hour + 10*time.Minute)
+			require.NoError(t, s.Tick(ctx))
+
+			if policy == MissedRunCatchUp {
+				assert.Len(t, publisher.events, 1, "catch up fires a single run")
+			} else {
+				assert.Empty(t, publisher.events)
+			}
+
+			stored, err := store.Get(ctx, sched.ID)
+			require.NoError(t, err)
+			assert.Equal(t, start.Add(6*time.Hour), stored.NextRun, "interval stays anchored on the first run")
+		}
+	})
+
+	t.Run("Paused schedule", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		store := NewMemoryStore()
+		publisher := &fakePublisher{}
+		s := newTestScheduler(store, publisher, clock)
+
+		_, err := s.Add(ctx, Schedule{Target: testTarget, Interval: time.Minute, Paused: true})
+		require.NoError(t, err)
+
+		clock.now = start.Add(time.Minute)
+		require.NoError(t, s.Tick(ctx))
+		assert.Empty(t, publisher.events)
+	})
+}
@@ -0,0 +1,209 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// ErrScheduleNotFound is returned when a schedule does not exist in the Store.
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// Store persists schedules. Implementations shared by several scheduler
+// replicas must implement Advance atomically, as it is what prevents a run
+// from being fired twice.
+type Store interface {
+	// List returns every schedule.
+	List(ctx context.Context) ([]Schedule, error)
+
+	// Get returns the schedule with the given ID, or ErrScheduleNotFound.
+	Get(ctx context.Context, id uuid.UUID) (Schedule, error)
+
+	// Put creates or replaces a schedule.
+	Put(ctx context.Context, s Schedule) error
+
+	// Delete removes a schedule.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Advance moves the NextRun of a schedule from expected to next, and sets
+	// its LastRun, only if NextRun still equals expected. It returns false if
+	// another replica advanced the schedule first.
+	Advance(ctx context.Context, id uuid.UUID, expected, next, lastRun time.Time) (bool, error)
+}
+
+// MemoryStore is an in-memory Store, suitable for a single scheduler replica and tests.
+type MemoryStore struct {
+	mu        sync.Mutex
+	schedules map[uuid.UUID]Schedule
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		schedules: make(map[uuid.UUID]Schedule),
+	}
+}
+
+func (m *MemoryStore) List(_ context.Context) ([]Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schedules := make([]Schedule, 0, len(m.schedules))
+	for _, s := range m.schedules {
+		schedules = append(schedules, s)
+	}
+	return schedules, nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, id uuid.UUID) (Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.schedules[id]
+	if !ok {
+		return Schedule{}, ErrScheduleNotFound
+	}
+	return s, nil
+}
+
+func (m *MemoryStore) Put(_ context.Context, s Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.schedules[s.ID] = s
+	return nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.schedules, id)
+	return nil
+}
+
+func (m *MemoryStore) Advance(_ context.Context, id uuid.UUID, expected, next, lastRun time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.schedules[id]
+	if !ok {
+		return false, ErrScheduleNotFound
+	}
+	if !s.NextRun.Equal(expected) {
+		return false, nil
+	}
+
+	s.NextRun, s.LastRun = next, lastRun
+	m.schedules[id] = s
+	return true, nil
+}
+
+// KVStore is a Store backed by a NATS JetStream key-value bucket, which lets
+// several scheduler replicas share schedules. Advance relies on the bucket
+// revisions for compare-and-swap.
+type KVStore struct {
+	kv nats.KeyValue
+}
+
+// NewKVStore creates a KVStore on the given bucket, e.g., one obtained with
+// js.CreateKeyValue(&nats.KeyValueConfig{Bucket: "scan_schedules"}).
+func NewKVStore(kv nats.KeyValue) *KVStore {
+	return &KVStore{
+		kv: kv,
+	}
+}
+
+func (k *KVStore) List(_ context.Context) ([]Schedule, error) {
+	keys, err := k.kv.Keys()
+	if errors.Is(err, nats.ErrNoKeysFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	schedules := make([]Schedule, 0, len(keys))
+	for _, key := range keys {
+		s, _, err := k.get(key)
+		if errors.Is(err, ErrScheduleNotFound) {
+			// Deleted since listed
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, nil
+}
+
+func (k *KVStore) Get(_ context.Context, id uuid.UUID) (Schedule, error) {
+	s, _, err := k.get(id.String())
+	return s, err
+}
+
+func (k *KVStore) Put(_ context.Context, s Schedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule: %w", err)
+	}
+	if _, err := k.kv.Put(s.ID.String(), data); err != nil {
+		return fmt.Errorf("failed to store schedule `%s`: %w", s.ID, err)
+	}
+	return nil
+}
+
+func (k *KVStore) Delete(_ context.Context, id uuid.UUID) error {
+	if err := k.kv.Delete(id.String()); err != nil && !errors.Is(err, nats.ErrKeyNotFound) {
+		return fmt.Errorf("failed to delete schedule `%s`: %w", id, err)
+	}
+	return nil
+}
+
+func (k *KVStore) Advance(_ context.Context, id uuid.UUID, expected, next, lastRun time.Time) (bool, error) {
+	s, revision, err := k.get(id.String())
+	if err != nil {
+		return false, err
+	}
+	if !s.NextRun.Equal(expected) {
+		return false, nil
+	}
+
+	s.NextRun, s.LastRun = next, lastRun
+	data, err := json.Marshal(s)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal schedule: %w", err)
+	}
+
+	if _, err := k.kv.Update(id.String(), data, revision); err != nil {
+		if errors.Is(err, nats.ErrKeyExists) {
+			// Another replica updated the schedule since we read it
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to advance schedule `%s`: %w", id, err)
+	}
+	return true, nil
+}
+
+func (k *KVStore) get(key string) (Schedule, uint64, error) {
+	entry, err := k.kv.Get(key)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return Schedule{}, 0, ErrScheduleNotFound
+	}
+	if err != nil {
+		return Schedule{}, 0, fmt.Errorf("failed to get schedule `%s`: %w", key, err)
+	}
+
+	var s Schedule
+	if err := json.Unmarshal(entry.Value(), &s); err != nil {
+		return Schedule{}, 0, fmt.Errorf("failed to unmarshal schedule `%s`: %w", key, err)
+	}
+	return s, entry.Revision(), nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/kptm-tools/common/common/pkg/customerrors"
 	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/results/tools"
 	"github.com/kptm-tools/common/common/pkg/utils/validation"
 )
 
@@ -14,25 +15,13 @@ type ToolCompatibilityChecker interface {
 	CanRunTool(toolName enums.ToolName, hc *validation.HostClassification) bool
 }
 
-// DefaultToolCompatibilityChecker provides a standard implementation
+// DefaultToolCompatibilityChecker provides a standard implementation based on
+// the target types of the tools registered with tools.RegisterTool.
 type DefaultToolCompatibilityChecker struct{}
 
 func (c *DefaultToolCompatibilityChecker) CanRunTool(toolName enums.ToolName, hc *validation.HostClassification) bool {
-	switch toolName {
-	case enums.ToolWhoIs:
-		return hc.Type == enums.Domain || hc.Type == enums.Subdomain
-	case enums.ToolDNSLookup:
-		return hc.Type == enums.Domain || hc.Type == enums.Subdomain
-	case enums.ToolHarvester:
-		return hc.Type == enums.Domain || hc.Type == enums.Subdomain
-	case enums.ToolNmap:
-		return hc.Type == enums.IP ||
-			hc.Type == enums.Domain ||
-			hc.Type == enums.Subdomain
-
-	default:
-		return false
-	}
+	def, ok := tools.LookupTool(toolName)
+	return ok && def.CanRun(hc.Type)
 }
 
 // NewToolCompatibilityChecker creates a new default compatibility checker
@@ -54,7 +43,7 @@ func ValidateHostForTool(value string, tool enums.ToolName) (string, error) {
 	if !checker.CanRunTool(tool, hostClass) {
 		return "", customerrors.NewToolIncompatibleError(tool, hostClass.Type.String())
 	}
-	if tool == enums.ToolDNSLookup || tool == enums.ToolWhoIs || tool == enums.ToolHarvester {
+	if def, _ := tools.LookupTool(tool); def.BaseDomain && hostClass.Type != enums.IP {
 		domain, err := hostClass.GetBaseDomain()
 		if err != nil {
 			return "", fmt.Errorf("failed to extract and validate domain %w", err)
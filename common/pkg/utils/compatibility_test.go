@@ -6,6 +6,7 @@ import (
 
 	"github.com/kptm-tools/common/common/pkg/customerrors"
 	"github.com/kptm-tools/common/common/pkg/enums"
+	"github.com/kptm-tools/common/common/pkg/results/tools"
 	"github.com/kptm-tools/common/common/pkg/utils/validation"
 	"github.com/stretchr/testify/assert"
 )
@@ -36,7 +37,7 @@ func Test_CanRunTool(t *testing.T) {
 			name:           "WhoIs on IP",
 			tool:           enums.ToolWhoIs,
 			hostType:       enums.IP,
-			expectedResult: false,
+			expectedResult: true,
 		},
 		{
 			name:           "DNSLookup on Domain",
@@ -140,6 +141,14 @@ func Test_ValidateHostForTool(t *testing.T) {
 			expectError:            false,
 			expectIncompatibleTool: false,
 		},
+		{
+			name:                   "Valid IPv6 for Nmap",
+			value:                  "2001:db8::10",
+			tool:                   enums.ToolNmap,
+			expected:               "2001:db8::10",
+			expectError:            false,
+			expectIncompatibleTool: false,
+		},
 		// Invalid scenarios
 		{
 			name:                   "Subdomain for WhoIs",
@@ -153,6 +162,22 @@ func Test_ValidateHostForTool(t *testing.T) {
 			name:                   "IP for WhoIs",
 			value:                  "192.168.1.1",
 			tool:                   enums.ToolWhoIs,
+			expected:               "192.168.1.1",
+			expectError:            false,
+			expectIncompatibleTool: false,
+		},
+		{
+			name:                   "IPv6 for WhoIs",
+			value:                  "2001:db8::10",
+			tool:                   enums.ToolWhoIs,
+			expected:               "2001:db8::10",
+			expectError:            false,
+			expectIncompatibleTool: false,
+		},
+		{
+			name:                   "IP for Harvester",
+			value:                  "192.168.1.1",
+			tool:                   enums.ToolHarvester,
 			expected:               "",
 			expectError:            true,
 			expectIncompatibleTool: true,
@@ -194,6 +219,33 @@ func Test_ValidateHostForTool(t *testing.T) {
 	}
 }
 
+type subdomainTakeoverResult struct{}
+
+func (r *subdomainTakeoverResult) GetToolName() enums.ToolName { return "SubdomainTakeover" }
+
+func Test_RegisteredToolCompatibility(t *testing.T) {
+	tools.MustRegisterTool(tools.ToolDefinition{
+		Name:        "SubdomainTakeover",
+		Subject:     "event.subdomaintakeover",
+		NewResult:   func() tools.IToolResult { return &subdomainTakeoverResult{} },
+		TargetTypes: []enums.TargetType{enums.Subdomain},
+	})
+	t.Cleanup(func() { tools.UnregisterTool("SubdomainTakeover") })
+
+	checker := NewToolCompatibilityChecker()
+	assert.True(t, checker.CanRunTool("SubdomainTakeover", &validation.HostClassification{Type: enums.Subdomain}))
+	assert.False(t, checker.CanRunTool("SubdomainTakeover", &validation.HostClassification{Type: enums.Domain}))
+
+	// Tools not running against the base domain get the host as is
+	target, err := ValidateHostForTool("shop.example.com", "SubdomainTakeover")
+	assert.NoError(t, err)
+	assert.Equal(t, "shop.example.com", target)
+
+	_, err = ValidateHostForTool("example.com", "SubdomainTakeover")
+	var errIncompatibleTool *customerrors.ToolIncompatibleError
+	assert.ErrorAs(t, err, &errIncompatibleTool)
+}
+
 func Test_ClassifyValidationErrorCode(t *testing.T) {
 	testCases := []struct {
 		name  string
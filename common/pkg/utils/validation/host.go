@@ -41,6 +41,16 @@ func ClassifyHostValue(value string) (*HostClassification, error) {
 		}, nil
 	}
 
+	// IPv6 addresses may be bracketed, e.g., [2001:db8::1]:8443
+	if IsValidIPv6(strings.Trim(baseValue, "[]")) || isBracketedIPv6(normalizedValue) {
+		return &HostClassification{
+			RawValue:        value,
+			NormalizedValue: normalizedValue,
+			Type:            enums.IP,
+			Classification:  "IPv6",
+		}, nil
+	}
+
 	// Extract host name
 	domain, err := ExtractHostName(normalizedValue)
 	if err != nil {
@@ -107,3 +117,9 @@ func (hc *HostClassification) GetBaseDomain() (string, error) {
 		return hostName, nil
 	}
 }
+
+// isBracketedIPv6 reports whether the host of a URL is a bracketed IPv6 address.
+func isBracketedIPv6(rawURL string) bool {
+	host, err := ExtractHostName(rawURL)
+	return err == nil && strings.Contains(rawURL, "["+host+"]") && IsValidIPv6(host)
+}
@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/kptm-tools/common/common/pkg/enums"
@@ -25,6 +26,23 @@ func Test_ClassifyHostValue(t *testing.T) {
 			expectedNormalized: "http://192.168.1.1",
 			expectError:        false,
 		},
+		// IPv6 Classification Tests
+		{
+			name:               "Valid IPv6 Address",
+			input:              "2001:db8::1",
+			expectedType:       enums.IP,
+			expectedClassif:    "IPv6",
+			expectedNormalized: "http://[2001:db8::1]",
+			expectError:        false,
+		},
+		{
+			name:               "Bracketed IPv6 URL with port",
+			input:              "https://[2001:db8::1]:8443/login",
+			expectedType:       enums.IP,
+			expectedClassif:    "IPv6",
+			expectedNormalized: "https://[2001:db8::1]:8443/login",
+			expectError:        false,
+		},
 		// Top-Level Domain Tests
 		{
 			name:               "Simple Domain",
@@ -105,6 +123,10 @@ func Test_ClassifyHostValue(t *testing.T) {
 			assert.Equal(t, tc.expectedClassif, result.Classification,
 				"Classification does not match expected")
 
+			// The normalized value must be a valid URL
+			_, err = url.Parse(result.NormalizedValue)
+			assert.NoError(t, err, "Normalized value is not a valid URL")
+
 		})
 	}
 
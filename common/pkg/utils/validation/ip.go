@@ -7,3 +7,9 @@ func IsValidIPv4(ip string) bool {
 	return net.ParseIP(ip) != nil && net.ParseIP(ip).To4() != nil
 
 }
+
+// IsValidIPv6 validates whether a string is a valid IPv6 address.
+func IsValidIPv6(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.To4() == nil
+}
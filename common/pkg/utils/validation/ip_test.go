@@ -23,3 +23,27 @@ func TestIsValidIPv4(t *testing.T) {
 		})
 	}
 }
+
+func TestIsValidIPv6(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "Valid IPv6 address", input: "2001:db8::1", expected: true},
+		{name: "Loopback", input: "::1", expected: true},
+		{name: "IPv4 address", input: "192.168.1.1", expected: false},
+		{name: "IPv4-mapped IPv6 address", input: "::ffff:192.168.1.1", expected: false},
+		{name: "Invalid IPv6 address", input: "2001:db8::g", expected: false},
+		{name: "Empty string", input: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := IsValidIPv6(tt.input)
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -27,12 +27,18 @@ func ExtractHostName(rawURL string) (string, error) {
 	return hostName, nil
 }
 
-// NormalizeURL prefixes the protocol if it's missing in the URL
+// NormalizeURL prefixes the protocol if it's missing in the URL, and brackets
+// bare IPv6 addresses, e.g., http://[2001:db8::1] for 2001:db8::1
 func NormalizeURL(url string) string {
-	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-		url = "http://" + url
+	scheme := "http://"
+	if strings.HasPrefix(url, "https://") {
+		scheme = "https://"
 	}
-	return url
+	host := strings.TrimPrefix(url, scheme)
+	if IsValidIPv6(host) {
+		host = "[" + host + "]"
+	}
+	return scheme + host
 }
 
 // ExtractTopLevelDomain extracts the top level domain plus one more label
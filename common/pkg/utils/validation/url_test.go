@@ -116,6 +116,21 @@ func Test_NormalizeURL(t *testing.T) {
 			input:    "http://example.com",
 			expected: "http://example.com",
 		},
+		{
+			name:     "IPv6 address",
+			input:    "2001:db8::1",
+			expected: "http://[2001:db8::1]",
+		},
+		{
+			name:     "IPv6 URL without brackets",
+			input:    "https://2001:db8::1",
+			expected: "https://[2001:db8::1]",
+		},
+		{
+			name:     "Bracketed IPv6 URL",
+			input:    "http://[2001:db8::1]:8080/",
+			expected: "http://[2001:db8::1]:8080/",
+		},
 		{
 			name:     "Complex URL without protocol",
 			input:    "subdomain.example.co.uk/path",